@@ -15,6 +15,7 @@ package main
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"github.com/adobe/cluster-registry/pkg/apiserver/docs"
 	"github.com/adobe/cluster-registry/pkg/apiserver/event"
 	"github.com/adobe/cluster-registry/pkg/apiserver/web"
@@ -26,17 +27,24 @@ import (
 	"github.com/adobe/cluster-registry/pkg/k8s"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/apiserver"
 	"github.com/adobe/cluster-registry/pkg/sqs"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/eko/gocache/lib/v4/cache"
-	"github.com/eko/gocache/lib/v4/store"
 	redisstore "github.com/eko/gocache/store/redis/v4"
 	"github.com/labstack/gommon/log"
 	"github.com/redis/go-redis/v9"
 	echoSwagger "github.com/swaggo/echo-swagger"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout bounds the time to wait for the requests being served on shutdown
+const shutdownTimeout = 30 * time.Second
+
 // Version it's passed as ldflags in the build process
 var Version = "dev"
 
@@ -65,23 +73,36 @@ func main() {
 	log.SetLevel(appConfig.LogLevel)
 	log.Debugf("Config loaded successfully %+v:", appConfig)
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Enabled:     appConfig.TracingEnabled,
+		Endpoint:    appConfig.TracingEndpoint,
+		SampleRatio: appConfig.TracingSampleRatio,
+		ServiceName: web.ServiceName,
+	})
+	if err != nil {
+		log.Fatalf("Cannot set up tracing: %s", err.Error())
+		return
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Errorf("Failed to flush traces: %s", err.Error())
+		}
+	}()
+
 	m := monitoring.NewMetrics("cluster_registry_api", false)
 	db := database.NewDb(appConfig, m)
-	q, err := sqs.NewSQS(sqs.Config{
-		AWSRegion:         appConfig.SqsAwsRegion,
-		Endpoint:          appConfig.SqsEndpoint,
-		QueueName:         appConfig.SqsQueueName,
+	q, err := sqs.NewQueue(appConfig, sqs.Config{
 		BatchSize:         appConfig.SqsBatchSize,
 		VisibilityTimeout: 120,
 		WaitSeconds:       appConfig.SqsWaitSeconds,
 		RunInterval:       appConfig.SqsRunInterval,
 		RunOnce:           false,
 		MaxHandlers:       10,
-		BusyTimeout:       30,
+		Metrics:           m,
 	})
 
 	if err != nil {
-		log.Fatalf("Cannot create SQS client: %s", err.Error())
+		log.Fatalf("Cannot create queue client: %s", err.Error())
 		return
 	}
 
@@ -108,45 +129,44 @@ func main() {
 	redisStore := redisstore.NewRedis(redisClient)
 	cacheManager := cache.New[string](redisStore)
 
-	handler := event.NewClusterUpdateHandler(db)
-	q.RegisterHandler(func(msg *awssqs.Message) {
-		log.Debugf("Received message: %s", *msg.MessageId)
-		e, err := sqs.NewEvent(msg)
-		if err != nil {
-			log.Errorf("Cannot create event from message: %s", err.Error())
-			return
-		}
-		if e.Type != sqs.ClusterUpdateEvent {
-			log.Infof("Not interested in event of type %s, skipping", e.Type)
-			return
-		}
-		log.Debugf("Handling event for message: %s", *msg.MessageId)
-		if err = handler.Handle(e); err != nil {
-			log.Errorf("Failed to handle event: %s", err.Error())
-			return
-		}
-		if err = q.Delete(msg); err != nil {
-			log.Errorf("Failed to delete message: %s", err.Error())
-			return
-		}
-
-		if val, ok := msg.MessageAttributes[sqs.MessageAttributeSkipCacheInvalidation]; ok && *val.StringValue == "true" {
-			log.Debugf("Skipping cache invalidation")
-			return
-		}
+	var deadLetters sqs.DeadLetterStore
+	if appConfig.QueueBackend == config.QueueBackendMemory {
+		deadLetters = sqs.NewMemoryDeadLetterStore()
+	} else {
+		deadLetters = sqs.NewRedisDeadLetterStore(redisClient, "cluster-registry:dead-letters")
+	}
 
-		log.Debugf("Invalidating clusters cache")
-		err = cacheManager.Invalidate(context.Background(), store.WithInvalidateTags([]string{"clusters"}))
+	dispatcher, err := sqs.NewDispatcher(q, sqs.DispatcherOptions{
+		UnknownEvents:   sqs.RequeueUnknownEvents,
+		RequeueDelay:    30,
+		DeadLetter:      deadLetters,
+		MaxAttempts:     appConfig.QueueMaxAttempts,
+		RetryBackoff:    10,
+		MaxRetryBackoff: 900,
+	})
+	if err != nil {
+		log.Fatalf("Cannot create event dispatcher: %s", err.Error())
+		return
+	}
+	dispatcher.Use(sqs.Tracing(), sqs.Logging(), sqs.Recording(m))
+	if appConfig.QueueTrustedKeysFile != "" {
+		verifier, err := sqs.NewVerifierFromFile(appConfig.QueueTrustedKeysFile)
 		if err != nil {
-			log.Errorf("Failed to invalidate clusters cache: %s", err.Error())
+			log.Fatalf("Cannot load trusted cluster keys: %s", err.Error())
 			return
 		}
-	})
+		dispatcher.Use(event.Authenticate(verifier, appConfig.QueueSigningIdentity, appConfig.QueueSignatureMaxAge, m))
+	} else {
+		log.Warnf("QUEUE_TRUSTED_KEYS_FILE is not set, the signatures of the cluster updates are not verified")
+	}
+	dispatcher.Use(event.InvalidateCache(cacheManager))
+	dispatcher.Register(event.NewClusterUpdateHandler(db, m, sqs.NewReplier(appConfig, sqs.Config{})), sqs.AckOnSuccess)
+	dispatcher.Register(event.NewClusterHeartbeatHandler(db, m), sqs.AckOnSuccess)
 
 	a := api.NewRouter()
 	status := api.StatusSessions{
 		Db:        db,
-		SQS:       q,
+		Queue:     q,
 		AppConfig: appConfig,
 	}
 
@@ -165,9 +185,35 @@ func main() {
 	v2 := a.Group("/api/v2")
 	hv2 := apiv2.NewHandler(appConfig, db, m, &k8s.ClientProvider{}, cacheManager)
 	hv2.Register(v2)
+	apiv2.NewDeadLetterHandler(appConfig, m, deadLetters, q).Register(v2)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	go q.Poll()
+	polling := make(chan struct{})
+	go func() {
+		defer close(polling)
+		if err := q.Poll(ctx, dispatcher.Handle); err != nil {
+			log.Errorf("Stopped polling the queue: %s", err.Error())
+		}
+	}()
 
 	m.Use(a)
-	a.Logger.Fatal(a.Start(":8080"))
+	go func() {
+		if err := a.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			a.Logger.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := a.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("Failed to shut down the server: %s", err.Error())
+	}
+
+	// wait for the in-flight messages to be processed
+	<-polling
 }
@@ -13,14 +13,18 @@ governing permissions and limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/base64"
 	"flag"
 	"fmt"
 	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+	"github.com/adobe/cluster-registry/pkg/client/admission"
 	"github.com/adobe/cluster-registry/pkg/client/controllers"
+	clusterdiscovery "github.com/adobe/cluster-registry/pkg/client/discovery"
 	"github.com/adobe/cluster-registry/pkg/config"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/client"
 	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
@@ -28,10 +32,12 @@ import (
 	"net/http"
 	"os"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"time"
 
 	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/client/webhook"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -95,6 +101,13 @@ func main() {
 		ServiceMetadata: configv1.ServiceMetadataConfig{
 			WatchedGVKs:         []configv1.WatchedGVK{},
 			ServiceIdAnnotation: "adobe.serviceid",
+			Debounce:            metav1.Duration{Duration: 10 * time.Second},
+		},
+		Heartbeat: configv1.HeartbeatConfig{
+			Interval: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		Discovery: configv1.DiscoveryConfig{
+			Interval: metav1.Duration{Duration: 10 * time.Minute},
 		},
 	}
 	options := ctrl.Options{
@@ -143,39 +156,127 @@ func main() {
 		os.Exit(1)
 	}
 
-	q, err := sqs.NewSQS(sqs.Config{
-		AWSRegion: appConfig.SqsAwsRegion,
-		Endpoint:  appConfig.SqsEndpoint,
-		QueueName: appConfig.SqsQueueName,
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     appConfig.TracingEnabled,
+		Endpoint:    appConfig.TracingEndpoint,
+		SampleRatio: appConfig.TracingSampleRatio,
+		ServiceName: "cluster-registry-client",
 	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush traces")
+		}
+	}()
+
+	q, err := sqs.NewQueue(appConfig, sqs.Config{})
 
 	if err != nil {
-		setupLog.Error(err, "cannot create SQS client")
+		setupLog.Error(err, "cannot create queue client")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
-		Scheme: mgr.GetScheme(),
-		Queue:  q,
-		CAData: base64.StdEncoding.EncodeToString(mgr.GetConfig().CAData),
+		Client:  mgr.GetClient(),
+		Log:     ctrl.Log.WithName("controllers").WithName("Cluster"),
+		Scheme:  mgr.GetScheme(),
+		Queue:   q,
+		CAData:  base64.StdEncoding.EncodeToString(mgr.GetConfig().CAData),
+		ReplyTo: appConfig.QueueAckName,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.ClusterTagReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ClusterTags"),
+		Recorder: mgr.GetEventRecorderFor("clustertags-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterTags")
+		os.Exit(1)
+	}
+
+	if appConfig.QueueAckName != "" {
+		ackQueue, err := sqs.NewNamedQueue(appConfig, appConfig.QueueAckName, sqs.Config{
+			BatchSize:         10,
+			VisibilityTimeout: 60,
+			WaitSeconds:       20,
+			RunInterval:       20,
+		})
+		if err != nil {
+			setupLog.Error(err, "cannot create acknowledgement queue client")
+			os.Exit(1)
+		}
+		if err = mgr.Add(&controllers.Acknowledgements{
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("controllers").WithName("Acknowledgements"),
+			Queue:  ackQueue,
+		}); err != nil {
+			setupLog.Error(err, "unable to add acknowledgements")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controllers.ServiceMetadataWatcherReconciler{
 		Client:              mgr.GetClient(),
 		Log:                 ctrl.Log.WithName("controllers").WithName("ServiceMetadataWatcher"),
 		Scheme:              mgr.GetScheme(),
 		WatchedGVKs:         loadWatchedGVKs(clientConfig),
 		ServiceIdAnnotation: clientConfig.ServiceMetadata.ServiceIdAnnotation,
+		Recorder:            mgr.GetEventRecorderFor("servicemetadatawatcher-controller"),
+		Namespace:           clientConfig.Namespace,
+		Debounce:            clientConfig.ServiceMetadata.Debounce.Duration,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ServiceMetadataWatcher")
 		os.Exit(1)
 	}
 
+	if clientConfig.Heartbeat.Interval.Duration > 0 {
+		if err = mgr.Add(&controllers.Heartbeat{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("Heartbeat"),
+			Queue:    q,
+			Interval: clientConfig.Heartbeat.Interval.Duration,
+		}); err != nil {
+			setupLog.Error(err, "unable to add heartbeat")
+			os.Exit(1)
+		}
+	}
+
+	if clientConfig.Discovery.Enabled && clientConfig.Discovery.Interval.Duration > 0 {
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create discovery client")
+			os.Exit(1)
+		}
+		if err = mgr.Add(&clusterdiscovery.Discoverer{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("discovery"),
+			Interval:     clientConfig.Discovery.Interval.Duration,
+			ManualFields: clientConfig.Discovery.ManualFields,
+			Sources: []clusterdiscovery.Source{
+				clusterdiscovery.KubernetesVersion(discoveryClient),
+				clusterdiscovery.Nodes(mgr.GetClient(), clientConfig.Discovery.TierLabel),
+				clusterdiscovery.OidcIssuer(discoveryClient.RESTClient()),
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to add discovery")
+			os.Exit(1)
+		}
+	}
+
+	// the webhook server is only set up when configured
+	if options.WebhookServer != nil {
+		if err = admission.SetupWebhooksWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhooks")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -194,6 +295,11 @@ func main() {
 			Log:         ctrl.Log.WithName("webhook"),
 			Metrics:     m,
 			AlertMap:    clientConfig.AlertmanagerWebhook.AlertMap,
+
+			Auth:            clientConfig.AlertmanagerWebhook.Auth,
+			TLS:             clientConfig.AlertmanagerWebhook.TLS,
+			MaxRequestBytes: clientConfig.AlertmanagerWebhook.MaxRequestBytes,
+			DedupWindow:     clientConfig.AlertmanagerWebhook.DedupWindow.Duration,
 		}).Start(); err != nil {
 			setupLog.Error(err, "unable to start alertmanager webhook server")
 			os.Exit(1)
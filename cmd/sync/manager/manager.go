@@ -13,6 +13,7 @@ governing permissions and limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
@@ -24,6 +25,7 @@ import (
 	"github.com/adobe/cluster-registry/pkg/sync/manager"
 	"github.com/adobe/cluster-registry/pkg/sync/parser"
 	"github.com/adobe/cluster-registry/pkg/sync/parser/handler"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -126,20 +128,32 @@ func main() {
 		os.Exit(1)
 	}
 
-	q, err := sqs.NewSQS(sqs.Config{
-		AWSRegion:         appConfig.SqsAwsRegion,
-		Endpoint:          appConfig.SqsEndpoint,
-		QueueName:         appConfig.SqsQueueName,
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     appConfig.TracingEnabled,
+		Endpoint:    appConfig.TracingEndpoint,
+		SampleRatio: appConfig.TracingSampleRatio,
+		ServiceName: "cluster-registry-sync-manager",
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			setupLog.Error(err, "failed to flush traces")
+		}
+	}()
+
+	q, err := sqs.NewQueue(appConfig, sqs.Config{
 		BatchSize:         10,
 		VisibilityTimeout: 120,
 		WaitSeconds:       5,
 		RunInterval:       20,
 		RunOnce:           false,
 		MaxHandlers:       10,
-		BusyTimeout:       30,
 	})
 	if err != nil {
-		setupLog.Error(err, "cannot create SQS client")
+		setupLog.Error(err, "cannot create queue client")
 		os.Exit(1)
 	}
 
@@ -153,6 +167,19 @@ func main() {
 	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "controlplane.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedControlPlane"}, &handler.AWSManagedControlPlaneHandler{})
 	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta2", Kind: "AWSManagedMachinePool"}, &handler.AWSManagedMachinePoolHandler{})
 	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "bootstrap.cluster.x-k8s.io", Version: "v1beta2", Kind: "EKSConfig"}, &handler.EKSConfigHandler{})
+	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedControlPlane"}, &handler.AzureManagedControlPlaneHandler{})
+	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "infrastructure.cluster.x-k8s.io", Version: "v1beta1", Kind: "AzureManagedMachinePool"}, &handler.AzureManagedMachinePoolHandler{})
+	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "network.azure.com", Version: "v1api20201101", Kind: "VirtualNetwork"}, &handler.AzureVirtualNetworkHandler{})
+	rp.RegisterHandlerForGVK(schema.GroupVersionKind{Group: "network.azure.com", Version: "v1api20201101", Kind: "VirtualNetworksSubnet"}, &handler.AzureVirtualNetworksSubnetHandler{})
+	for _, rule := range syncConfig.ParserRules {
+		gvk := schema.GroupVersionKind{Group: rule.Group, Version: rule.Version, Kind: rule.Kind}
+		h, err := handler.NewRuleHandler(rule)
+		if err != nil {
+			setupLog.Error(err, "invalid parser rule", "gvk", gvk)
+			os.Exit(1)
+		}
+		rp.RegisterHandlerForGVK(gvk, h)
+	}
 
 	if err = (&manager.SyncController{
 		Client:         client,
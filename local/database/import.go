@@ -13,6 +13,7 @@ governing permissions and limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -56,7 +57,7 @@ func main() {
 	}
 
 	for _, cluster := range clusters {
-		err = d.PutCluster(&cluster)
+		err = d.PutCluster(context.Background(), &cluster)
 		if err != nil {
 			log.Fatalf("Failed to add or update the cluster %s: '%v'", cluster.Name, err.Error())
 		}
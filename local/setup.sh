@@ -166,6 +166,8 @@ if [[ "${RUN_APISERVER}" == 1 ]]; then
         -e SQS_AWS_REGION \
         -e SQS_ENDPOINT=http://"${CONTAINER_SQS}":9324 \
         -e SQS_QUEUE_NAME="${SQS_QUEUE_NAME}" \
+        -e QUEUE_BACKEND \
+        -e QUEUE_MAX_ATTEMPTS \
         -e SQS_BATCH_SIZE \
         -e SQS_WAIT_SECONDS \
         -e SQS_RUN_INTERVAL \
@@ -178,8 +180,11 @@ if [[ "${RUN_APISERVER}" == 1 ]]; then
         -e API_CLIENT_SECRET="${API_CLIENT_SECRET}" \
         -e API_AUTHORIZED_GROUP_ID="${API_AUTHORIZED_GROUP_ID}" \
         -e API_CACHE_TTL \
+        -e API_STALE_AFTER \
         -e API_CACHE_REDIS_HOST=${CONTAINER_REDIS}:6379 \
         -e API_CACHE_REDIS_TLS_ENABLED \
+        -e TRACING_ENABLED \
+        -e TRACING_OTLP_ENDPOINT \
         --network "${NETWORK}" \
         "${IMAGE_APISERVER}":"${TAG}" || die "Failed to create $CONTAINER_API container."
 fi
@@ -199,6 +204,8 @@ if [[ "${RUN_CLIENT}" == 1 ]]; then
         -e SQS_AWS_REGION \
         -e SQS_ENDPOINT=http://"${CONTAINER_SQS}":9324 \
         -e SQS_QUEUE_NAME="${SQS_QUEUE_NAME}" \
+        -e QUEUE_ACK_NAME \
+        -e QUEUE_BACKEND \
         --network "${NETWORK}" \
         "${IMAGE_CLIENT}":"${TAG}" || die "Failed to create $CONTAINER_CLIENT container."
 fi
@@ -218,6 +225,7 @@ if [[ "${RUN_SYNC_MANAGER}" == 1 ]]; then
         -e SQS_AWS_REGION \
         -e SQS_ENDPOINT=http://"${CONTAINER_SQS}":9324 \
         -e SQS_QUEUE_NAME="${SQS_QUEUE_NAME}" \
+        -e QUEUE_BACKEND \
         --network "${NETWORK}" \
         "${IMAGE_SYNC_MANAGER}":"${TAG}" || die "Failed to create $CONTAINER_SYNC_MANAGER container."
 fi
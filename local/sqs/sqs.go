@@ -17,9 +17,6 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
-	"github.com/google/uuid"
 	"log"
 	"os"
 	"time"
@@ -44,17 +41,13 @@ func main() {
 		log.Fatalf("Cannot load the api configuration: '%v'", err.Error())
 	}
 
-	q, err := sqs.NewSQS(sqs.Config{
-		AWSRegion:         appConfig.SqsAwsRegion,
-		Endpoint:          appConfig.SqsEndpoint,
-		QueueName:         appConfig.SqsQueueName,
+	q, err := sqs.NewQueue(appConfig, sqs.Config{
 		BatchSize:         10,
 		VisibilityTimeout: 120,
 		WaitSeconds:       5,
 		RunInterval:       20,
 		RunOnce:           false,
 		MaxHandlers:       10,
-		BusyTimeout:       30,
 	})
 	if err != nil {
 		log.Panicf("Error while trying to create SQS client: %v", err.Error())
@@ -73,32 +66,17 @@ func main() {
 		log.Panicf("Error while trying to unmarshal data: %v", err.Error())
 	}
 
-	id, err := uuid.NewUUID()
-	if err != nil {
-		log.Panic(err.Error())
-	}
-
 	for _, cluster := range clusters {
 		data, _ := json.Marshal(cluster)
-		err = q.Enqueue(ctx, []*awssqs.SendMessageBatchRequestEntry{
+		err = q.Enqueue(ctx, []*sqs.Message{
 			{
-				Id:           aws.String(id.String()),
-				DelaySeconds: aws.Int64(10),
-				MessageAttributes: map[string]*awssqs.MessageAttributeValue{
-					"Type": {
-						DataType:    aws.String("String"),
-						StringValue: aws.String(sqs.ClusterUpdateEvent),
-					},
-					"ClusterName": {
-						DataType:    aws.String("String"),
-						StringValue: aws.String(cluster.Spec.Name),
-					},
-					"SkipCacheInvalidation": {
-						DataType:    aws.String("Bool"),
-						StringValue: aws.String(fmt.Sprintf("%t", false)),
-					},
+				DelaySeconds: 10,
+				Attributes: map[string]string{
+					sqs.MessageAttributeType:                  sqs.ClusterUpdateEvent,
+					sqs.MessageAttributeClusterName:           cluster.Spec.Name,
+					sqs.MessageAttributeSkipCacheInvalidation: fmt.Sprintf("%t", false),
 				},
-				MessageBody: aws.String(string(data)),
+				Body: string(data),
 			},
 		})
 		if err != nil {
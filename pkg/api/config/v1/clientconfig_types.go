@@ -34,24 +34,131 @@ type ClientConfig struct {
 	AlertmanagerWebhook AlertmanagerWebhookConfig `json:"alertmanagerWebhook"`
 
 	ServiceMetadata ServiceMetadataConfig `json:"serviceMetadata"`
+
+	Heartbeat HeartbeatConfig `json:"heartbeat,omitempty"`
+
+	Discovery DiscoveryConfig `json:"discovery,omitempty"`
 }
 
 // AlertmanagerWebhookConfig ...
 type AlertmanagerWebhookConfig struct {
 	BindAddress string      `json:"bindAddress"`
 	AlertMap    []AlertRule `json:"alertMap"`
+
+	// Auth holds the credentials the notifications must carry, the webhook
+	// accepts unauthenticated notifications if it is not set
+	Auth *AlertmanagerWebhookAuth `json:"auth,omitempty"`
+
+	// TLS serves the webhook over TLS, and verifies the certificates of the
+	// clients if a client CA is set
+	TLS *AlertmanagerWebhookTLS `json:"tls,omitempty"`
+
+	// MaxRequestBytes limits the size of the notifications, 1MiB by default
+	MaxRequestBytes int64 `json:"maxRequestBytes,omitempty"`
+
+	// DedupWindow drops the notifications with the group key and status of a
+	// notification handled within the window, notifications are not
+	// deduplicated if it is not set
+	DedupWindow metav1.Duration `json:"dedupWindow,omitempty"`
 }
 
-// AlertRule ...
+// AlertmanagerWebhookAuth holds the files of the credentials, usually
+// mounted from a Secret, which are read on each request so that rotated
+// credentials are picked up. A request is accepted if it matches either the
+// bearer token or the basic auth credentials.
+type AlertmanagerWebhookAuth struct {
+	// BearerTokenFile is the file holding the bearer token
+	BearerTokenFile string `json:"bearerTokenFile,omitempty"`
+
+	// UsernameFile is the file holding the basic auth username
+	UsernameFile string `json:"usernameFile,omitempty"`
+
+	// PasswordFile is the file holding the basic auth password
+	PasswordFile string `json:"passwordFile,omitempty"`
+}
+
+// AlertmanagerWebhookTLS holds the files of the serving certificate
+type AlertmanagerWebhookTLS struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+
+	// ClientCAFile is the CA the certificates of the clients must be signed
+	// by, enabling mTLS
+	ClientCAFile string `json:"clientCAFile,omitempty"`
+}
+
+// AlertRule maps the alerts to the tags of the clusters
 type AlertRule struct {
-	AlertName  string            `json:"alertName"`
+	// AlertName matches the alertname label of the alerts, it may be omitted
+	// if the matchers are set
+	AlertName string `json:"alertName,omitempty"`
+
+	// Matchers select the alerts by their labels, all of them must match
+	Matchers []AlertMatcher `json:"matchers,omitempty"`
+
+	// ClusterLabel is the alert label whose value is the name of the tagged
+	// cluster, either the name of the Cluster object or the one in its spec
+	ClusterLabel string `json:"clusterLabel,omitempty"`
+
+	// ClusterSelector selects the tagged clusters by their labels. The alerts
+	// tag the clusters matching both the cluster label and the selector, or
+	// all the clusters of the namespace if neither is set.
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
 	OnFiring   map[string]string `json:"onFiring"`
 	OnResolved map[string]string `json:"onResolved"`
+
+	// MaxDuration reverts the tags set on firing once it elapsed since the
+	// last firing notification, in case the resolved notification is lost
+	MaxDuration metav1.Duration `json:"maxDuration,omitempty"`
+}
+
+// AlertMatcher matches a label of the alerts
+type AlertMatcher struct {
+	// Name of the label
+	Name string `json:"name"`
+
+	// Value of the label, or the regular expression matching the whole value
+	Value string `json:"value"`
+
+	// Regex makes the value a regular expression
+	Regex bool `json:"regex,omitempty"`
 }
 
 type ServiceMetadataConfig struct {
 	WatchedGVKs         []WatchedGVK `json:"watchedGVKs"`
 	ServiceIdAnnotation string       `json:"serviceIdAnnotation"`
+
+	// Debounce delays the sync of the watchers after an update of their
+	// watched objects, so that a burst of updates, such as a rollout, results
+	// in a single update of the clusters
+	Debounce metav1.Duration `json:"debounce,omitempty"`
+}
+
+// HeartbeatConfig configures the periodic heartbeats of the clusters, which
+// tell the API server that the client is alive even if nothing changed
+type HeartbeatConfig struct {
+	// Interval between the heartbeats, zero disables them
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// DiscoveryConfig configures the discovery of the cluster facts, which are
+// merged into the Cluster objects
+type DiscoveryConfig struct {
+	// Enabled turns the discovery on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Interval between the discoveries, zero disables them
+	Interval metav1.Duration `json:"interval,omitempty"`
+
+	// TierLabel is the node label whose value is the tier of the node. The
+	// tiers are not discovered if it is empty.
+	TierLabel string `json:"tierLabel,omitempty"`
+
+	// ManualFields are the fields whose values in the Cluster objects win over
+	// the discovered ones, among kubernetesVersion, availabilityZones, tiers
+	// and oidcIssuer. The discovered values only fill them if empty.
+	ManualFields []string `json:"manualFields,omitempty"`
 }
 
 func init() {
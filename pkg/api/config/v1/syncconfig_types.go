@@ -32,8 +32,81 @@ type SyncConfig struct {
 	Namespace string `json:"namespace,omitempty"`
 
 	WatchedGVKs []WatchedGVK `json:"watchedGVKs"`
+
+	// ParserRules map the watched objects to the cluster spec without a
+	// dedicated handler, a rule replaces the built-in handler of its GVK
+	ParserRules []ParserRule `json:"parserRules,omitempty"`
+}
+
+// ParserRule maps the objects of a GVK to the fields of the cluster spec
+type ParserRule struct {
+	WatchedGVK `json:",inline"`
+
+	// Filters select the objects to parse, all of them must match
+	Filters []ParserFilter `json:"filters,omitempty"`
+
+	// Lists configure how the objects are merged into the lists of the
+	// cluster spec, the elements of a list that has none are appended
+	Lists []ParserList `json:"lists,omitempty"`
+
+	// Mappings copy the values of the objects to the cluster spec
+	Mappings []ParserMapping `json:"mappings"`
 }
 
+// ParserFilter matches a value of the objects
+type ParserFilter struct {
+	// Source is the JSONPath expression of the value, e.g. "{.metadata.name}"
+	Source string `json:"source"`
+
+	// Regex is the regular expression the value must contain
+	Regex string `json:"regex"`
+}
+
+// ParserList merges the elements of a list of the cluster spec
+type ParserList struct {
+	// Path of the list in the cluster spec, e.g. "tiers"
+	Path string `json:"path"`
+
+	// Key is the field identifying the elements of the list, e.g. "name".
+	// The fields of an element are set on the element with the same key if
+	// there is one, and appended otherwise.
+	Key string `json:"key,omitempty"`
+}
+
+// ParserMapping copies a value of the objects to the cluster spec
+type ParserMapping struct {
+	// Source is the JSONPath expression of the value, e.g. "{.spec.region}"
+	Source string `json:"source"`
+
+	// Destination is the path of the field in the cluster spec, e.g.
+	// "extra.oidcIssuer", or "tiers[].name" for a field of the element of a
+	// list that each object is mapped to
+	Destination string `json:"destination"`
+
+	// Type of the value, one of string (default), integer, stringList,
+	// stringMap or taints
+	Type ParserValueType `json:"type,omitempty"`
+
+	// Regex extracts the first submatch of the value, or an empty string if
+	// it does not match. Only applies to strings.
+	Regex string `json:"regex,omitempty"`
+
+	// Optional skips the mapping if the value is missing, which is an error
+	// otherwise
+	Optional bool `json:"optional,omitempty"`
+}
+
+// ParserValueType is the type of the value of a mapping
+type ParserValueType string
+
+const (
+	ParserValueString     ParserValueType = "string"
+	ParserValueInteger    ParserValueType = "integer"
+	ParserValueStringList ParserValueType = "stringList"
+	ParserValueStringMap  ParserValueType = "stringMap"
+	ParserValueTaints     ParserValueType = "taints"
+)
+
 func init() {
 	SchemeBuilder.Register(&SyncConfig{})
 }
@@ -23,9 +23,34 @@ import (
 	timex "time"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertMatcher) DeepCopyInto(out *AlertMatcher) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertMatcher.
+func (in *AlertMatcher) DeepCopy() *AlertMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertRule) DeepCopyInto(out *AlertRule) {
 	*out = *in
+	if in.Matchers != nil {
+		in, out := &in.Matchers, &out.Matchers
+		*out = make([]AlertMatcher, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterSelector != nil {
+		in, out := &in.ClusterSelector, &out.ClusterSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.OnFiring != nil {
 		in, out := &in.OnFiring, &out.OnFiring
 		*out = make(map[string]string, len(*in))
@@ -40,6 +65,7 @@ func (in *AlertRule) DeepCopyInto(out *AlertRule) {
 			(*out)[key] = val
 		}
 	}
+	out.MaxDuration = in.MaxDuration
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertRule.
@@ -52,6 +78,21 @@ func (in *AlertRule) DeepCopy() *AlertRule {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertmanagerWebhookAuth) DeepCopyInto(out *AlertmanagerWebhookAuth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertmanagerWebhookAuth.
+func (in *AlertmanagerWebhookAuth) DeepCopy() *AlertmanagerWebhookAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertmanagerWebhookAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AlertmanagerWebhookConfig) DeepCopyInto(out *AlertmanagerWebhookConfig) {
 	*out = *in
@@ -62,6 +103,17 @@ func (in *AlertmanagerWebhookConfig) DeepCopyInto(out *AlertmanagerWebhookConfig
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(AlertmanagerWebhookAuth)
+		**out = **in
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(AlertmanagerWebhookTLS)
+		**out = **in
+	}
+	out.DedupWindow = in.DedupWindow
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertmanagerWebhookConfig.
@@ -74,6 +126,21 @@ func (in *AlertmanagerWebhookConfig) DeepCopy() *AlertmanagerWebhookConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertmanagerWebhookTLS) DeepCopyInto(out *AlertmanagerWebhookTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertmanagerWebhookTLS.
+func (in *AlertmanagerWebhookTLS) DeepCopy() *AlertmanagerWebhookTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertmanagerWebhookTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientConfig) DeepCopyInto(out *ClientConfig) {
 	*out = *in
@@ -81,6 +148,8 @@ func (in *ClientConfig) DeepCopyInto(out *ClientConfig) {
 	in.ControllerManager.DeepCopyInto(&out.ControllerManager)
 	in.AlertmanagerWebhook.DeepCopyInto(&out.AlertmanagerWebhook)
 	in.ServiceMetadata.DeepCopyInto(&out.ServiceMetadata)
+	out.Heartbeat = in.Heartbeat
+	in.Discovery.DeepCopyInto(&out.Discovery)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientConfig.
@@ -253,6 +322,119 @@ func (in *ControllerWebhook) DeepCopy() *ControllerWebhook {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiscoveryConfig) DeepCopyInto(out *DiscoveryConfig) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.ManualFields != nil {
+		in, out := &in.ManualFields, &out.ManualFields
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiscoveryConfig.
+func (in *DiscoveryConfig) DeepCopy() *DiscoveryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DiscoveryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeartbeatConfig) DeepCopyInto(out *HeartbeatConfig) {
+	*out = *in
+	out.Interval = in.Interval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeartbeatConfig.
+func (in *HeartbeatConfig) DeepCopy() *HeartbeatConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HeartbeatConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParserFilter) DeepCopyInto(out *ParserFilter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParserFilter.
+func (in *ParserFilter) DeepCopy() *ParserFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ParserFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParserList) DeepCopyInto(out *ParserList) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParserList.
+func (in *ParserList) DeepCopy() *ParserList {
+	if in == nil {
+		return nil
+	}
+	out := new(ParserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParserMapping) DeepCopyInto(out *ParserMapping) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParserMapping.
+func (in *ParserMapping) DeepCopy() *ParserMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(ParserMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ParserRule) DeepCopyInto(out *ParserRule) {
+	*out = *in
+	out.WatchedGVK = in.WatchedGVK
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]ParserFilter, len(*in))
+		copy(*out, *in)
+	}
+	if in.Lists != nil {
+		in, out := &in.Lists, &out.Lists
+		*out = make([]ParserList, len(*in))
+		copy(*out, *in)
+	}
+	if in.Mappings != nil {
+		in, out := &in.Mappings, &out.Mappings
+		*out = make([]ParserMapping, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ParserRule.
+func (in *ParserRule) DeepCopy() *ParserRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ParserRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceMetadataConfig) DeepCopyInto(out *ServiceMetadataConfig) {
 	*out = *in
@@ -261,6 +443,7 @@ func (in *ServiceMetadataConfig) DeepCopyInto(out *ServiceMetadataConfig) {
 		*out = make([]WatchedGVK, len(*in))
 		copy(*out, *in)
 	}
+	out.Debounce = in.Debounce
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMetadataConfig.
@@ -283,6 +466,13 @@ func (in *SyncConfig) DeepCopyInto(out *SyncConfig) {
 		*out = make([]WatchedGVK, len(*in))
 		copy(*out, *in)
 	}
+	if in.ParserRules != nil {
+		in, out := &in.ParserRules, &out.ParserRules
+		*out = make([]ParserRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncConfig.
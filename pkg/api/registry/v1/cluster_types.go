@@ -106,6 +106,9 @@ type ClusterSpec struct {
 	// The type of the cluster
 	Type string `json:"type,omitempty"`
 
+	// Kubernetes version of the cluster
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
 	// Extra information, not necessary related to the cluster
 	Extra Extra `json:"extra,omitempty"`
 
@@ -121,9 +124,16 @@ type ClusterSpec struct {
 	// Timestamp when cluster information was updated
 	LastUpdated string `json:"lastUpdated"`
 
+	// Timestamp when the client of the cluster was last heard from, either
+	// through an update or a heartbeat
+	LastSeen string `json:"lastSeen,omitempty"`
+
 	// Cluster tags that were applied
 	Tags map[string]string `json:"tags,omitempty"`
 
+	// Tag changes which are reverted once they expire, by tag
+	TagOverrides map[string]TagOverride `json:"tagOverrides,omitempty"`
+
 	// Capacity cluster information
 	Capacity Capacity `json:"capacity,omitempty"`
 
@@ -134,6 +144,30 @@ type ClusterSpec struct {
 	AvailabilityZones []AvailabilityZone `json:"availabilityZones,omitempty"`
 }
 
+// TagOverride is a change of a cluster tag, which is reverted to the
+// previous value of the tag once it expires
+type TagOverride struct {
+	// Value set by the change
+	Value string `json:"value"`
+
+	// Value of the tag before the change, the tag is removed on expiry if it
+	// was not set
+	// +optional
+	PreviousValue *string `json:"previousValue,omitempty"`
+
+	// Timestamp when the change expires
+	// +kubebuilder:validation:Format=date-time
+	ExpiresAt string `json:"expiresAt"`
+
+	// Who changed the tag
+	// +optional
+	SetBy string `json:"setBy,omitempty"`
+
+	// Why the tag was changed
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
 // Offering the cluster is meant for
 // +kubebuilder:validation:Enum=CaaS;PaaS
 type Offering string
@@ -206,7 +240,7 @@ type Tier struct {
 
 	// Container runtime
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Enum=docker;cri-o
+	// +kubebuilder:validation:Enum=docker;cri-o;containerd
 	ContainerRuntime string `json:"containerRuntime"`
 
 	// Min number of instances
@@ -217,6 +251,9 @@ type Tier struct {
 	// +kubebuilder:validation:Required
 	MaxCapacity int `json:"maxCapacity"`
 
+	// Current number of instances
+	NodeCount int `json:"nodeCount,omitempty"`
+
 	// Instance K8s labels
 	Labels map[string]string `json:"labels,omitempty"`
 
@@ -276,14 +313,45 @@ type AvailabilityZone struct {
 	ID   string `json:"id,omitempty"`
 }
 
+const (
+	// ConditionEnqueued is true when the current spec was sent to the registry
+	ConditionEnqueued = "Enqueued"
+
+	// ConditionAcknowledged is true when the registry stored the current spec
+	ConditionAcknowledged = "Acknowledged"
+)
+
 // ClusterStatus defines the observed state of Cluster
 type ClusterStatus struct {
-	// Send/Receive Errors
-	// Last Update Timestamp?
+	// Time when the spec was last sent to the registry
+	// +optional
+	LastEnqueuedTime *metav1.Time `json:"lastEnqueuedTime,omitempty"`
+
+	// Hash of the spec last sent to the registry
+	// +optional
+	LastEnqueuedHash string `json:"lastEnqueuedHash,omitempty"`
+
+	// Error of the last attempt to send the spec to the registry
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// Revision of the cluster in the registry, as acknowledged by the API server
+	// +optional
+	RegistryRevision string `json:"registryRevision,omitempty"`
+
+	// Conditions of the delivery of the spec to the registry
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Enqueued",type=string,JSONPath=`.status.conditions[?(@.type=="Enqueued")].status`
+//+kubebuilder:printcolumn:name="Acknowledged",type=string,JSONPath=`.status.conditions[?(@.type=="Acknowledged")].status`
+//+kubebuilder:printcolumn:name="Revision",type=string,JSONPath=`.status.registryRevision`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
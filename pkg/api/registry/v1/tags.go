@@ -0,0 +1,55 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v1
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ExcludedTagsAnnotation lists the comma separated tags of a cluster which
+// are not changed automatically
+const ExcludedTagsAnnotation = "registry.ethos.adobe.com/excluded-tags"
+
+// TagExcluded returns whether the tag is excluded from automatic changes
+func (c *Cluster) TagExcluded(key string) bool {
+	excludedTags := c.Annotations[ExcludedTagsAnnotation]
+	if excludedTags == "" {
+		return false
+	}
+	return slices.Contains(strings.Split(excludedTags, ","), key)
+}
+
+// NewTagOverride returns the change of the tag to the value until it
+// expires. Successive changes of a tag keep the value it had before the
+// first of them, which is the value the tag is reverted to.
+func (s *ClusterSpec) NewTagOverride(key, value string, expiresAt time.Time, setBy, reason string) TagOverride {
+	override := TagOverride{
+		Value:     value,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		SetBy:     setBy,
+		Reason:    reason,
+	}
+	if previous, ok := s.TagOverrides[key]; ok {
+		override.PreviousValue = previous.PreviousValue
+	} else if tagValue, ok := s.Tags[key]; ok {
+		override.PreviousValue = &tagValue
+	}
+	return override
+}
+
+// Expiry returns the time when the change of the tag expires
+func (o *TagOverride) Expiry() (time.Time, error) {
+	return time.Parse(time.RFC3339, o.ExpiresAt)
+}
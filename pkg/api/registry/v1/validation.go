@@ -0,0 +1,95 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/base64"
+	"net"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks the semantic constraints of the cluster which cannot be
+// expressed in its schema
+func (c *Cluster) Validate() error {
+	return c.Spec.ValidateFields(field.NewPath("spec")).ToAggregate()
+}
+
+// Validate checks the semantic constraints of the cluster spec which cannot
+// be expressed in its schema
+func (s *ClusterSpec) Validate() error {
+	return s.ValidateFields(field.NewPath("spec")).ToAggregate()
+}
+
+// ValidateFields returns the invalid fields of the cluster spec, under the
+// given path
+func (s *ClusterSpec) ValidateFields(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	errs = append(errs, s.APIServer.validate(path.Child("apiServer"))...)
+
+	tiers := map[string]bool{}
+	for i, tier := range s.Tiers {
+		p := path.Child("tiers").Index(i)
+		if tiers[tier.Name] {
+			errs = append(errs, field.Duplicate(p.Child("name"), tier.Name))
+		}
+		tiers[tier.Name] = true
+
+		if tier.MinCapacity < 0 {
+			errs = append(errs, field.Invalid(p.Child("minCapacity"), tier.MinCapacity, "must not be negative"))
+		}
+		if tier.MinCapacity > tier.MaxCapacity {
+			errs = append(errs, field.Invalid(p.Child("minCapacity"), tier.MinCapacity, "must not be greater than maxCapacity"))
+		}
+	}
+
+	for i, vnet := range s.VirtualNetworks {
+		errs = append(errs, validateCidrs(path.Child("virtualNetworks").Index(i).Child("cidrs"), vnet.Cidrs)...)
+	}
+	for i, vnet := range s.PeerVirtualNetworks {
+		errs = append(errs, validateCidrs(path.Child("peerVirtualNetworks").Index(i).Child("cidrs"), vnet.Cidrs)...)
+	}
+
+	return errs
+}
+
+func (a *APIServer) validate(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if a.Endpoint != "" {
+		u, err := url.Parse(a.Endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			errs = append(errs, field.Invalid(path.Child("endpoint"), a.Endpoint, "must be an absolute http or https URL"))
+		}
+	}
+
+	if a.CertificateAuthorityData != "" {
+		if _, err := base64.StdEncoding.DecodeString(a.CertificateAuthorityData); err != nil {
+			errs = append(errs, field.Invalid(path.Child("certificateAuthorityData"), "<data>", "must be base64 encoded"))
+		}
+	}
+
+	return errs
+}
+
+func validateCidrs(path *field.Path, cidrs []string) field.ErrorList {
+	var errs field.ErrorList
+	for i, cidr := range cidrs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, field.Invalid(path.Index(i), cidr, "must be a valid CIDR"))
+		}
+	}
+	return errs
+}
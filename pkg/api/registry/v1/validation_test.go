@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidate(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test validating the semantic constraints of the cluster spec.")
+
+	valid := func() ClusterSpec {
+		return ClusterSpec{
+			Name: "cluster1",
+			APIServer: APIServer{
+				Endpoint:                 "https://cluster1.example.com",
+				CertificateAuthorityData: "LS0tLS1CRUdJTiBDRVJUSUZJQ0FURS0tLS0tCg==",
+			},
+			Tiers: []Tier{
+				{Name: "system", MinCapacity: 1, MaxCapacity: 3},
+				{Name: "worker", MinCapacity: 2, MaxCapacity: 2},
+			},
+			VirtualNetworks:     []VirtualNetwork{{ID: "vnet1", Cidrs: []string{"10.0.0.0/24"}}},
+			PeerVirtualNetworks: []PeerVirtualNetwork{{ID: "vnet2", Cidrs: []string{"10.2.0.1/23"}}},
+		}
+	}
+
+	tcs := []struct {
+		name           string
+		mutate         func(spec *ClusterSpec)
+		expectedFields []string
+	}{
+		{
+			name:   "valid cluster",
+			mutate: func(_ *ClusterSpec) {},
+		},
+		{
+			name: "min capacity greater than max capacity",
+			mutate: func(spec *ClusterSpec) {
+				spec.Tiers[1].MinCapacity = 3
+			},
+			expectedFields: []string{"spec.tiers[1].minCapacity"},
+		},
+		{
+			name: "negative min capacity",
+			mutate: func(spec *ClusterSpec) {
+				spec.Tiers[0].MinCapacity = -1
+			},
+			expectedFields: []string{"spec.tiers[0].minCapacity"},
+		},
+		{
+			name: "duplicate tier names",
+			mutate: func(spec *ClusterSpec) {
+				spec.Tiers[1].Name = "system"
+			},
+			expectedFields: []string{"spec.tiers[1].name"},
+		},
+		{
+			name: "malformed CIDRs",
+			mutate: func(spec *ClusterSpec) {
+				spec.VirtualNetworks[0].Cidrs = append(spec.VirtualNetworks[0].Cidrs, "10.0.0.0")
+				spec.PeerVirtualNetworks[0].Cidrs = []string{"10.300.0.0/16"}
+			},
+			expectedFields: []string{"spec.virtualNetworks[0].cidrs[1]", "spec.peerVirtualNetworks[0].cidrs[0]"},
+		},
+		{
+			name: "endpoint without scheme",
+			mutate: func(spec *ClusterSpec) {
+				spec.APIServer.Endpoint = "cluster1.example.com"
+			},
+			expectedFields: []string{"spec.apiServer.endpoint"},
+		},
+		{
+			name: "undecodable certificate authority data",
+			mutate: func(spec *ClusterSpec) {
+				spec.APIServer.CertificateAuthorityData = "-----BEGIN CERTIFICATE-----"
+			},
+			expectedFields: []string{"spec.apiServer.certificateAuthorityData"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen validating the cluster spec", tc.name)
+
+		spec := valid()
+		tc.mutate(&spec)
+
+		errs := spec.ValidateFields(field.NewPath("spec"))
+		var fields []string
+		for _, err := range errs {
+			fields = append(fields, err.Field)
+		}
+		test.Equal(tc.expectedFields, fields)
+		test.Equal(len(tc.expectedFields) > 0, spec.Validate() != nil)
+	}
+}
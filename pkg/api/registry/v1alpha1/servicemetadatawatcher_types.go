@@ -23,11 +23,37 @@ type ServiceMetadataWatcherSpec struct {
 
 type WatchedServiceObject struct {
 	ObjectReference ObjectReference `json:"objectReference"`
-	WatchedFields   []WatchedField  `json:"watchedFields"`
+
+	// Label selector of the watched objects in the namespace of the watcher,
+	// instead of the name of the object reference
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// How the values of the selected objects are combined, Map by default
+	// +kubebuilder:validation:Enum=Map;First;Last
+	// +optional
+	Aggregation string `json:"aggregation,omitempty"`
+
+	WatchedFields []WatchedField `json:"watchedFields"`
 }
 
+// Aggregations of the values of the objects selected by a label selector,
+// which are ordered by name
+const (
+	// AggregationMap sets the destinations of each object, which must be
+	// templated with the object name, such as {{.name}}.version
+	AggregationMap = "Map"
+
+	// AggregationFirst sets each destination from the first object having the field
+	AggregationFirst = "First"
+
+	// AggregationLast sets each destination from the last object having the field
+	AggregationLast = "Last"
+)
+
 type ObjectReference struct {
-	Name       string `json:"name"`
+	// +optional
+	Name       string `json:"name,omitempty"`
 	APIVersion string `json:"apiVersion"`
 	Kind       string `json:"kind"`
 }
@@ -36,24 +62,97 @@ func (o *ObjectReference) String() string {
 	return o.Name + "/" + o.APIVersion + "/" + o.Kind
 }
 
+// Policies of the watched fields when the watched object is deleted
+const (
+	// OnDeleteRemove removes the field from the service metadata
+	OnDeleteRemove = "Remove"
+
+	// OnDeleteKeep keeps the last value of the field
+	OnDeleteKeep = "Keep"
+
+	// OnDeleteDefault sets the field to its default value
+	OnDeleteDefault = "Default"
+)
+
 type WatchedField struct {
-	Source      string `json:"src"`
+	// Path of the field in the watched object, such as spec.containers[0].image
+	// +optional
+	Source string `json:"src,omitempty"`
+
+	// Kubernetes JSONPath expression evaluated against the watched object,
+	// instead of src, such as {.spec.rules[*].host}. Multiple results are
+	// joined with commas
+	// +optional
+	Expression string `json:"expression,omitempty"`
+
+	// Key of the field in the service metadata, which may be templated with
+	// the name and namespace of the watched object, such as {{.name}}.version
 	Destination string `json:"dst"`
+
+	// Policy of the field when the watched object is deleted, Remove by default
+	// +kubebuilder:validation:Enum=Remove;Keep;Default
+	// +optional
+	OnDelete string `json:"onDelete,omitempty"`
+
+	// Value of the field when the watched object is deleted, with the Default policy
+	// +optional
+	Default string `json:"default,omitempty"`
 }
 
 type WatchedServiceObjectStatus struct {
-	LastUpdated     metav1.Time     `json:"lastUpdated"`
+	// Time when the watched fields of the object were last synced to the cluster
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
 	ObjectReference ObjectReference `json:"objectReference"`
-	Errors          []string        `json:"errors"`
+
+	// Label selector of the watched objects, if any
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// Names of the selected objects which contributed values
+	// +optional
+	Objects []string `json:"objects,omitempty"`
+
+	// Values of the watched fields in the service metadata, by destination
+	// +optional
+	Values map[string]string `json:"values,omitempty"`
+
+	// Errors of the last sync of the object
+	// +optional
+	Errors []string `json:"errors,omitempty"`
 }
 
+const (
+	// ConditionReady is true when all the watched fields were synced
+	ConditionReady = "Ready"
+
+	// ConditionDegraded is true when some watched objects or fields could not
+	// be synced
+	ConditionDegraded = "Degraded"
+)
+
 // ServiceMetadataWatcherStatus defines the observed state of ServiceMetadataWatcher
 type ServiceMetadataWatcherStatus struct {
-	WatchedServiceObjects []WatchedServiceObjectStatus `json:"watchedServiceObjects"`
+	// Service ID under which the watched fields were synced
+	// +optional
+	ServiceID string `json:"serviceId,omitempty"`
+
+	// +optional
+	WatchedServiceObjects []WatchedServiceObjectStatus `json:"watchedServiceObjects,omitempty"`
+
+	// Conditions of the sync of the watched fields
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Degraded",type=string,JSONPath=`.status.conditions[?(@.type=="Degraded")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ServiceMetadataWatcher is the Schema for the servicemetadatawatchers API
 type ServiceMetadataWatcher struct {
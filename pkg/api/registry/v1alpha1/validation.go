@@ -0,0 +1,150 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// watchedFieldSource matches the paths of the watched fields, made of dot
+// separated keys and of slice indexes or map keys between brackets, such as
+// spec.template.spec.containers[0].image or metadata.labels[app]
+var watchedFieldSource = regexp.MustCompile(`^[^.\[\]]+(\.[^.\[\]]+|\[[^\[\]]+\])*$`)
+
+// ParseExpression compiles the JSONPath expression of a watched field, whose
+// enclosing braces may be omitted like with kubectl. Missing keys resolve to
+// no results rather than to an error.
+func ParseExpression(expression string) (*jsonpath.JSONPath, error) {
+	if !strings.HasPrefix(expression, "{") {
+		expression = "{" + expression + "}"
+	}
+	jp := jsonpath.New("expression").AllowMissingKeys(true)
+	if err := jp.Parse(expression); err != nil {
+		return nil, err
+	}
+	return jp, nil
+}
+
+// RenderDestination returns the destination of a watched field for the given
+// object, as the destination may be templated with its name and namespace
+func RenderDestination(destination, name, namespace string) (string, error) {
+	if !strings.Contains(destination, "{{") {
+		return destination, nil
+	}
+	t, err := template.New("dst").Option("missingkey=error").Parse(destination)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, map[string]string{"name": name, "namespace": namespace}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Validate checks the semantic constraints of the watcher which cannot be
+// expressed in its schema
+func (w *ServiceMetadataWatcher) Validate() error {
+	return w.Spec.ValidateFields(field.NewPath("spec")).ToAggregate()
+}
+
+// ValidateFields returns the invalid fields of the watcher spec, under the
+// given path
+func (s *ServiceMetadataWatcherSpec) ValidateFields(path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if len(s.WatchedServiceObjects) == 0 {
+		errs = append(errs, field.Required(path.Child("watchedServiceObjects"), "at least one object must be watched"))
+	}
+
+	for i, wso := range s.WatchedServiceObjects {
+		p := path.Child("watchedServiceObjects").Index(i)
+		errs = append(errs, wso.ObjectReference.validate(p.Child("objectReference"), wso.LabelSelector == nil)...)
+
+		if wso.LabelSelector != nil {
+			if wso.ObjectReference.Name != "" {
+				errs = append(errs, field.Forbidden(p.Child("objectReference", "name"), "must not be set together with labelSelector"))
+			}
+			if _, err := metav1.LabelSelectorAsSelector(wso.LabelSelector); err != nil {
+				errs = append(errs, field.Invalid(p.Child("labelSelector"), wso.LabelSelector, err.Error()))
+			}
+		}
+		switch wso.Aggregation {
+		case "", AggregationMap, AggregationFirst, AggregationLast:
+		default:
+			errs = append(errs, field.NotSupported(p.Child("aggregation"), wso.Aggregation, []string{AggregationMap, AggregationFirst, AggregationLast}))
+		}
+		// the selected objects would overwrite each other's fields
+		byName := wso.LabelSelector != nil && (wso.Aggregation == "" || wso.Aggregation == AggregationMap)
+
+		if len(wso.WatchedFields) == 0 {
+			errs = append(errs, field.Required(p.Child("watchedFields"), "at least one field must be watched"))
+		}
+		for j, wf := range wso.WatchedFields {
+			errs = append(errs, wf.validate(p.Child("watchedFields").Index(j), byName)...)
+		}
+	}
+
+	return errs
+}
+
+func (o *ObjectReference) validate(path *field.Path, named bool) field.ErrorList {
+	var errs field.ErrorList
+	if named && o.Name == "" {
+		errs = append(errs, field.Required(path.Child("name"), "either name or labelSelector is required"))
+	}
+	if _, err := schema.ParseGroupVersion(o.APIVersion); err != nil || o.APIVersion == "" {
+		errs = append(errs, field.Invalid(path.Child("apiVersion"), o.APIVersion, "must be a group version such as apps/v1"))
+	}
+	if o.Kind == "" {
+		errs = append(errs, field.Required(path.Child("kind"), ""))
+	}
+	return errs
+}
+
+func (f *WatchedField) validate(path *field.Path, byName bool) field.ErrorList {
+	var errs field.ErrorList
+	switch {
+	case f.Source == "" && f.Expression == "":
+		errs = append(errs, field.Required(path.Child("src"), "either src or expression is required"))
+	case f.Source != "" && f.Expression != "":
+		errs = append(errs, field.Forbidden(path.Child("expression"), "must not be set together with src"))
+	case f.Expression != "":
+		if _, err := ParseExpression(f.Expression); err != nil {
+			errs = append(errs, field.Invalid(path.Child("expression"), f.Expression, "must be a JSONPath expression: "+err.Error()))
+		}
+	case !watchedFieldSource.MatchString(f.Source):
+		errs = append(errs, field.Invalid(path.Child("src"), f.Source, "must be a path such as spec.containers[0].image"))
+	}
+	if f.Destination == "" {
+		errs = append(errs, field.Required(path.Child("dst"), ""))
+	} else if a, err := RenderDestination(f.Destination, "a", "ns"); err != nil {
+		errs = append(errs, field.Invalid(path.Child("dst"), f.Destination, "must be a template such as {{.name}}.version: "+err.Error()))
+	} else if b, _ := RenderDestination(f.Destination, "b", "ns"); byName && a == b {
+		errs = append(errs, field.Invalid(path.Child("dst"), f.Destination, "must be templated with the object name, such as {{.name}}.version"))
+	}
+	switch f.OnDelete {
+	case "", OnDeleteRemove, OnDeleteKeep, OnDeleteDefault:
+	default:
+		errs = append(errs, field.NotSupported(path.Child("onDelete"), f.OnDelete, []string{OnDeleteRemove, OnDeleteKeep, OnDeleteDefault}))
+	}
+	return errs
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidate(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test validating the watched objects and fields of the service metadata watchers.")
+
+	tcs := []struct {
+		name           string
+		objects        []WatchedServiceObject
+		expectedFields []string
+	}{
+		{
+			name: "valid paths",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "apps/v1", Kind: "Deployment"},
+				WatchedFields: []WatchedField{
+					{Source: "spec.replicas", Destination: "replicas"},
+					{Source: "spec.template.spec.containers[0].image", Destination: "image"},
+					{Source: "metadata.annotations[example.com/owner]", Destination: "owner"},
+				},
+			}},
+		},
+		{
+			name:           "no watched objects",
+			expectedFields: []string{"spec.watchedServiceObjects"},
+		},
+		{
+			name: "invalid object reference",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{APIVersion: "apps/v1/beta"},
+				WatchedFields:   []WatchedField{{Source: "spec.replicas", Destination: "replicas"}},
+			}},
+			expectedFields: []string{
+				"spec.watchedServiceObjects[0].objectReference.name",
+				"spec.watchedServiceObjects[0].objectReference.apiVersion",
+				"spec.watchedServiceObjects[0].objectReference.kind",
+			},
+		},
+		{
+			name: "invalid paths",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "v1", Kind: "ConfigMap"},
+				WatchedFields: []WatchedField{
+					{Source: "data..key", Destination: "key"},
+					{Source: "data[key", Destination: "key"},
+					{Source: "", Destination: "key"},
+					{Source: "data.key"},
+				},
+			}},
+			expectedFields: []string{
+				"spec.watchedServiceObjects[0].watchedFields[0].src",
+				"spec.watchedServiceObjects[0].watchedFields[1].src",
+				"spec.watchedServiceObjects[0].watchedFields[2].src",
+				"spec.watchedServiceObjects[0].watchedFields[3].dst",
+			},
+		},
+		{
+			name: "valid expressions",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "apps/v1", Kind: "Deployment"},
+				WatchedFields: []WatchedField{
+					{Expression: `{.spec.template.spec.containers[?(@.name=="app")].image}`, Destination: "image"},
+					{Expression: ".status.readyReplicas", Destination: "ready"},
+				},
+			}},
+		},
+		{
+			name: "invalid expressions",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "apps/v1", Kind: "Deployment"},
+				WatchedFields: []WatchedField{
+					{Expression: "{.spec.containers[?(@.name==]}", Destination: "image"},
+					{Source: "spec.replicas", Expression: "{.spec.replicas}", Destination: "replicas"},
+				},
+			}},
+			expectedFields: []string{
+				"spec.watchedServiceObjects[0].watchedFields[0].expression",
+				"spec.watchedServiceObjects[0].watchedFields[1].expression",
+			},
+		},
+		{
+			name: "label selectors",
+			objects: []WatchedServiceObject{
+				{
+					ObjectReference: ObjectReference{APIVersion: "apps/v1", Kind: "Deployment"},
+					LabelSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					WatchedFields:   []WatchedField{{Source: "spec.replicas", Destination: "{{.name}}.replicas"}},
+				},
+				{
+					ObjectReference: ObjectReference{APIVersion: "v1", Kind: "ConfigMap"},
+					LabelSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					Aggregation:     AggregationFirst,
+					WatchedFields:   []WatchedField{{Source: "data.tier", Destination: "tier"}},
+				},
+			},
+		},
+		{
+			name: "invalid label selectors",
+			objects: []WatchedServiceObject{
+				{
+					ObjectReference: ObjectReference{Name: "app", APIVersion: "apps/v1", Kind: "Deployment"},
+					LabelSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "team", Operator: "Matches"},
+					}},
+					Aggregation:   "Sum",
+					WatchedFields: []WatchedField{{Source: "spec.replicas", Destination: "replicas"}},
+				},
+				{
+					ObjectReference: ObjectReference{APIVersion: "apps/v1", Kind: "Deployment"},
+					LabelSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					WatchedFields:   []WatchedField{{Source: "spec.replicas", Destination: "replicas"}},
+				},
+			},
+			expectedFields: []string{
+				"spec.watchedServiceObjects[0].objectReference.name",
+				"spec.watchedServiceObjects[0].labelSelector",
+				"spec.watchedServiceObjects[0].aggregation",
+				"spec.watchedServiceObjects[1].watchedFields[0].dst",
+			},
+		},
+		{
+			name: "invalid destination templates",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "v1", Kind: "ConfigMap"},
+				WatchedFields: []WatchedField{
+					{Source: "data.tier", Destination: "{{.name}.tier"},
+					{Source: "data.tier", Destination: "{{.owner}}.tier"},
+					{Source: "data.tier", Destination: "{{.namespace}}.{{.name}}.tier"},
+				},
+			}},
+			expectedFields: []string{
+				"spec.watchedServiceObjects[0].watchedFields[0].dst",
+				"spec.watchedServiceObjects[0].watchedFields[1].dst",
+			},
+		},
+		{
+			name: "no watched fields",
+			objects: []WatchedServiceObject{{
+				ObjectReference: ObjectReference{Name: "app", APIVersion: "v1", Kind: "ConfigMap"},
+			}},
+			expectedFields: []string{"spec.watchedServiceObjects[0].watchedFields"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen validating the watcher spec", tc.name)
+
+		w := &ServiceMetadataWatcher{Spec: ServiceMetadataWatcherSpec{WatchedServiceObjects: tc.objects}}
+
+		var fields []string
+		for _, err := range w.Spec.ValidateFields(field.NewPath("spec")) {
+			fields = append(fields, err.Field)
+		}
+		test.Equal(tc.expectedFields, fields)
+		test.Equal(len(tc.expectedFields) > 0, w.Validate() != nil)
+	}
+}
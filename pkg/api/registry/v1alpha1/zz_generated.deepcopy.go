@@ -247,6 +247,13 @@ func (in *ServiceMetadataWatcherStatus) DeepCopyInto(out *ServiceMetadataWatcher
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMetadataWatcherStatus.
@@ -298,6 +305,11 @@ func (in *WatchedResource) DeepCopy() *WatchedResource {
 func (in *WatchedServiceObject) DeepCopyInto(out *WatchedServiceObject) {
 	*out = *in
 	out.ObjectReference = in.ObjectReference
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.WatchedFields != nil {
 		in, out := &in.WatchedFields, &out.WatchedFields
 		*out = make([]WatchedField, len(*in))
@@ -318,8 +330,28 @@ func (in *WatchedServiceObject) DeepCopy() *WatchedServiceObject {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WatchedServiceObjectStatus) DeepCopyInto(out *WatchedServiceObjectStatus) {
 	*out = *in
-	in.LastUpdated.DeepCopyInto(&out.LastUpdated)
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
 	out.ObjectReference = in.ObjectReference
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Errors != nil {
 		in, out := &in.Errors, &out.Errors
 		*out = make([]string, len(*in))
@@ -13,31 +13,210 @@ governing permissions and limitations under the License.
 package errors
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+// MIMEApplicationProblemJSON is the media type of problem details responses (RFC 7807)
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Code is a stable, machine-readable identifier of an error
+type Code string
+
+const (
+	CodeBadRequest          Code = "bad_request"
+	CodeForbidden           Code = "forbidden"
+	CodeNotFound            Code = "not_found"
+	CodeInvalidCondition    Code = "invalid_condition"
+	CodeForbiddenField      Code = "forbidden_field"
+	CodeConflict            Code = "conflict"
+	CodeRateLimited         Code = "rate_limited"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeInternal            Code = "internal"
 )
 
-// Error struct
-type Error struct {
-	Errors map[string]interface{} `json:"errors"`
+// typePrefix is prepended to the error code to build the problem type URI
+const typePrefix = "urn:cluster-registry:error:"
+
+// Problem is the error returned by all API versions, rendered as problem details (RFC 7807)
+type Problem struct {
+	// Type is a URI identifying the problem type, derived from Code
+	Type string `json:"type"`
+
+	// Title is a short summary of the problem type
+	Title string `json:"title"`
+
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+
+	// Detail is an explanation specific to this occurrence of the problem
+	Detail string `json:"detail,omitempty"`
+
+	// Instance is the request path which caused the problem
+	Instance string `json:"instance,omitempty"`
+
+	// Code is a stable, machine-readable identifier of the problem
+	Code Code `json:"code"`
+
+	// RequestID identifies the request in the API server logs
+	RequestID string `json:"requestId,omitempty"`
+
+	// cause is the error behind a server error, which is logged along with
+	// the request ID instead of being returned to the client
+	cause error
 }
 
-// NewError constructor
-func NewError(err error) Error {
-	e := Error{}
-	e.Errors = make(map[string]interface{})
+// New returns a problem with the given status, code and detail
+func New(status int, code Code, detail string) *Problem {
+	return &Problem{
+		Type:   typePrefix + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Error implements the error interface
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return fmt.Sprintf("%s: %s", p.Code, p.Title)
+	}
+	return fmt.Sprintf("%s: %s", p.Code, p.Detail)
+}
+
+// Unwrap returns the error behind a server error, if any
+func (p *Problem) Unwrap() error {
+	return p.cause
+}
+
+// BadRequest returns an error in case the request could not be parsed
+func BadRequest(err error) *Problem {
+	return New(http.StatusBadRequest, CodeBadRequest, detail(err))
+}
+
+// Forbidden returns an error in case the identity is not allowed to perform the request
+func Forbidden(err error) *Problem {
+	return New(http.StatusForbidden, CodeForbidden, detail(err))
+}
+
+// NotFound returns an error in case a resource was not found
+func NotFound() *Problem {
+	return New(http.StatusNotFound, CodeNotFound, "resource not found")
+}
+
+// InvalidCondition returns an error in case a filter condition could not be parsed
+func InvalidCondition(err error) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidCondition, detail(err))
+}
+
+// ForbiddenField returns an error in case a field or value is not allowed in the request body
+func ForbiddenField(err error) *Problem {
+	return New(http.StatusBadRequest, CodeForbiddenField, detail(err))
+}
+
+// Conflict returns an error in case the resource was modified concurrently
+func Conflict(err error) *Problem {
+	return New(http.StatusConflict, CodeConflict, detail(err))
+}
+
+// RateLimited returns an error in case the identity exceeded its request rate
+func RateLimited() *Problem {
+	return New(http.StatusTooManyRequests, CodeRateLimited, "too many requests")
+}
+
+// UpstreamUnavailable returns an error in case a dependency (database, Kubernetes API) failed.
+// The error is only logged, as it may expose the internals of the API server.
+func UpstreamUnavailable(err error) *Problem {
+	p := New(http.StatusServiceUnavailable, CodeUpstreamUnavailable, "a dependency of the API server is unavailable")
+	p.cause = err
+	return p
+}
+
+// Internal returns an error in case of an unexpected failure. The error is
+// only logged, as it may expose the internals of the API server.
+func Internal(err error) *Problem {
+	p := New(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+	p.cause = err
+	return p
+}
+
+// FromError converts any error to a problem, preserving the status of echo errors
+func FromError(err error) *Problem {
 	switch v := err.(type) {
+	case *Problem:
+		return v
 	case *echo.HTTPError:
-		e.Errors["body"] = v.Message
+		code := CodeInternal
+		switch v.Code {
+		case http.StatusBadRequest:
+			code = CodeBadRequest
+		case http.StatusUnauthorized, http.StatusForbidden:
+			code = CodeForbidden
+		case http.StatusNotFound:
+			code = CodeNotFound
+		case http.StatusConflict:
+			code = CodeConflict
+		case http.StatusTooManyRequests:
+			code = CodeRateLimited
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			code = CodeUpstreamUnavailable
+		}
+		return New(v.Code, code, fmt.Sprintf("%v", v.Message))
 	default:
-		e.Errors["body"] = v.Error()
+		return Internal(err)
 	}
-	return e
 }
 
-// NotFound returns an error in case a resource was not found
-func NotFound() Error {
-	e := Error{}
-	e.Errors = make(map[string]interface{})
-	e.Errors["body"] = "resource not found"
-	return e
+// Render sends the problem details, along with the ID and path of the request
+func Render(c echo.Context, p *Problem) error {
+	res := *p
+	res.Instance = c.Request().URL.Path
+	res.RequestID = requestID(c)
+
+	if res.Status >= http.StatusInternalServerError {
+		cause := res.Error()
+		if res.cause != nil {
+			cause = res.cause.Error()
+		}
+		log.Errorf("Request %s failed: %s", res.RequestID, cause)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(res.Status)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(res.Status, res)
+}
+
+// HTTPErrorHandler renders the errors returned by handlers and middlewares as problem details
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	if err := Render(c, FromError(err)); err != nil {
+		log.Error(err)
+	}
+}
+
+// requestID returns the ID of the request, as set by the request ID middleware or the client
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+func detail(err error) string {
+	if err == nil {
+		return ""
+	}
+	if he, ok := err.(*echo.HTTPError); ok {
+		return fmt.Sprintf("%v", he.Message)
+	}
+	return err.Error()
 }
@@ -13,44 +13,184 @@ governing permissions and limitations under the License.
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestNewError(t *testing.T) {
+func TestNewProblem(t *testing.T) {
 	test := assert.New(t)
 
-	t.Log("Test creating new error.")
+	t.Log("Test creating new problems.")
 
 	tcs := []struct {
-		name          string
-		inputError    Error
-		expectedError Error
+		name           string
+		problem        *Problem
+		expectedStatus int
+		expectedCode   Code
+		expectedDetail string
 	}{
 		{
-			name:          "simple error",
-			inputError:    NewError(fmt.Errorf("Validation failed")),
-			expectedError: Error{Errors: map[string]interface{}{"body": "Validation failed"}},
+			name:           "not found",
+			problem:        NotFound(),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   CodeNotFound,
+			expectedDetail: "resource not found",
 		},
 		{
-			name:          "http error",
-			inputError:    NewError(echo.NewHTTPError(http.StatusBadGateway, "Bad gateway")),
-			expectedError: Error{Errors: map[string]interface{}{"body": "Bad gateway"}},
+			name:           "invalid condition",
+			problem:        InvalidCondition(fmt.Errorf("invalid operator")),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeInvalidCondition,
+			expectedDetail: "invalid operator",
 		},
 		{
-			name:          "simple error",
-			inputError:    NotFound(),
-			expectedError: Error{Errors: map[string]interface{}{"body": "resource not found"}},
+			name:           "forbidden field",
+			problem:        ForbiddenField(fmt.Errorf("invalid tag foo")),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   CodeForbiddenField,
+			expectedDetail: "invalid tag foo",
+		},
+		{
+			name:           "conflict",
+			problem:        Conflict(fmt.Errorf("object has been modified")),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeConflict,
+			expectedDetail: "object has been modified",
+		},
+		{
+			name:           "upstream unavailable",
+			problem:        UpstreamUnavailable(fmt.Errorf("connection refused")),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   CodeUpstreamUnavailable,
+			expectedDetail: "a dependency of the API server is unavailable",
+		},
+		{
+			name:           "rate limited",
+			problem:        RateLimited(),
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   CodeRateLimited,
+			expectedDetail: "too many requests",
+		},
+		{
+			name:           "http error",
+			problem:        FromError(echo.NewHTTPError(http.StatusBadGateway, "Bad gateway")),
+			expectedStatus: http.StatusBadGateway,
+			expectedCode:   CodeUpstreamUnavailable,
+			expectedDetail: "Bad gateway",
+		},
+		{
+			name:           "simple error",
+			problem:        FromError(fmt.Errorf("Validation failed")),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   CodeInternal,
+			expectedDetail: "an unexpected error occurred",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen creating new problem: %v", tc.name, tc.problem)
+
+		test.Equal(tc.expectedStatus, tc.problem.Status)
+		test.Equal(tc.expectedCode, tc.problem.Code)
+		test.Equal(tc.expectedDetail, tc.problem.Detail)
+		test.Equal(http.StatusText(tc.expectedStatus), tc.problem.Title)
+		test.Equal("urn:cluster-registry:error:"+string(tc.expectedCode), tc.problem.Type)
+	}
+
+	cause := fmt.Errorf("connection refused")
+	test.ErrorIs(UpstreamUnavailable(cause), cause)
+}
+
+func TestRender(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rendering problem details.")
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/clusters/foo?bar=baz", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Response().Header().Set(echo.HeaderXRequestID, "4f6d3c1e-6a3c-4b8e-9a1e-2d7c0b0a9c11")
+
+	err := Render(ctx, NotFound())
+	test.NoError(err)
+
+	test.Equal(http.StatusNotFound, rec.Code)
+	test.Equal(MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+
+	var p Problem
+	test.NoError(json.Unmarshal(rec.Body.Bytes(), &p))
+	test.Equal(Problem{
+		Type:      "urn:cluster-registry:error:not_found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "resource not found",
+		Instance:  "/api/v2/clusters/foo",
+		Code:      CodeNotFound,
+		RequestID: "4f6d3c1e-6a3c-4b8e-9a1e-2d7c0b0a9c11",
+	}, p)
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rendering errors returned by handlers.")
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.GET("/conflict", func(c echo.Context) error {
+		return Conflict(fmt.Errorf("object has been modified"))
+	})
+	e.GET("/internal", func(c echo.Context) error {
+		return fmt.Errorf("dial tcp 10.0.0.1:6379: connection refused")
+	})
+
+	tcs := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedCode   Code
+	}{
+		{
+			name:           "problem returned by handler",
+			path:           "/conflict",
+			expectedStatus: http.StatusConflict,
+			expectedCode:   CodeConflict,
+		},
+		{
+			name:           "error returned by handler",
+			path:           "/internal",
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   CodeInternal,
+		},
+		{
+			name:           "unknown route",
+			path:           "/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   CodeNotFound,
 		},
 	}
 
 	for _, tc := range tcs {
-		t.Logf("\tTest %s:\tWhen creating new error with input: %v", tc.name, tc.inputError)
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		rec := httptest.NewRecorder()
+
+		t.Logf("\tTest %s:\tWhen checking for status code %d", tc.name, tc.expectedStatus)
+		e.ServeHTTP(rec, req)
+
+		test.Equal(tc.expectedStatus, rec.Code)
+		test.Equal(MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
 
-		test.Contains(fmt.Sprintf("%v", tc.inputError), fmt.Sprintf("%v", tc.expectedError), "the error message should be as expected")
+		var p Problem
+		test.NoError(json.Unmarshal(rec.Body.Bytes(), &p))
+		test.Equal(tc.expectedCode, p.Code)
+		test.Equal(tc.path, p.Instance)
+		test.NotContains(rec.Body.String(), "10.0.0.1")
 	}
 }
@@ -13,24 +13,40 @@ governing permissions and limitations under the License.
 package event
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/database"
 	"github.com/adobe/cluster-registry/pkg/sqs"
 	"github.com/labstack/gommon/log"
-	"strconv"
 	"time"
 )
 
+// LastSeenMetrics records when the clients of the clusters were last heard from
+type LastSeenMetrics interface {
+	RecordClusterLastSeen(cluster string, timestamp float64)
+}
+
+// Replier sends the acknowledgements of the cluster updates to the clients
+type Replier interface {
+	Reply(ctx context.Context, msg *sqs.Message, reply *sqs.Message) error
+}
+
 type ClusterUpdateHandler struct {
 	sqs.EventHandler
-	db database.Db
+	db      database.Db
+	metrics LastSeenMetrics
+	replier Replier
 }
 
-func NewClusterUpdateHandler(db database.Db) *ClusterUpdateHandler {
+// NewClusterUpdateHandler creates the handler of the cluster updates, which
+// acknowledges the stored updates with the replier, if any
+func NewClusterUpdateHandler(db database.Db, m LastSeenMetrics, r Replier) *ClusterUpdateHandler {
 	return &ClusterUpdateHandler{
-		db: db,
+		db:      db,
+		metrics: m,
+		replier: r,
 	}
 }
 
@@ -38,7 +54,7 @@ func (h *ClusterUpdateHandler) Type() string {
 	return sqs.ClusterUpdateEvent
 }
 
-func (h *ClusterUpdateHandler) Handle(event *sqs.Event) error {
+func (h *ClusterUpdateHandler) Handle(ctx context.Context, event *sqs.Event) error {
 	if event == nil {
 		return errors.New("event is nil")
 	}
@@ -51,53 +67,193 @@ func (h *ClusterUpdateHandler) Handle(event *sqs.Event) error {
 
 	msg := event.Message
 
-	err := json.Unmarshal([]byte(*msg.Body), &rcvCluster)
+	// fields returns the structured log entry of the event
+	fields := func(message string, err error) log.JSON {
+		j := log.JSON{
+			"message":    message,
+			"request_id": event.RequestID(),
+			"message_id": msg.ID,
+			"cluster":    rcvCluster.Spec.Name,
+		}
+		if err != nil {
+			j["error"] = err.Error()
+		}
+		return j
+	}
+
+	err := json.Unmarshal([]byte(msg.Body), &rcvCluster)
 	if err != nil {
-		log.Error("Failed to unmarshal message.")
+		log.Errorj(fields("failed to unmarshal message", err))
 		return err
 	}
 
 	clusterName := rcvCluster.Spec.Name
 
-	msgTimestamp, err := strconv.ParseInt(*msg.Attributes["SentTimestamp"], 10, 64)
-	if err != nil {
-		log.Error("Wrong time format for sqs message:", msg.MessageId)
+	// an invalid cluster would be rejected again on every delivery
+	if err = rcvCluster.Validate(); err != nil {
+		log.Errorj(fields("invalid cluster", err))
+		return sqs.Permanent(err)
+	}
+
+	if msg.SentTimestamp.IsZero() {
+		err = errors.New("missing sent timestamp")
+		log.Errorj(fields("wrong time format for sqs message", err))
 		return err
 	}
-	lastUpdated := time.Unix(0, msgTimestamp*int64(time.Millisecond))
+	lastUpdated := msg.SentTimestamp
 
-	cluster, err := h.db.GetCluster(clusterName)
+	cluster, err := h.db.GetCluster(ctx, clusterName)
 	if err != nil {
-		log.Error("Failed to get cluster ", clusterName, " from database.")
+		log.Errorj(fields("failed to get cluster from database", err))
 		return err
 	}
 
 	if cluster == nil {
 		rcvCluster.Spec.LastUpdated = lastUpdated.UTC().Format(time.RFC3339Nano)
-		err = h.db.PutCluster(&rcvCluster)
+		rcvCluster.Spec.LastSeen = rcvCluster.Spec.LastUpdated
+		err = h.db.PutCluster(ctx, &rcvCluster)
 		if err != nil {
-			log.Error("Cluster ", clusterName, " failed to be created.")
+			log.Errorj(fields("cluster failed to be created", err))
 			return err
 		}
-		log.Info("Cluster ", clusterName, " was created.")
+		h.metrics.RecordClusterLastSeen(clusterName, float64(lastUpdated.Unix()))
+		log.Infoj(fields("cluster was created", nil))
+		h.acknowledge(ctx, event, clusterName, rcvCluster.Spec.LastUpdated)
 		return nil
 	}
 
 	clusterTime, err := time.Parse(time.RFC3339Nano, cluster.Spec.LastUpdated)
 	if err != nil {
-		log.Warn("Wrong time format in database for: ", clusterName)
+		log.Warnj(fields("wrong time format in database", err))
 	} else if lastUpdated.Before(clusterTime) {
-		log.Info("Cluster lastUpdated timestamp is too old. This update will be skip for ", clusterName)
+		log.Infoj(fields("cluster lastUpdated timestamp is too old, skipping update", nil))
+		// the client still waits for an acknowledgement, which carries the
+		// revision of the newer update superseding this one
+		h.acknowledge(ctx, event, clusterName, cluster.Spec.LastUpdated)
 		return nil
 	}
 
 	rcvCluster.Spec.LastUpdated = lastUpdated.UTC().Format(time.RFC3339Nano)
-	err = h.db.PutCluster(&rcvCluster)
+
+	// a heartbeat may have been handled after the update was sent
+	lastSeen := lastUpdated
+	if seen, err := time.Parse(time.RFC3339Nano, cluster.Spec.LastSeen); err == nil && seen.After(lastSeen) {
+		lastSeen = seen
+	}
+	rcvCluster.Spec.LastSeen = lastSeen.UTC().Format(time.RFC3339Nano)
+
+	err = h.db.PutCluster(ctx, &rcvCluster)
 	if err != nil {
-		log.Error("Cluster ", clusterName, " failed to be updated.")
+		log.Errorj(fields("cluster failed to be updated", err))
 		return err
 	}
+	h.metrics.RecordClusterLastSeen(clusterName, float64(lastSeen.Unix()))
 
-	log.Info("Cluster ", clusterName, " was updated.")
+	log.Infoj(fields("cluster was updated", nil))
+	h.acknowledge(ctx, event, clusterName, rcvCluster.Spec.LastUpdated)
 	return err
 }
+
+// acknowledge tells the client that its update is stored, with the revision of
+// the cluster in the registry. The update is not handled again if the
+// acknowledgement fails, as the client sends the next one anyway.
+func (h *ClusterUpdateHandler) acknowledge(ctx context.Context, event *sqs.Event, clusterName, revision string) {
+	if h.replier == nil {
+		return
+	}
+
+	msg := event.Message
+	err := h.replier.Reply(ctx, msg, &sqs.Message{
+		Body: "{}",
+		Attributes: map[string]string{
+			sqs.MessageAttributeType:        sqs.ClusterAckEvent,
+			sqs.MessageAttributeClusterName: clusterName,
+			sqs.MessageAttributeRequestID:   event.RequestID(),
+			sqs.MessageAttributeHash:        msg.Attributes[sqs.MessageAttributeHash],
+			sqs.MessageAttributeRevision:    revision,
+		},
+	})
+	if err != nil {
+		log.Warnj(log.JSON{
+			"message":    "failed to acknowledge cluster update",
+			"request_id": event.RequestID(),
+			"message_id": msg.ID,
+			"cluster":    clusterName,
+			"reply_to":   msg.Attributes[sqs.MessageAttributeReplyTo],
+			"error":      err.Error(),
+		})
+	}
+}
+
+// ClusterHeartbeatHandler records when the client of a cluster was last heard
+// from, without updating the cluster itself
+type ClusterHeartbeatHandler struct {
+	sqs.EventHandler
+	db      database.Db
+	metrics LastSeenMetrics
+}
+
+func NewClusterHeartbeatHandler(db database.Db, m LastSeenMetrics) *ClusterHeartbeatHandler {
+	return &ClusterHeartbeatHandler{
+		db:      db,
+		metrics: m,
+	}
+}
+
+func (h *ClusterHeartbeatHandler) Type() string {
+	return sqs.ClusterHeartbeatEvent
+}
+
+func (h *ClusterHeartbeatHandler) Handle(ctx context.Context, event *sqs.Event) error {
+	if event == nil {
+		return errors.New("event is nil")
+	}
+
+	if event.Type != h.Type() {
+		return errors.New("event type does not match handler type")
+	}
+
+	msg := event.Message
+	clusterName := msg.Attributes[sqs.MessageAttributeClusterName]
+
+	// fields returns the structured log entry of the event
+	fields := func(message string, err error) log.JSON {
+		j := log.JSON{
+			"message":    message,
+			"request_id": event.RequestID(),
+			"message_id": msg.ID,
+			"cluster":    clusterName,
+		}
+		if err != nil {
+			j["error"] = err.Error()
+		}
+		return j
+	}
+
+	if clusterName == "" {
+		err := errors.New("missing cluster name")
+		log.Errorj(fields("invalid heartbeat", err))
+		return sqs.Permanent(err)
+	}
+
+	if msg.SentTimestamp.IsZero() {
+		err := errors.New("missing sent timestamp")
+		log.Errorj(fields("wrong time format for sqs message", err))
+		return err
+	}
+	lastSeen := msg.SentTimestamp
+
+	updated, err := h.db.UpdateLastSeen(ctx, clusterName, lastSeen)
+	if err != nil {
+		log.Errorj(fields("cluster lastSeen failed to be updated", err))
+		return err
+	}
+	if !updated {
+		log.Infoj(fields("cluster is not registered or was seen more recently, skipping heartbeat", nil))
+		return nil
+	}
+	h.metrics.RecordClusterLastSeen(clusterName, float64(lastSeen.Unix()))
+
+	log.Debugj(fields("cluster heartbeat was recorded", nil))
+	return nil
+}
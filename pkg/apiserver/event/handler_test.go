@@ -0,0 +1,278 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/database"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDb struct {
+	database.Db
+	clusters map[string]*registryv1.Cluster
+	lastSeen map[string]time.Time
+	err      error
+}
+
+func (d *fakeDb) GetCluster(_ context.Context, name string) (*registryv1.Cluster, error) {
+	return d.clusters[name], d.err
+}
+
+func (d *fakeDb) PutCluster(_ context.Context, cluster *registryv1.Cluster) error {
+	if d.err != nil {
+		return d.err
+	}
+	d.clusters[cluster.Spec.Name] = cluster
+	return nil
+}
+
+func (d *fakeDb) UpdateLastSeen(_ context.Context, name string, lastSeen time.Time) (bool, error) {
+	if d.err != nil {
+		return false, d.err
+	}
+	seen, ok := d.lastSeen[name]
+	if !ok || seen.After(lastSeen) {
+		return false, nil
+	}
+	d.lastSeen[name] = lastSeen
+	return true, nil
+}
+
+type fakeLastSeenMetrics struct {
+	lastSeen map[string]float64
+}
+
+func (m *fakeLastSeenMetrics) RecordClusterLastSeen(cluster string, timestamp float64) {
+	m.lastSeen[cluster] = timestamp
+}
+
+func TestClusterHeartbeatHandler(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test recording when the clusters were last seen.")
+
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := before.Add(time.Hour)
+
+	tcs := []struct {
+		name              string
+		cluster           string
+		sent              time.Time
+		dbErr             error
+		expectedLastSeen  time.Time
+		expectedRecorded  bool
+		expectedErr       bool
+		expectedPermanent bool
+	}{
+		{
+			name:             "registered cluster",
+			cluster:          "cluster1",
+			sent:             now,
+			expectedLastSeen: now,
+			expectedRecorded: true,
+		},
+		{
+			name:             "out of order heartbeat",
+			cluster:          "cluster1",
+			sent:             before.Add(-time.Hour),
+			expectedLastSeen: before,
+		},
+		{
+			name:    "unregistered cluster",
+			cluster: "cluster2",
+			sent:    now,
+		},
+		{
+			name:              "missing cluster name",
+			sent:              now,
+			expectedErr:       true,
+			expectedPermanent: true,
+		},
+		{
+			name:             "missing sent timestamp",
+			cluster:          "cluster1",
+			expectedLastSeen: before,
+			expectedErr:      true,
+		},
+		{
+			name:             "database error",
+			cluster:          "cluster1",
+			sent:             now,
+			dbErr:            errors.New("unavailable"),
+			expectedLastSeen: before,
+			expectedErr:      true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen handling a heartbeat of %q", tc.name, tc.cluster)
+
+		db := &fakeDb{lastSeen: map[string]time.Time{"cluster1": before}, err: tc.dbErr}
+		m := &fakeLastSeenMetrics{lastSeen: map[string]float64{}}
+		h := NewClusterHeartbeatHandler(db, m)
+
+		msg := &sqs.Message{
+			Attributes:    map[string]string{sqs.MessageAttributeType: sqs.ClusterHeartbeatEvent},
+			SentTimestamp: tc.sent,
+		}
+		if tc.cluster != "" {
+			msg.Attributes[sqs.MessageAttributeClusterName] = tc.cluster
+		}
+
+		err := h.Handle(context.Background(), &sqs.Event{Type: sqs.ClusterHeartbeatEvent, Message: msg})
+		if tc.expectedErr {
+			test.Error(err)
+			test.Equal(tc.expectedPermanent, sqs.IsPermanent(err))
+		} else {
+			test.NoError(err)
+		}
+
+		test.Equal(tc.expectedLastSeen, db.lastSeen[tc.cluster])
+		if tc.expectedRecorded {
+			test.Equal(float64(tc.sent.Unix()), m.lastSeen[tc.cluster])
+		} else {
+			test.Empty(m.lastSeen)
+		}
+	}
+}
+
+type fakeReplier struct {
+	replies []*sqs.Message
+	err     error
+}
+
+func (r *fakeReplier) Reply(_ context.Context, msg *sqs.Message, reply *sqs.Message) error {
+	if msg.Attributes[sqs.MessageAttributeReplyTo] == "" {
+		return nil
+	}
+	r.replies = append(r.replies, reply)
+	return r.err
+}
+
+func TestClusterUpdateHandlerAcknowledgement(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test acknowledging the cluster updates once stored.")
+
+	stored := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := []struct {
+		name             string
+		replyTo          string
+		sent             time.Time
+		dbErr            error
+		replyErr         error
+		expectedErr      bool
+		expectedRevision string
+	}{
+		{
+			name:             "stored update",
+			replyTo:          "cluster1-acks",
+			sent:             stored.Add(time.Hour),
+			expectedRevision: stored.Add(time.Hour).Format(time.RFC3339Nano),
+		},
+		{
+			name: "no reply expected",
+			sent: stored.Add(time.Hour),
+		},
+		{
+			name:             "outdated update",
+			replyTo:          "cluster1-acks",
+			sent:             stored.Add(-time.Hour),
+			expectedRevision: stored.Format(time.RFC3339Nano),
+		},
+		{
+			name:        "database error",
+			replyTo:     "cluster1-acks",
+			sent:        stored.Add(time.Hour),
+			dbErr:       errors.New("unavailable"),
+			expectedErr: true,
+		},
+		{
+			name:             "acknowledgement error",
+			replyTo:          "cluster1-acks",
+			sent:             stored.Add(time.Hour),
+			replyErr:         errors.New("queue does not exist"),
+			expectedRevision: stored.Add(time.Hour).Format(time.RFC3339Nano),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen handling an update replying to %q", tc.name, tc.replyTo)
+
+		db := &fakeDb{clusters: map[string]*registryv1.Cluster{
+			"cluster1": {Spec: registryv1.ClusterSpec{Name: "cluster1", LastUpdated: stored.Format(time.RFC3339Nano)}},
+		}}
+		m := &fakeLastSeenMetrics{lastSeen: map[string]float64{}}
+		r := &fakeReplier{err: tc.replyErr}
+		h := NewClusterUpdateHandler(db, m, r)
+		db.err = tc.dbErr
+
+		msg := &sqs.Message{
+			Body: `{"spec":{"name":"cluster1"}}`,
+			Attributes: map[string]string{
+				sqs.MessageAttributeType:      sqs.ClusterUpdateEvent,
+				sqs.MessageAttributeRequestID: "request1",
+				sqs.MessageAttributeHash:      "hash1",
+			},
+			SentTimestamp: tc.sent,
+		}
+		if tc.replyTo != "" {
+			msg.Attributes[sqs.MessageAttributeReplyTo] = tc.replyTo
+		}
+
+		err := h.Handle(context.Background(), &sqs.Event{Type: sqs.ClusterUpdateEvent, Message: msg})
+		if tc.expectedErr {
+			test.Error(err)
+		} else {
+			test.NoError(err)
+		}
+
+		if tc.expectedRevision == "" {
+			test.Empty(r.replies)
+			continue
+		}
+		test.Len(r.replies, 1)
+		reply := r.replies[0]
+		test.Equal(sqs.ClusterAckEvent, reply.Attributes[sqs.MessageAttributeType])
+		test.Equal("cluster1", reply.Attributes[sqs.MessageAttributeClusterName])
+		test.Equal("request1", reply.Attributes[sqs.MessageAttributeRequestID])
+		test.Equal("hash1", reply.Attributes[sqs.MessageAttributeHash])
+		test.Equal(tc.expectedRevision, reply.Attributes[sqs.MessageAttributeRevision])
+	}
+}
+
+func TestClusterUpdateHandlerValidation(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rejecting the invalid cluster updates.")
+
+	db := &fakeDb{clusters: map[string]*registryv1.Cluster{}}
+	h := NewClusterUpdateHandler(db, &fakeLastSeenMetrics{lastSeen: map[string]float64{}}, &fakeReplier{})
+
+	err := h.Handle(context.Background(), &sqs.Event{Type: sqs.ClusterUpdateEvent, Message: &sqs.Message{
+		Body:          `{"spec":{"name":"cluster1","tiers":[{"name":"worker","minCapacity":5,"maxCapacity":1}]}}`,
+		Attributes:    map[string]string{sqs.MessageAttributeType: sqs.ClusterUpdateEvent},
+		SentTimestamp: time.Now(),
+	}})
+
+	test.True(sqs.IsPermanent(err))
+	test.Empty(db.clusters)
+}
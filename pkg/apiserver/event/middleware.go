@@ -0,0 +1,150 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/labstack/gommon/log"
+)
+
+// CacheInvalidator is the part of the cache used by InvalidateCache
+type CacheInvalidator interface {
+	Invalidate(ctx context.Context, options ...store.InvalidateOption) error
+}
+
+// InvalidateCache invalidates the cached clusters once an event is handled,
+// unless the message asks to skip it
+func InvalidateCache(c CacheInvalidator) sqs.Middleware {
+	return func(next sqs.HandlerFunc) sqs.HandlerFunc {
+		return func(ctx context.Context, event *sqs.Event) error {
+			if err := next(ctx, event); err != nil {
+				return err
+			}
+
+			if event.Message.Attributes[sqs.MessageAttributeSkipCacheInvalidation] == "true" {
+				log.Debugf("Skipping cache invalidation")
+				return nil
+			}
+
+			log.Debugf("Invalidating clusters cache")
+			if err := c.Invalidate(ctx, store.WithInvalidateTags([]string{"clusters"})); err != nil {
+				log.Errorf("Failed to invalidate clusters cache: %s", err.Error())
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// ErrSignerMismatch is returned for the cluster updates signed by another
+// cluster than the updated one
+var ErrSignerMismatch = errors.New("message signer does not match the cluster")
+
+// RejectionMetrics records the messages rejected by Authenticate
+type RejectionMetrics interface {
+	RecordQueueRejectedCnt(reason string)
+}
+
+// Authenticate rejects the messages which are not signed by a trusted
+// identity, and the cluster updates and heartbeats which are not signed by the
+// cluster itself. The rejections are permanent, so that the messages are
+// dead-lettered without being retried. The signature covers the body as sent,
+// which is decoded once authenticated. The messages signed more than maxAge
+// ago are rejected too, so that a captured message cannot be replayed later,
+// unless maxAge is zero. The dead letters replayed by the API server are signed
+// by the replayer identity instead of the cluster, and are trusted as such when
+// it is set.
+func Authenticate(v *sqs.Verifier, replayer string, maxAge time.Duration, m RejectionMetrics) sqs.Middleware {
+	return func(next sqs.HandlerFunc) sqs.HandlerFunc {
+		return func(ctx context.Context, event *sqs.Event) error {
+			signer, err := v.Verify(event.Message)
+			if err == nil && maxAge > 0 {
+				err = sqs.CheckSignedAt(event.Message, time.Now(), maxAge)
+			}
+			if err == nil {
+				// the body is decoded once its signature is checked
+				if decodeErr := event.Message.Decode(ctx); decodeErr != nil {
+					return decodeErr
+				}
+				switch {
+				case replayer != "" && signer == replayer:
+				case event.Type == sqs.ClusterUpdateEvent:
+					err = matchSigner(event.Message, signer)
+				case event.Type == sqs.ClusterHeartbeatEvent:
+					err = matchHeartbeatSigner(event.Message, signer)
+				}
+			}
+			if err != nil {
+				reason := rejectionReason(err)
+				m.RecordQueueRejectedCnt(reason)
+				log.Warnj(log.JSON{
+					"message":    "rejected untrusted message",
+					"reason":     reason,
+					"signer":     signer,
+					"request_id": event.RequestID(),
+					"message_id": event.Message.ID,
+					"error":      err.Error(),
+				})
+				return sqs.Permanent(fmt.Errorf("%w: %w", sqs.ErrUnauthenticated, err))
+			}
+			return next(ctx, event)
+		}
+	}
+}
+
+// matchSigner checks that the updated cluster is the one which signed the message
+func matchSigner(msg *sqs.Message, signer string) error {
+	var cluster registryv1.Cluster
+	if err := json.Unmarshal([]byte(msg.Body), &cluster); err != nil {
+		return err
+	}
+	if cluster.Spec.Name != signer {
+		return fmt.Errorf("%w: signed by %s for %s", ErrSignerMismatch, signer, cluster.Spec.Name)
+	}
+	return nil
+}
+
+// matchHeartbeatSigner checks that the cluster sending the heartbeat is the one
+// which signed the message
+func matchHeartbeatSigner(msg *sqs.Message, signer string) error {
+	name := msg.Attributes[sqs.MessageAttributeClusterName]
+	if name != signer {
+		return fmt.Errorf("%w: signed by %s for %s", ErrSignerMismatch, signer, name)
+	}
+	return nil
+}
+
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, sqs.ErrUnsigned):
+		return "unsigned"
+	case errors.Is(err, sqs.ErrUnknownSigner):
+		return "unknown_signer"
+	case errors.Is(err, sqs.ErrInvalidSignature):
+		return "invalid_signature"
+	case errors.Is(err, sqs.ErrStaleSignature):
+		return "stale_signature"
+	case errors.Is(err, ErrSignerMismatch):
+		return "signer_mismatch"
+	default:
+		return "malformed"
+	}
+}
@@ -0,0 +1,379 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package event
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/eko/gocache/lib/v4/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCache struct {
+	invalidated int
+	err         error
+}
+
+func (c *fakeCache) Invalidate(_ context.Context, _ ...store.InvalidateOption) error {
+	c.invalidated++
+	return c.err
+}
+
+func TestInvalidateCache(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test invalidating the clusters cache after handling events.")
+
+	tcs := []struct {
+		name                string
+		skip                string
+		handlerErr          error
+		cacheErr            error
+		expectedInvalidated int
+		expectedErr         bool
+	}{
+		{
+			name:                "handled event",
+			expectedInvalidated: 1,
+		},
+		{
+			name:                "skipped invalidation",
+			skip:                "true",
+			expectedInvalidated: 0,
+		},
+		{
+			name:                "failed event",
+			handlerErr:          errors.New("database unavailable"),
+			expectedInvalidated: 0,
+			expectedErr:         true,
+		},
+		{
+			name:                "failed invalidation",
+			cacheErr:            errors.New("redis unavailable"),
+			expectedInvalidated: 1,
+			expectedErr:         true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen handling event with %s=%q", tc.name, sqs.MessageAttributeSkipCacheInvalidation, tc.skip)
+
+		c := &fakeCache{err: tc.cacheErr}
+		handler := InvalidateCache(c)(func(_ context.Context, _ *sqs.Event) error {
+			return tc.handlerErr
+		})
+
+		err := handler(context.Background(), &sqs.Event{
+			Type: sqs.ClusterUpdateEvent,
+			Message: &sqs.Message{
+				Attributes: map[string]string{
+					sqs.MessageAttributeSkipCacheInvalidation: tc.skip,
+				},
+			},
+		})
+
+		test.Equal(tc.expectedErr, err != nil)
+		test.Equal(tc.expectedInvalidated, c.invalidated)
+	}
+}
+
+type fakeRejectionMetrics struct {
+	reasons []string
+}
+
+func (m *fakeRejectionMetrics) RecordQueueRejectedCnt(reason string) {
+	m.reasons = append(m.reasons, reason)
+}
+
+func TestAuthenticate(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rejecting the messages which are not signed by the updated cluster.")
+
+	pub1, priv1, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	pub2, priv2, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	_, priv3, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	replayerPub, replayerPriv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+
+	verifier := sqs.NewVerifier(map[string]ed25519.PublicKey{
+		"cluster1":         pub1,
+		"cluster2":         pub2,
+		"cluster-registry": replayerPub,
+	})
+
+	tcs := []struct {
+		name            string
+		signer          string
+		key             ed25519.PrivateKey
+		eventType       string
+		cluster         string
+		body            string
+		maxAge          time.Duration
+		expectedHandled int
+		expectedReason  string
+	}{
+		{
+			name:            "signed by the updated cluster",
+			signer:          "cluster1",
+			key:             priv1,
+			eventType:       sqs.ClusterUpdateEvent,
+			body:            `{"spec":{"name":"cluster1"}}`,
+			expectedHandled: 1,
+		},
+		{
+			name:           "signed by another cluster",
+			signer:         "cluster2",
+			key:            priv2,
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{"spec":{"name":"cluster1"}}`,
+			expectedReason: "signer_mismatch",
+		},
+		{
+			name:            "replayed by the API server",
+			signer:          "cluster-registry",
+			key:             replayerPriv,
+			eventType:       sqs.ClusterUpdateEvent,
+			body:            `{"spec":{"name":"cluster1"}}`,
+			expectedHandled: 1,
+		},
+		{
+			name:           "signed by an untrusted cluster",
+			signer:         "cluster3",
+			key:            priv3,
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{"spec":{"name":"cluster3"}}`,
+			expectedReason: "unknown_signer",
+		},
+		{
+			name:           "signed with another key",
+			signer:         "cluster1",
+			key:            priv3,
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{"spec":{"name":"cluster1"}}`,
+			expectedReason: "invalid_signature",
+		},
+		{
+			name:           "replayed after the max age",
+			signer:         "cluster1",
+			key:            priv1,
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{"spec":{"name":"cluster1"}}`,
+			maxAge:         time.Nanosecond,
+			expectedReason: "stale_signature",
+		},
+		{
+			name:           "unsigned",
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{"spec":{"name":"cluster1"}}`,
+			expectedReason: "unsigned",
+		},
+		{
+			name:           "malformed body",
+			signer:         "cluster1",
+			key:            priv1,
+			eventType:      sqs.ClusterUpdateEvent,
+			body:           `{`,
+			expectedReason: "malformed",
+		},
+		{
+			name:            "heartbeat signed by the cluster",
+			signer:          "cluster1",
+			key:             priv1,
+			eventType:       sqs.ClusterHeartbeatEvent,
+			cluster:         "cluster1",
+			expectedHandled: 1,
+		},
+		{
+			name:           "heartbeat signed by another cluster",
+			signer:         "cluster2",
+			key:            priv2,
+			eventType:      sqs.ClusterHeartbeatEvent,
+			cluster:        "cluster1",
+			expectedReason: "signer_mismatch",
+		},
+		{
+			name:            "other event signed by a trusted cluster",
+			signer:          "cluster2",
+			key:             priv2,
+			eventType:       sqs.PartialClusterUpdateEvent,
+			body:            `{}`,
+			expectedHandled: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen handling a %s event signed by %q", tc.name, tc.eventType, tc.signer)
+
+		msg := &sqs.Message{
+			Body:       tc.body,
+			Attributes: map[string]string{sqs.MessageAttributeType: tc.eventType},
+		}
+		if tc.cluster != "" {
+			msg.Attributes[sqs.MessageAttributeClusterName] = tc.cluster
+		}
+		if tc.signer != "" {
+			signer, err := sqs.NewSigner(tc.signer, tc.key)
+			test.NoError(err)
+			signer.Sign(msg)
+		}
+
+		maxAge := tc.maxAge
+		if maxAge == 0 {
+			maxAge = time.Hour
+		}
+
+		m := &fakeRejectionMetrics{}
+		handled := 0
+		handler := Authenticate(verifier, "cluster-registry", maxAge, m)(func(_ context.Context, _ *sqs.Event) error {
+			handled++
+			return nil
+		})
+
+		err := handler(context.Background(), &sqs.Event{Type: tc.eventType, Message: msg})
+
+		test.Equal(tc.expectedHandled, handled)
+		if tc.expectedReason == "" {
+			test.NoError(err)
+			test.Empty(m.reasons)
+		} else {
+			test.True(sqs.IsPermanent(err))
+			test.ErrorIs(err, sqs.ErrUnauthenticated)
+			test.Equal([]string{tc.expectedReason}, m.reasons)
+		}
+	}
+}
+
+type fakeUpdateHandler struct {
+	handled int
+}
+
+func (h *fakeUpdateHandler) Type() string {
+	return sqs.ClusterUpdateEvent
+}
+
+func (h *fakeUpdateHandler) Handle(_ context.Context, _ *sqs.Event) error {
+	h.handled++
+	return nil
+}
+
+func TestAuthenticateReplay(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test authenticating the dead letters replayed by the API server after the max age.")
+
+	ctx := context.Background()
+	maxAge := 100 * time.Millisecond
+
+	pub1, priv1, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	pub2, priv2, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	replayerPub, replayerPriv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	verifier := sqs.NewVerifier(map[string]ed25519.PublicKey{
+		"cluster1":         pub1,
+		"cluster2":         pub2,
+		"cluster-registry": replayerPub,
+	})
+	replayer, err := sqs.NewSigner("cluster-registry", replayerPriv)
+	test.NoError(err)
+
+	q, err := sqs.NewMemory(sqs.Config{VisibilityTimeout: 60, RunOnce: true, Signer: replayer})
+	test.NoError(err)
+	store := sqs.NewMemoryDeadLetterStore()
+	m := &fakeRejectionMetrics{}
+	authenticate := Authenticate(verifier, "cluster-registry", maxAge, m)
+
+	d, err := sqs.NewDispatcher(q, sqs.DispatcherOptions{DeadLetter: store})
+	test.NoError(err)
+	d.Use(authenticate)
+	h := &fakeUpdateHandler{}
+	d.Register(h, sqs.AckOnSuccess)
+
+	tcs := []struct {
+		name            string
+		signer          string
+		key             ed25519.PrivateKey
+		rejected        bool
+		expectedHandled int
+		expectedErr     error
+	}{
+		{
+			name:            "failed update signed by the cluster",
+			signer:          "cluster1",
+			key:             priv1,
+			expectedHandled: 1,
+		},
+		{
+			name:        "update rejected as signed by another cluster",
+			signer:      "cluster2",
+			key:         priv2,
+			rejected:    true,
+			expectedErr: sqs.ErrDeadLetterUnauthenticated,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("	Test %s:	When replaying a dead letter signed by %s", tc.name, tc.signer)
+
+		h.handled = 0
+		m.reasons = nil
+
+		signer, err := sqs.NewSigner(tc.signer, tc.key)
+		test.NoError(err)
+		msg := &sqs.Message{
+			ID:         tc.name,
+			Body:       `{"spec":{"name":"cluster1"}}`,
+			Attributes: map[string]string{sqs.MessageAttributeType: sqs.ClusterUpdateEvent},
+		}
+		signer.Sign(msg)
+
+		reason := errors.New("database unavailable")
+		if tc.rejected {
+			reason = authenticate(func(_ context.Context, _ *sqs.Event) error {
+				return nil
+			})(ctx, &sqs.Event{Type: sqs.ClusterUpdateEvent, Message: msg})
+			m.reasons = nil
+		}
+		test.NoError(store.DeadLetter(ctx, msg, reason))
+
+		dls, err := store.List(ctx)
+		test.NoError(err)
+		test.Len(dls, 1)
+
+		// the original signature is older than the max age once replayed
+		time.Sleep(2 * maxAge)
+
+		_, err = sqs.Replay(ctx, q, store, dls[0].ID)
+		if tc.expectedErr != nil {
+			test.ErrorIs(err, tc.expectedErr)
+			test.NoError(store.Delete(ctx, dls[0].ID))
+			continue
+		}
+		test.NoError(err)
+
+		test.NoError(q.Poll(ctx, d.Handle))
+		test.Equal(tc.expectedHandled, h.handled)
+		test.Empty(m.reasons)
+	}
+}
@@ -30,12 +30,19 @@ import (
 	"strings"
 )
 
+// perRequestHeaders are the response headers which describe the current
+// request rather than the response value, and are neither cached nor replayed
+var perRequestHeaders = []string{echo.HeaderXRequestID, echo.HeaderSetCookie}
+
 type Response struct {
 	// Value is the cached response value.
 	Value []byte
 
 	// Header is the cached response header.
 	Header http.Header
+
+	// ETag is the entity tag of the cached response value.
+	ETag string
 }
 
 func StringToResponse(s string) Response {
@@ -59,7 +66,7 @@ func HTTPCache(client *cache.Cache[string], appConfig *config.AppConfig, tags []
 		return func(c echo.Context) error {
 			if c.Request().Method == http.MethodGet {
 				sortURLParams(c.Request().URL)
-				key := GenerateKey(c.Request().URL.String())
+				key := CacheKey(c.Request())
 
 				cachedResponse, err := client.Get(c.Request().Context(), key)
 				response := StringToResponse(cachedResponse)
@@ -70,9 +77,18 @@ func HTTPCache(client *cache.Cache[string], appConfig *config.AppConfig, tags []
 				// if key in cache
 				if cachedResponse != "" {
 					// return body from cache
-					for k, v := range response.Header {
+					for k, v := range cacheableHeader(response.Header) {
 						c.Response().Header().Set(k, strings.Join(v, ","))
 					}
+					if response.ETag != "" {
+						c.Response().Header().Set(HeaderETag, response.ETag)
+					}
+
+					lastModified, _ := http.ParseTime(response.Header.Get(echo.HeaderLastModified))
+					if NotModified(c.Request(), response.ETag, lastModified) {
+						return c.NoContent(http.StatusNotModified)
+					}
+
 					c.Response().WriteHeader(http.StatusOK)
 					_, _ = c.Response().Write(response.Value)
 
@@ -87,10 +103,18 @@ func HTTPCache(client *cache.Cache[string], appConfig *config.AppConfig, tags []
 				if err := next(c); err != nil {
 					c.Error(err)
 				}
-				if writer.statusCode < 400 {
+				// not modified responses have no body, so there is nothing to cache,
+				// and the time dependent ones opt out with no-store
+				noStore := strings.Contains(writer.Header().Get(echo.HeaderCacheControl), "no-store")
+				if writer.statusCode < 400 && writer.statusCode != http.StatusNotModified && !noStore {
+					etag := writer.Header().Get(HeaderETag)
+					if etag == "" {
+						etag = GenerateETag(resBody.Bytes())
+					}
 					newResponse := Response{
 						Value:  resBody.Bytes(),
-						Header: writer.Header(),
+						Header: cacheableHeader(writer.Header()),
+						ETag:   etag,
 					}
 					err := client.Set(c.Request().Context(), key, newResponse.String(), store.WithExpiration(appConfig.ApiCacheTTL), store.WithTags(tags))
 					if err != nil {
@@ -108,6 +132,15 @@ func HTTPCache(client *cache.Cache[string], appConfig *config.AppConfig, tags []
 	}
 }
 
+// cacheableHeader returns a copy of the header without the per request headers
+func cacheableHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, k := range perRequestHeaders {
+		h.Del(k)
+	}
+	return h
+}
+
 func sortURLParams(URL *url.URL) {
 	params := URL.Query()
 	for _, param := range params {
@@ -118,6 +151,12 @@ func sortURLParams(URL *url.URL) {
 	URL.RawQuery = params.Encode()
 }
 
+// CacheKey returns the cache key of a request, which depends on both the
+// request URL and the negotiated media type
+func CacheKey(r *http.Request) string {
+	return GenerateKey(NegotiateMediaType(r) + " " + r.URL.String())
+}
+
 func GenerateKey(URL string) string {
 	hash := fnv.New64a()
 	_, err := hash.Write([]byte(URL))
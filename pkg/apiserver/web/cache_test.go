@@ -0,0 +1,95 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eko/gocache/lib/v4/cache"
+	redisstore "github.com/eko/gocache/store/redis/v4"
+	"github.com/go-redis/redismock/v9"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+)
+
+func TestHTTPCacheRequestID(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that a cached response keeps the request ID of each request.")
+
+	redisClient, redisMock := redismock.NewClientMock()
+	cacheManager := cache.New[string](redisstore.NewRedis(redisClient))
+	appConfig := &config.AppConfig{}
+
+	e := echo.New()
+	handler := RequestID()(HTTPCache(cacheManager, appConfig, nil)(func(c echo.Context) error {
+		return c.String(http.StatusOK, "clusters")
+	}))
+
+	tcs := []struct {
+		name      string
+		requestID string
+		cached    bool
+	}{
+		{
+			name:      "cache miss",
+			requestID: "request-1",
+		},
+		{
+			name:      "cache hit",
+			requestID: "request-2",
+			cached:    true,
+		},
+	}
+
+	var cached string
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen requesting with the request ID %s", tc.name, tc.requestID)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", nil)
+		req.Header.Set(echo.HeaderXRequestID, tc.requestID)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		key := CacheKey(req)
+
+		if tc.cached {
+			redisMock.ExpectGet(key).SetVal(cached)
+		} else {
+			redisMock.ExpectGet(key).SetVal("")
+			redisMock.CustomMatch(func(_, actual []interface{}) error {
+				if len(actual) < 3 {
+					return fmt.Errorf("unexpected arguments: %v", actual)
+				}
+				value, ok := actual[2].(string)
+				if !ok {
+					return fmt.Errorf("unexpected cache value type: %T", actual[2])
+				}
+				cached = value
+				return nil
+			}).ExpectSet(key, "", appConfig.ApiCacheTTL).SetVal("OK")
+		}
+
+		test.NoError(handler(ctx))
+		test.NoError(redisMock.ExpectationsWereMet())
+
+		test.Equal(http.StatusOK, rec.Code)
+		test.Equal("clusters", rec.Body.String())
+		test.Equal(tc.requestID, rec.Header().Get(echo.HeaderXRequestID))
+		test.NotContains(StringToResponse(cached).Header, echo.HeaderXRequestID)
+	}
+}
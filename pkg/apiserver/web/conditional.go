@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderETag is the response header carrying the entity tag
+	HeaderETag = "ETag"
+
+	// HeaderIfNoneMatch is the request header carrying the entity tags known by the client
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// GenerateETag returns a strong entity tag computed from the response body
+func GenerateETag(body []byte) string {
+	return fmt.Sprintf("\"%x\"", sha256.Sum256(body))
+}
+
+// ClustersLastModified returns the most recent LastUpdated timestamp of the given clusters
+func ClustersLastModified(clusters ...registryv1.Cluster) time.Time {
+	var lastModified time.Time
+	for _, c := range clusters {
+		t, err := time.Parse(time.RFC3339Nano, c.Spec.LastUpdated)
+		if err != nil {
+			continue
+		}
+		if t.After(lastModified) {
+			lastModified = t
+		}
+	}
+	return lastModified
+}
+
+// NotModified checks the request preconditions against the entity tag and the
+// last modification time of the response. If-None-Match takes precedence over
+// If-Modified-Since, as described in RFC 9110.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get(HeaderIfNoneMatch); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, t := range strings.Split(inm, ",") {
+			t = strings.TrimSpace(t)
+			if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get(echo.HeaderIfModifiedSince); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have a one second resolution
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// ConditionalJSON sends a JSON response along with its ETag and Last-Modified
+// headers, or an empty 304 response if the client copy is still fresh
+func ConditionalJSON(c echo.Context, code int, i interface{}, lastModified time.Time) error {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(i); err != nil {
+		return err
+	}
+	return ConditionalBlob(c, code, echo.MIMEApplicationJSON, b.Bytes(), lastModified)
+}
+
+// ConditionalBlob sends an already encoded response along with its ETag and
+// Last-Modified headers, or an empty 304 response if the client copy is still fresh
+func ConditionalBlob(c echo.Context, code int, contentType string, body []byte, lastModified time.Time) error {
+	etag := GenerateETag(body)
+	c.Response().Header().Set(HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Response().Header().Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if code == http.StatusOK && NotModified(c.Request(), etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(code, contentType, body)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotModified(t *testing.T) {
+	test := assert.New(t)
+
+	etag := GenerateETag([]byte("foo"))
+	lastModified := time.Date(2020, 2, 14, 6, 15, 32, 500, time.UTC)
+
+	tcs := []struct {
+		name     string
+		method   string
+		headers  map[string]string
+		expected bool
+	}{
+		{
+			name:     "no preconditions",
+			method:   http.MethodGet,
+			expected: false,
+		},
+		{
+			name:     "matching etag",
+			method:   http.MethodGet,
+			headers:  map[string]string{HeaderIfNoneMatch: etag},
+			expected: true,
+		},
+		{
+			name:     "weak matching etag",
+			method:   http.MethodGet,
+			headers:  map[string]string{HeaderIfNoneMatch: "W/" + etag},
+			expected: true,
+		},
+		{
+			name:     "wildcard etag",
+			method:   http.MethodGet,
+			headers:  map[string]string{HeaderIfNoneMatch: "*"},
+			expected: true,
+		},
+		{
+			name:     "etag takes precedence over date",
+			method:   http.MethodGet,
+			headers:  map[string]string{HeaderIfNoneMatch: `"bar"`, echo.HeaderIfModifiedSince: "Fri, 14 Feb 2020 06:15:32 GMT"},
+			expected: false,
+		},
+		{
+			name:     "not modified since",
+			method:   http.MethodGet,
+			headers:  map[string]string{echo.HeaderIfModifiedSince: "Fri, 14 Feb 2020 06:15:32 GMT"},
+			expected: true,
+		},
+		{
+			name:     "modified since",
+			method:   http.MethodGet,
+			headers:  map[string]string{echo.HeaderIfModifiedSince: "Fri, 14 Feb 2020 06:15:31 GMT"},
+			expected: false,
+		},
+		{
+			name:     "invalid date",
+			method:   http.MethodGet,
+			headers:  map[string]string{echo.HeaderIfModifiedSince: "yesterday"},
+			expected: false,
+		},
+		{
+			name:     "unsafe method",
+			method:   http.MethodPatch,
+			headers:  map[string]string{HeaderIfNoneMatch: etag},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen checking request preconditions", tc.name)
+
+		req := httptest.NewRequest(tc.method, "/api/v2/clusters", nil)
+		for k, v := range tc.headers {
+			req.Header.Set(k, v)
+		}
+
+		test.Equal(tc.expected, NotModified(req, etag, lastModified))
+	}
+}
+
+func TestClustersLastModified(t *testing.T) {
+	test := assert.New(t)
+
+	clusters := []registryv1.Cluster{
+		{Spec: registryv1.ClusterSpec{LastUpdated: "2020-02-14T06:15:32Z"}},
+		{Spec: registryv1.ClusterSpec{LastUpdated: "2020-03-14T06:15:32.123456Z"}},
+		{Spec: registryv1.ClusterSpec{LastUpdated: "invalid"}},
+	}
+
+	test.Equal(time.Date(2020, 3, 14, 6, 15, 32, 123456000, time.UTC), ClustersLastModified(clusters...))
+	test.True(ClustersLastModified().IsZero())
+}
@@ -13,9 +13,11 @@ governing permissions and limitations under the License.
 package v1
 
 import (
+	"context"
+	"fmt"
 	"github.com/eko/gocache/lib/v4/cache"
-	"net/http"
 	"strconv"
+	"time"
 
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
@@ -70,27 +72,34 @@ func (h *handler) Register(v1 *echo.Group) {
 // @ID v1-get-cluster
 // @Tags cluster
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param name path string true "Name of the cluster to get"
+// @Param columns query string false "Comma separated ClusterSpec paths to include in CSV responses"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Param If-Modified-Since header string false "Date of a previously fetched response"
 // @Success 200 {object} registryv1.ClusterSpec
-// @Failure 400 {object} errors.Error
-// @Failure 500 {object} errors.Error
+// @Header 200 {string} ETag "Strong entity tag of the response"
+// @Header 200 {string} Last-Modified "Time when the cluster was last updated"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v1/clusters/{name} [get]
 func (h *handler) GetCluster(ctx echo.Context) error {
 
 	name := ctx.Param("name")
-	c, err := getCluster(h.db, name)
+	c, err := getCluster(ctx.Request().Context(), h.db, name)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, errors.NewError(err))
+		return errors.Render(ctx, errors.UpstreamUnavailable(err))
 	}
 
 	if c == nil {
-		return ctx.JSON(http.StatusNotFound, errors.NotFound())
+		return errors.Render(ctx, errors.NotFound())
 	}
 
-	return ctx.JSON(http.StatusOK, newClusterResponse(ctx, c))
+	return web.Render(ctx, newClusterResponse(ctx, c), web.ClustersLastModified(*c), web.DefaultClusterColumns)
 }
 
 // ListClusters godoc
@@ -99,15 +108,20 @@ func (h *handler) GetCluster(ctx echo.Context) error {
 // @ID v1-get-clusters
 // @Tags cluster
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param region query string false "Filter by region"
 // @Param environment query string false "Filter by environment"
 // @Param status query string false "Filter by status"
 // @Param lastUpdated query string false "Filter since last updated (RFC3339)"
+// @Param columns query string false "Comma separated ClusterSpec paths to include in CSV responses"
 // @Param offset query integer false "Offset to start pagination search results (default is 0)"
 // @Param limit query integer false "The number of results per page (default is 200)"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
 // @Success 200 {object} clusterList
-// @Failure 500 {object} errors.Error
+// @Header 200 {string} ETag "Strong entity tag of the response"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v1/clusters [get]
 func (h *handler) ListClusters(ctx echo.Context) error {
@@ -120,6 +134,12 @@ func (h *handler) ListClusters(ctx echo.Context) error {
 	status := ctx.QueryParam("status")
 	lastUpdated := ctx.QueryParam("lastUpdated")
 
+	if lastUpdated != "" {
+		if _, err := time.Parse(time.RFC3339, lastUpdated); err != nil {
+			return errors.Render(ctx, errors.InvalidCondition(fmt.Errorf("lastUpdated must be a RFC3339 timestamp: %v", err)))
+		}
+	}
+
 	offset, err := strconv.Atoi(ctx.QueryParam("offset"))
 	if err != nil {
 		offset = 0
@@ -130,17 +150,20 @@ func (h *handler) ListClusters(ctx echo.Context) error {
 		limit = 200
 	}
 
-	clusters, count, more, _ := h.db.ListClusters(offset, limit, region, environment, status, lastUpdated)
-	return ctx.JSON(http.StatusOK, newClusterListResponse(clusters, count, offset, limit, more))
+	clusters, count, more, err := h.db.ListClusters(ctx.Request().Context(), offset, limit, region, environment, status, lastUpdated)
+	if err != nil {
+		return errors.Render(ctx, errors.UpstreamUnavailable(err))
+	}
+	return renderClusterList(ctx, clusters, count, offset, limit, more)
 }
 
 // getCluster by standard name or short name
-func getCluster(db database.Db, name string) (*registryv1.Cluster, error) {
+func getCluster(ctx context.Context, db database.Db, name string) (*registryv1.Cluster, error) {
 
 	var c *registryv1.Cluster
 	var err error
 
-	c, err = db.GetCluster(name)
+	c, err = db.GetCluster(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -150,7 +173,7 @@ func getCluster(db database.Db, name string) (*registryv1.Cluster, error) {
 		if err != nil {
 			log.Infof("Cluster %s is not a short name. Error: %v", name, err.Error())
 		} else {
-			c, err = db.GetCluster(dashName)
+			c, err = db.GetCluster(ctx, dashName)
 			if err != nil {
 				return nil, err
 			}
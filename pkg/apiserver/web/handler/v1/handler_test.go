@@ -15,6 +15,7 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/eko/gocache/lib/v4/cache"
@@ -28,6 +29,7 @@ import (
 	"testing"
 
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
 	"github.com/adobe/cluster-registry/pkg/apiserver/web"
 	"github.com/adobe/cluster-registry/pkg/config"
 	"github.com/adobe/cluster-registry/pkg/database"
@@ -52,6 +54,7 @@ var (
 type mockDatabase struct {
 	database.Db
 	clusters []registryv1.Cluster
+	err      error
 }
 
 func init() {
@@ -64,7 +67,7 @@ func init() {
 	cacheManager = cache.New[string](redisStore)
 }
 
-func (m mockDatabase) GetCluster(name string) (*registryv1.Cluster, error) {
+func (m mockDatabase) GetCluster(ctx context.Context, name string) (*registryv1.Cluster, error) {
 	for _, c := range m.clusters {
 		if c.Spec.Name == name {
 			return &c, nil
@@ -73,7 +76,10 @@ func (m mockDatabase) GetCluster(name string) (*registryv1.Cluster, error) {
 	return nil, nil
 }
 
-func (m mockDatabase) ListClusters(offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error) {
+func (m mockDatabase) ListClusters(ctx context.Context, offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error) {
+	if m.err != nil {
+		return nil, 0, false, m.err
+	}
 	return m.clusters, len(m.clusters), false, nil
 }
 
@@ -202,9 +208,12 @@ func TestListClusters(t *testing.T) {
 
 	tcs := []struct {
 		name           string
+		query          string
 		clusters       []registryv1.Cluster
+		dbErr          error
 		expectedStatus int
 		expectedItems  int
+		expectedCode   errors.Code
 	}{
 		{
 			name: "get all clusters",
@@ -228,15 +237,27 @@ func TestListClusters(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedItems:  2,
 		},
+		{
+			name:           "invalid lastUpdated",
+			query:          "lastUpdated=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   errors.CodeInvalidCondition,
+		},
+		{
+			name:           "database error",
+			dbErr:          fmt.Errorf("DynamonDB API query call failed"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   errors.CodeUpstreamUnavailable,
+		},
 	}
 	for _, tc := range tcs {
 
-		d := mockDatabase{clusters: tc.clusters}
+		d := mockDatabase{clusters: tc.clusters, err: tc.dbErr}
 		m := monitoring.NewMetrics("cluster_registry_api_handler_test", true)
 		r := web.NewRouter()
 		h := NewHandler(appConfig, d, m, cacheManager)
 
-		req := httptest.NewRequest(echo.GET, "/api/v1/clusters", nil)
+		req := httptest.NewRequest(echo.GET, "/api/v1/clusters?"+tc.query, nil)
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		ctx := r.NewContext(req, rec)
@@ -253,6 +274,13 @@ func TestListClusters(t *testing.T) {
 
 			test.NoError(err)
 			test.Equal(tc.expectedItems, cl.ItemsCount)
+		} else {
+			var p errors.Problem
+			err := json.Unmarshal(rec.Body.Bytes(), &p)
+
+			test.NoError(err)
+			test.Equal(tc.expectedCode, p.Code)
+			test.Equal(errors.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
 		}
 	}
 }
@@ -316,15 +344,33 @@ func TestListClustersWithEmptyCache(t *testing.T) {
 	expectedBody = append(expectedBody, "\n"...)
 
 	expectedResponse := web.Response{
-		Value:  expectedBody,
-		Header: http.Header{"Content-Type": []string{echo.MIMEApplicationJSON}},
+		Value: expectedBody,
+		Header: http.Header{
+			"Content-Type": []string{echo.MIMEApplicationJSON},
+			"Etag":         []string{web.GenerateETag(expectedBody)},
+			"Vary":         []string{echo.HeaderAccept},
+		},
+		ETag: web.GenerateETag(expectedBody),
 	}
 
-	key := web.GenerateKey(ctx.Request().URL.String())
+	key := web.CacheKey(ctx.Request())
 
 	redisMock.ExpectGet(key).SetVal("")
 
-	redisMock.ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("OK")
+	// the gob encoding of the response header is not deterministic, so compare the decoded values
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		if len(actual) < 3 {
+			return fmt.Errorf("unexpected arguments: %v", actual)
+		}
+		value, ok := actual[2].(string)
+		if !ok {
+			return fmt.Errorf("unexpected cache value type: %T", actual[2])
+		}
+		if !assert.ObjectsAreEqual(expectedResponse, web.StringToResponse(value)) {
+			return fmt.Errorf("unexpected cache value: %+v", web.StringToResponse(value))
+		}
+		return nil
+	}).ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("OK")
 
 	err = web.HTTPCache(cacheManager, appConfig, []string{"clusters"})(h.ListClusters)(ctx)
 	test.NoError(err)
@@ -383,7 +429,7 @@ func TestListClustersWithCache(t *testing.T) {
 		Header: http.Header{"Content-Type": []string{echo.MIMEApplicationJSON}},
 	}
 
-	key := web.GenerateKey(ctx.Request().URL.String())
+	key := web.CacheKey(ctx.Request())
 
 	redisMock.ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("")
 	err = redisStore.Set(context.Background(), key, expectedResponse.String(), store.WithExpiration(appConfig.ApiCacheTTL))
@@ -13,7 +13,10 @@ governing permissions and limitations under the License.
 package v1
 
 import (
+	"time"
+
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/apiserver/web"
 	"github.com/labstack/echo/v4"
 )
 
@@ -31,14 +34,18 @@ func newClusterResponse(ctx echo.Context, c *registryv1.Cluster) *registryv1.Clu
 	return cs
 }
 
+func newClusterItem(c registryv1.Cluster) *registryv1.ClusterSpec {
+	cs := c.Spec
+	cs.ServiceMetadata = nil
+	return &cs
+}
+
 func newClusterListResponse(clusters []registryv1.Cluster, count int, offset int, limit int, more bool) *clusterList {
 	r := new(clusterList)
 	r.Items = make([]*registryv1.ClusterSpec, 0) // TODO: check memory allocation
 
 	for _, c := range clusters {
-		cs := c.Spec
-		cs.ServiceMetadata = nil
-		r.Items = append(r.Items, &cs)
+		r.Items = append(r.Items, newClusterItem(c))
 	}
 
 	r.ItemsCount = count
@@ -48,3 +55,14 @@ func newClusterListResponse(clusters []registryv1.Cluster, count int, offset int
 
 	return r
 }
+
+// renderClusterList sends the clusters in the negotiated media type, streaming
+// them one by one for NDJSON responses. Lists have no Last-Modified header, as
+// removing a cluster from a list does not make it more recent, so they are
+// only validated by their ETag.
+func renderClusterList(ctx echo.Context, clusters []registryv1.Cluster, count int, offset int, limit int, more bool) error {
+	return web.RenderList(ctx, len(clusters),
+		func(i int) interface{} { return newClusterItem(clusters[i]) },
+		func() interface{} { return newClusterListResponse(clusters, count, offset, limit, more) },
+		time.Time{}, web.DefaultClusterColumns)
+}
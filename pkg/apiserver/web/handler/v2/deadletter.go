@@ -0,0 +1,193 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v2
+
+import (
+	goerrors "errors"
+	"net/http"
+
+	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
+	"github.com/adobe/cluster-registry/pkg/apiserver/web"
+	"github.com/adobe/cluster-registry/pkg/auth"
+	"github.com/adobe/cluster-registry/pkg/config"
+	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/apiserver"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type deadLetterList struct {
+	Items      []*sqs.DeadLetter `json:"items"`
+	ItemsCount int               `json:"itemsCount"`
+}
+
+type purgedDeadLetters struct {
+	Purged int `json:"purged"`
+}
+
+// DeadLetterHandler serves the admin endpoints of the dead-lettered cluster updates
+type DeadLetterHandler struct {
+	appConfig *config.AppConfig
+	metrics   monitoring.MetricsI
+	store     sqs.DeadLetterStore
+	queue     sqs.Queue
+}
+
+// NewDeadLetterHandler func
+func NewDeadLetterHandler(appConfig *config.AppConfig, m monitoring.MetricsI, store sqs.DeadLetterStore, q sqs.Queue) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		appConfig: appConfig,
+		metrics:   m,
+		store:     store,
+		queue:     q,
+	}
+}
+
+// Register the endpoints, which are restricted to the authorized group
+func (h *DeadLetterHandler) Register(v2 *echo.Group) {
+	a, err := auth.NewAuthenticator(h.appConfig, h.metrics)
+	if err != nil {
+		log.Fatalf("Failed to initialize authenticator: %v", err)
+	}
+	deadLetters := v2.Group("/deadletters", a.VerifyToken(), a.VerifyGroupAccess(h.appConfig.ApiAuthorizedGroupId), web.RateLimiter(h.appConfig))
+	deadLetters.GET("", h.ListDeadLetters)
+	deadLetters.DELETE("", h.PurgeDeadLetters)
+	deadLetters.GET("/:id", h.GetDeadLetter)
+	deadLetters.DELETE("/:id", h.DeleteDeadLetter)
+	deadLetters.POST("/:id/replay", h.ReplayDeadLetter)
+}
+
+// ListDeadLetters godoc
+// @Summary List dead letters
+// @Description List the cluster updates which could not be processed, oldest first. Auth is required
+// @ID v2-get-deadletters
+// @Tags deadletter
+// @Produce  json
+// @Success 200 {object} deadLetterList
+// @Failure 403 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
+// @Security bearerAuth
+// @Router /v2/deadletters [get]
+func (h *DeadLetterHandler) ListDeadLetters(c echo.Context) error {
+	dls, err := h.store.List(c.Request().Context())
+	if err != nil {
+		return errors.Render(c, errors.UpstreamUnavailable(err))
+	}
+	return c.JSON(http.StatusOK, &deadLetterList{Items: dls, ItemsCount: len(dls)})
+}
+
+// GetDeadLetter godoc
+// @Summary Get a dead letter
+// @Description Get a cluster update which could not be processed, along with the error. Auth is required
+// @ID v2-get-deadletter
+// @Tags deadletter
+// @Produce  json
+// @Param id path string true "ID of the dead letter"
+// @Success 200 {object} sqs.DeadLetter
+// @Failure 403 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
+// @Security bearerAuth
+// @Router /v2/deadletters/{id} [get]
+func (h *DeadLetterHandler) GetDeadLetter(c echo.Context) error {
+	dl, err := h.store.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return renderDeadLetterError(c, err)
+	}
+	return c.JSON(http.StatusOK, dl)
+}
+
+// ReplayDeadLetter godoc
+// @Summary Replay a dead letter
+// @Description Enqueue a cluster update which could not be processed again, and remove it from the dead letters. Auth is required
+// @ID v2-replay-deadletter
+// @Tags deadletter
+// @Produce  json
+// @Param id path string true "ID of the dead letter"
+// @Success 202 {object} sqs.DeadLetter
+// @Failure 403 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 409 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
+// @Security bearerAuth
+// @Router /v2/deadletters/{id}/replay [post]
+func (h *DeadLetterHandler) ReplayDeadLetter(c echo.Context) error {
+	dl, err := sqs.Replay(c.Request().Context(), h.queue, h.store, c.Param("id"))
+	if err != nil {
+		return renderDeadLetterError(c, err)
+	}
+
+	log.Infoj(log.JSON{
+		"message":    "dead letter replayed",
+		"id":         dl.ID,
+		"message_id": dl.MessageID,
+		"oid":        c.Get("oid"),
+		"request_id": c.Response().Header().Get(echo.HeaderXRequestID),
+	})
+	return c.JSON(http.StatusAccepted, dl)
+}
+
+// DeleteDeadLetter godoc
+// @Summary Delete a dead letter
+// @Description Discard a cluster update which could not be processed. Auth is required
+// @ID v2-delete-deadletter
+// @Tags deadletter
+// @Param id path string true "ID of the dead letter"
+// @Success 204 "No content"
+// @Failure 403 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
+// @Security bearerAuth
+// @Router /v2/deadletters/{id} [delete]
+func (h *DeadLetterHandler) DeleteDeadLetter(c echo.Context) error {
+	if err := h.store.Delete(c.Request().Context(), c.Param("id")); err != nil {
+		return renderDeadLetterError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PurgeDeadLetters godoc
+// @Summary Purge the dead letters
+// @Description Discard all the cluster updates which could not be processed. Auth is required
+// @ID v2-purge-deadletters
+// @Tags deadletter
+// @Produce  json
+// @Success 200 {object} purgedDeadLetters
+// @Failure 403 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
+// @Security bearerAuth
+// @Router /v2/deadletters [delete]
+func (h *DeadLetterHandler) PurgeDeadLetters(c echo.Context) error {
+	count, err := h.store.Purge(c.Request().Context())
+	if err != nil {
+		return errors.Render(c, errors.UpstreamUnavailable(err))
+	}
+
+	log.Infoj(log.JSON{
+		"message":    "dead letters purged",
+		"count":      count,
+		"oid":        c.Get("oid"),
+		"request_id": c.Response().Header().Get(echo.HeaderXRequestID),
+	})
+	return c.JSON(http.StatusOK, &purgedDeadLetters{Purged: count})
+}
+
+func renderDeadLetterError(c echo.Context, err error) error {
+	if goerrors.Is(err, sqs.ErrDeadLetterNotFound) {
+		return errors.Render(c, errors.NotFound())
+	}
+	if goerrors.Is(err, sqs.ErrDeadLetterUnauthenticated) {
+		return errors.Render(c, errors.Conflict(err))
+	}
+	return errors.Render(c, errors.UpstreamUnavailable(err))
+}
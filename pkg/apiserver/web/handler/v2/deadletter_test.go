@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adobe/cluster-registry/pkg/apiserver/web"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDeadLetterContext(method, id string) (echo.Context, *httptest.ResponseRecorder) {
+	r := web.NewRouter()
+	req := httptest.NewRequest(method, "/api/v2/deadletters/:id", nil)
+	rec := httptest.NewRecorder()
+
+	ctx := r.NewContext(req, rec)
+	ctx.SetPath("/api/v2/deadletters/:id")
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id)
+	return ctx, rec
+}
+
+func TestDeadLetters(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test listing, inspecting, replaying and purging dead letters.")
+
+	q, err := sqs.NewMemory(sqs.Config{VisibilityTimeout: 60, RunOnce: true})
+	test.NoError(err)
+	store := sqs.NewMemoryDeadLetterStore()
+	h := NewDeadLetterHandler(appConfig, m, store, q)
+
+	for _, id := range []string{"1", "2", "3"} {
+		test.NoError(store.DeadLetter(context.Background(), &sqs.Message{
+			ID:         id,
+			Body:       "{}",
+			Attributes: map[string]string{sqs.MessageAttributeType: sqs.ClusterUpdateEvent},
+		}, errors.New("database unavailable")))
+	}
+
+	t.Logf("\tTest list:\tWhen listing the dead letters")
+	ctx, rec := newDeadLetterContext(echo.GET, "")
+	test.NoError(h.ListDeadLetters(ctx))
+	test.Equal(http.StatusOK, rec.Code)
+
+	var list deadLetterList
+	test.NoError(json.Unmarshal(rec.Body.Bytes(), &list))
+	test.Equal(3, list.ItemsCount)
+	test.Equal("database unavailable", list.Items[0].Error)
+
+	t.Logf("\tTest get:\tWhen inspecting the dead letter %s", list.Items[0].ID)
+	ctx, rec = newDeadLetterContext(echo.GET, list.Items[0].ID)
+	test.NoError(h.GetDeadLetter(ctx))
+	test.Equal(http.StatusOK, rec.Code)
+
+	var dl sqs.DeadLetter
+	test.NoError(json.Unmarshal(rec.Body.Bytes(), &dl))
+	test.Equal(list.Items[0].MessageID, dl.MessageID)
+
+	t.Logf("\tTest get nonexistent:\tWhen inspecting a missing dead letter")
+	ctx, rec = newDeadLetterContext(echo.GET, "missing")
+	test.NoError(h.GetDeadLetter(ctx))
+	test.Equal(http.StatusNotFound, rec.Code)
+
+	t.Logf("\tTest replay:\tWhen replaying the dead letter %s", list.Items[0].ID)
+	ctx, rec = newDeadLetterContext(echo.POST, list.Items[0].ID)
+	test.NoError(h.ReplayDeadLetter(ctx))
+	test.Equal(http.StatusAccepted, rec.Code)
+
+	var replayed []string
+	test.NoError(q.Poll(context.Background(), func(_ context.Context, msg *sqs.Message) error {
+		replayed = append(replayed, msg.Attributes[sqs.MessageAttributeType])
+		return nil
+	}))
+	test.Equal([]string{sqs.ClusterUpdateEvent}, replayed)
+
+	t.Logf("\tTest replay nonexistent:\tWhen replaying the dead letter %s again", list.Items[0].ID)
+	ctx, rec = newDeadLetterContext(echo.POST, list.Items[0].ID)
+	test.NoError(h.ReplayDeadLetter(ctx))
+	test.Equal(http.StatusNotFound, rec.Code)
+
+	t.Logf("\tTest replay unauthenticated:\tWhen replaying a message rejected by the authentication")
+	test.NoError(store.DeadLetter(context.Background(), &sqs.Message{
+		ID:         "4",
+		Body:       "{}",
+		Attributes: map[string]string{sqs.MessageAttributeType: sqs.ClusterUpdateEvent},
+	}, fmt.Errorf("%w: %w", sqs.ErrUnauthenticated, sqs.ErrUnsigned)))
+	dls, err := store.List(context.Background())
+	test.NoError(err)
+	ctx, rec = newDeadLetterContext(echo.POST, dls[len(dls)-1].ID)
+	test.NoError(h.ReplayDeadLetter(ctx))
+	test.Equal(http.StatusConflict, rec.Code)
+
+	t.Logf("\tTest delete:\tWhen deleting the dead letter %s", list.Items[1].ID)
+	ctx, rec = newDeadLetterContext(echo.DELETE, list.Items[1].ID)
+	test.NoError(h.DeleteDeadLetter(ctx))
+	test.Equal(http.StatusNoContent, rec.Code)
+
+	ctx, rec = newDeadLetterContext(echo.DELETE, list.Items[1].ID)
+	test.NoError(h.DeleteDeadLetter(ctx))
+	test.Equal(http.StatusNotFound, rec.Code)
+
+	t.Logf("\tTest purge:\tWhen purging the remaining dead letters")
+	ctx, rec = newDeadLetterContext(echo.DELETE, "")
+	test.NoError(h.PurgeDeadLetters(ctx))
+	test.Equal(http.StatusOK, rec.Code)
+
+	var purged purgedDeadLetters
+	test.NoError(json.Unmarshal(rec.Body.Bytes(), &purged))
+	test.Equal(2, purged.Purged)
+}
@@ -19,9 +19,11 @@ import (
 	"github.com/adobe/cluster-registry/pkg/apiserver/models"
 	"github.com/adobe/cluster-registry/pkg/k8s"
 	"github.com/eko/gocache/lib/v4/cache"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"net/http"
 	"strconv"
+	"time"
 
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
@@ -47,10 +49,28 @@ type ClusterSpec struct {
 	Status *string            `json:"status,omitempty" validate:"omitempty,oneof=Inactive Active Deprecated Deleted"`
 	Phase  *string            `json:"phase,omitempty" validate:"omitempty,oneof=Building Testing Running Upgrading"`
 	Tags   *map[string]string `json:"tags,omitempty" validate:"omitempty"`
+
+	// ExpiresAt reverts the patched tags to their previous values at the
+	// given time
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" validate:"omitempty"`
+
+	// Reason of the patched tags, recorded with their expiry
+	Reason *string `json:"reason,omitempty" validate:"omitempty,max=256"`
+}
+
+// ClusterSpecPatch is the merge patch of the spec of a cluster
+type ClusterSpecPatch struct {
+	Status *string            `json:"status,omitempty"`
+	Phase  *string            `json:"phase,omitempty"`
+	Tags   *map[string]string `json:"tags,omitempty"`
+
+	// TagOverrides sets the expiry of the patched tags, or removes it with
+	// null values
+	TagOverrides map[string]*registryv1.TagOverride `json:"tagOverrides,omitempty"`
 }
 
 type ClusterPatch struct {
-	Spec ClusterSpec `json:"spec" validate:"required"`
+	Spec ClusterSpecPatch `json:"spec" validate:"required"`
 }
 
 // handler struct
@@ -95,26 +115,33 @@ func (h *handler) Register(v2 *echo.Group) {
 // @ID v2-get-cluster
 // @Tags cluster
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param name path string true "Name of the cluster to get"
+// @Param columns query string false "Comma separated ClusterSpec paths to include in CSV responses"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
+// @Param If-Modified-Since header string false "Date of a previously fetched response"
 // @Success 200 {object} registryv1.ClusterSpec
-// @Failure 400 {object} errors.Error
-// @Failure 500 {object} errors.Error
+// @Header 200 {string} ETag "Strong entity tag of the response"
+// @Header 200 {string} Last-Modified "Time when the cluster was last updated"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v2/clusters/{name} [get]
 func (h *handler) GetCluster(c echo.Context) error {
 	name := c.Param("name")
-	cluster, err := h.getCluster(h.db, name)
+	cluster, err := h.getCluster(c.Request().Context(), h.db, name)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, errors.NewError(err))
+		return errors.Render(c, errors.UpstreamUnavailable(err))
 	}
 
 	if cluster == nil {
-		return c.JSON(http.StatusNotFound, errors.NotFound())
+		return errors.Render(c, errors.NotFound())
 	}
 
-	return c.JSON(http.StatusOK, newClusterResponse(cluster))
+	return web.Render(c, newClusterResponse(cluster), web.ClustersLastModified(*cluster), web.DefaultClusterColumns)
 }
 
 // ListClusters
@@ -123,12 +150,18 @@ func (h *handler) GetCluster(c echo.Context) error {
 // @ID v2-get-clusters
 // @Tags cluster
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param conditions query []string false "Filter conditions" collectionFormat(multi)
+// @Param stale query boolean false "Filter the clusters whose client was not seen recently (true), or was (false)"
+// @Param columns query string false "Comma separated paths to include in CSV responses"
 // @Param offset query integer false "Offset to start pagination search results (default is 0)"
 // @Param limit query integer false "The number of results per page (default is 200)"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
 // @Success 200 {object} clusterList
-// @Failure 500 {object} errors.Error
+// @Header 200 {string} ETag "Strong entity tag of the response"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v2/clusters [get]
 func (h *handler) ListClusters(c echo.Context) error {
@@ -148,21 +181,38 @@ func (h *handler) ListClusters(c echo.Context) error {
 	filter := database.NewDynamoDBFilter()
 	queryConditions := getQueryConditions(c)
 
-	if len(queryConditions) == 0 {
-		clusters, count, more, _ := h.db.ListClusters(offset, limit, "", "", "", "")
-		return c.JSON(http.StatusOK, newClusterListResponse(clusters, count, offset, limit, more))
+	if s := c.QueryParam("stale"); s != "" {
+		stale, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.Render(c, errors.InvalidCondition(fmt.Errorf("stale must be a boolean: %v", err)))
+		}
+		filter.SetStale(stale, time.Now().Add(-h.appConfig.ApiStaleAfter))
+
+		// the staleness depends on the time of the request
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	}
+
+	if len(queryConditions) == 0 && c.QueryParam("stale") == "" {
+		clusters, count, more, err := h.db.ListClusters(c.Request().Context(), offset, limit, "", "", "", "")
+		if err != nil {
+			return errors.Render(c, errors.UpstreamUnavailable(err))
+		}
+		return renderClusterList(c, clusters, count, offset, limit, more)
 	}
 
 	for _, qc := range queryConditions {
 		condition, err := models.NewFilterConditionFromQuery(qc)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, errors.NewError(err))
+			return errors.Render(c, errors.InvalidCondition(err))
 		}
 		filter.AddCondition(condition)
 	}
 
-	clusters, count, more, _ := h.db.ListClustersWithFilter(offset, limit, filter)
-	return c.JSON(http.StatusOK, newClusterListResponse(clusters, count, offset, limit, more))
+	clusters, count, more, err := h.db.ListClustersWithFilter(c.Request().Context(), offset, limit, filter)
+	if err != nil {
+		return errors.Render(c, errors.UpstreamUnavailable(err))
+	}
+	return renderClusterList(c, clusters, count, offset, limit, more)
 }
 
 // PatchCluster godoc
@@ -175,36 +225,42 @@ func (h *handler) ListClusters(c echo.Context) error {
 // @Param name path string true "Name of the cluster to patch"
 // @Param clusterSpec body ClusterSpec true "Request body"
 // @Success 200 {object} registryv1.ClusterSpec
-// @Failure 400 {object} errors.Error
-// @Failure 500 {object} errors.Error
+// @Failure 400 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 409 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v2/clusters/{name} [patch]
 func (h *handler) PatchCluster(c echo.Context) error {
 
 	name := c.Param("name")
-	cluster, err := h.getCluster(h.db, name)
+	cluster, err := h.getCluster(c.Request().Context(), h.db, name)
 
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, errors.NewError(err))
+		return errors.Render(c, errors.UpstreamUnavailable(err))
 	}
 
 	if cluster == nil {
-		return c.JSON(http.StatusNotFound, errors.NotFound())
+		return errors.Render(c, errors.NotFound())
 	}
 
 	var clusterSpec ClusterSpec
 
 	if err = c.Bind(&clusterSpec); err != nil {
-		return c.JSON(http.StatusBadRequest, errors.NewError(err))
+		return errors.Render(c, errors.BadRequest(err))
 	}
 
 	if err = clusterSpec.Validate(c); err != nil {
-		return c.JSON(http.StatusBadRequest, errors.NewError(err))
+		return errors.Render(c, errors.ForbiddenField(err))
 	}
 
-	err = h.patchCluster(cluster, clusterSpec)
+	setBy, _ := c.Get("oid").(string)
+	err = h.patchCluster(cluster, newClusterSpecPatch(cluster, clusterSpec, setBy))
+	if apierrors.IsConflict(err) {
+		return errors.Render(c, errors.Conflict(err))
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, errors.NewError(err))
+		return errors.Render(c, errors.UpstreamUnavailable(err))
 	}
 
 	return c.JSON(http.StatusOK, newClusterResponse(cluster))
@@ -216,13 +272,18 @@ func (h *handler) PatchCluster(c echo.Context) error {
 // @ID v2-get-service-metadata
 // @Tags service
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param serviceId path string true "SNOW Service ID"
 // @Param conditions query []string false "Filter conditions" collectionFormat(multi)
+// @Param columns query string false "Comma separated paths to include in CSV responses"
 // @Param offset query integer false "Offset to start pagination search results (default is 0)"
 // @Param limit query integer false "The number of results per page (default is 200)"
+// @Param If-None-Match header string false "ETag of a previously fetched response"
 // @Success 200 {object} clusterList
-// @Failure 500 {object} errors.Error
+// @Header 200 {string} ETag "Strong entity tag of the response"
+// @Success 304 "Not modified"
+// @Failure 400 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v2/services/{serviceId} [get]
 func (h *handler) GetServiceMetadata(c echo.Context) error {
@@ -245,20 +306,26 @@ func (h *handler) GetServiceMetadata(c echo.Context) error {
 	queryConditions := getQueryConditions(c)
 
 	if len(queryConditions) == 0 {
-		clusters, count, more, _ := h.db.ListClustersWithService(serviceId, offset, limit, "", "", "", "")
-		return c.JSON(http.StatusOK, newServiceMetadataListResponse(clusters, count, offset, limit, more))
+		clusters, count, more, err := h.db.ListClustersWithService(c.Request().Context(), serviceId, offset, limit, "", "", "", "")
+		if err != nil {
+			return errors.Render(c, errors.UpstreamUnavailable(err))
+		}
+		return renderServiceMetadataList(c, clusters, count, offset, limit, more)
 	}
 
 	for _, qc := range queryConditions {
 		condition, err := models.NewFilterConditionFromQuery(qc)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, errors.NewError(err))
+			return errors.Render(c, errors.InvalidCondition(err))
 		}
 		filter.AddCondition(condition)
 	}
 
-	clusters, count, more, _ := h.db.ListClustersWithServiceAndFilter(serviceId, offset, limit, filter)
-	return c.JSON(http.StatusOK, newServiceMetadataListResponse(clusters, count, offset, limit, more))
+	clusters, count, more, err := h.db.ListClustersWithServiceAndFilter(c.Request().Context(), serviceId, offset, limit, filter)
+	if err != nil {
+		return errors.Render(c, errors.UpstreamUnavailable(err))
+	}
+	return renderServiceMetadataList(c, clusters, count, offset, limit, more)
 }
 
 // GetServiceMetadataForCluster
@@ -267,36 +334,38 @@ func (h *handler) GetServiceMetadata(c echo.Context) error {
 // @ID v2-get-service-metadata-for-cluster
 // @Tags service
 // @Accept  json
-// @Produce  json
+// @Produce  json,application/yaml,text/csv,application/x-ndjson
 // @Param serviceId path string true "SNOW Service ID"
 // @Param clusterName path string true "Name of the cluster"
+// @Param columns query string false "Comma separated paths to include in CSV responses"
 // @Success 200 {object} registryv1.ClusterSpec
-// @Failure 400 {object} errors.Error
-// @Failure 500 {object} errors.Error
+// @Failure 400 {object} errors.Problem
+// @Failure 404 {object} errors.Problem
+// @Failure 503 {object} errors.Problem
 // @Security bearerAuth
 // @Router /v2/services/{serviceId}/cluster/{clusterName} [get]
 func (h *handler) GetServiceMetadataForCluster(c echo.Context) error {
 	serviceId := c.Param("serviceId")
 	clusterName := c.Param("clusterName")
-	cluster, err := h.db.GetClusterWithService(serviceId, clusterName)
+	cluster, err := h.db.GetClusterWithService(c.Request().Context(), serviceId, clusterName)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, errors.NewError(err))
+		return errors.Render(c, errors.UpstreamUnavailable(err))
 	}
 
 	if cluster == nil {
-		return c.JSON(http.StatusNotFound, errors.NotFound())
+		return errors.Render(c, errors.NotFound())
 	}
 
-	return c.JSON(http.StatusOK, newServiceMetadataResponse(cluster))
+	return web.Render(c, newServiceMetadataResponse(cluster), web.ClustersLastModified(*cluster), serviceMetadataColumns)
 }
 
 // getCluster by standard name or short name
-func (h *handler) getCluster(db database.Db, name string) (*registryv1.Cluster, error) {
+func (h *handler) getCluster(ctx context.Context, db database.Db, name string) (*registryv1.Cluster, error) {
 
 	var cluster *registryv1.Cluster
 	var err error
 
-	cluster, err = db.GetCluster(name)
+	cluster, err = db.GetCluster(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -306,7 +375,7 @@ func (h *handler) getCluster(db database.Db, name string) (*registryv1.Cluster,
 		if err != nil {
 			log.Warnf("Cluster %s is not a short name. Error: %v", name, err.Error())
 		} else {
-			cluster, err = db.GetCluster(dashName)
+			cluster, err = db.GetCluster(ctx, dashName)
 			if err != nil {
 				return nil, err
 			}
@@ -315,8 +384,42 @@ func (h *handler) getCluster(db database.Db, name string) (*registryv1.Cluster,
 	return cluster, nil
 }
 
+// newClusterSpecPatch returns the patch of the spec of the cluster, with the
+// overrides reverting the patched tags once they expire. Tags patched without
+// expiry are not reverted anymore.
+func newClusterSpecPatch(cluster *registryv1.Cluster, spec ClusterSpec, setBy string) ClusterSpecPatch {
+	patch := ClusterSpecPatch{
+		Status: spec.Status,
+		Phase:  spec.Phase,
+		Tags:   spec.Tags,
+	}
+	if spec.Tags == nil {
+		return patch
+	}
+
+	reason := ""
+	if spec.Reason != nil {
+		reason = *spec.Reason
+	}
+	for key, value := range *spec.Tags {
+		if spec.ExpiresAt != nil {
+			override := cluster.Spec.NewTagOverride(key, value, *spec.ExpiresAt, setBy, reason)
+			if patch.TagOverrides == nil {
+				patch.TagOverrides = make(map[string]*registryv1.TagOverride)
+			}
+			patch.TagOverrides[key] = &override
+		} else if _, ok := cluster.Spec.TagOverrides[key]; ok {
+			if patch.TagOverrides == nil {
+				patch.TagOverrides = make(map[string]*registryv1.TagOverride)
+			}
+			patch.TagOverrides[key] = nil
+		}
+	}
+	return patch
+}
+
 // patchCluster
-func (h *handler) patchCluster(cluster *registryv1.Cluster, spec ClusterSpec) error {
+func (h *handler) patchCluster(cluster *registryv1.Cluster, spec ClusterSpecPatch) error {
 	client, err := h.kcp.GetClient(h.appConfig, cluster)
 	if err != nil {
 		return fmt.Errorf("failed to get client for cluster %s: %v", cluster.Spec.Name, err)
@@ -367,6 +470,15 @@ func (patch *ClusterSpec) Validate(c echo.Context) error {
 		}
 	}
 
+	if patch.ExpiresAt != nil {
+		if patch.Tags == nil || len(*patch.Tags) == 0 {
+			return fmt.Errorf("expiresAt requires tags to revert")
+		}
+		if !patch.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("expiresAt must be in the future")
+		}
+	}
+
 	return nil
 }
 
@@ -17,10 +17,12 @@ import (
 	"encoding/json"
 	"fmt"
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
 	"github.com/adobe/cluster-registry/pkg/apiserver/web"
 	"github.com/adobe/cluster-registry/pkg/config"
 	"github.com/adobe/cluster-registry/pkg/database"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/apiserver"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/eko/gocache/lib/v4/cache"
@@ -32,13 +34,19 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/utils/ptr"
 	"net/http"
 	"net/http/httptest"
+	"sigs.k8s.io/yaml"
 	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -169,6 +177,7 @@ func TestListClusters(t *testing.T) {
 	tcs := []struct {
 		name             string
 		filter           []string
+		stale            string
 		expectedClusters []registryv1.Cluster
 		expectedStatus   int
 		expectedItems    int
@@ -222,6 +231,25 @@ func TestListClusters(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedItems:  1,
 		},
+		{
+			name:   "get stale clusters",
+			filter: []string{},
+			stale:  "true",
+			expectedClusters: []registryv1.Cluster{
+				{
+					Spec: registryv1.ClusterSpec{
+						Name:         "cluster2",
+						LastUpdated:  "2020-03-14T06:15:32Z",
+						LastSeen:     "2020-03-14T06:15:32Z",
+						RegisteredAt: "2019-03-14T06:15:32Z",
+						Status:       "Active",
+						Phase:        "Upgrading",
+					},
+				},
+			},
+			expectedStatus: http.StatusOK,
+			expectedItems:  1,
+		},
 	}
 	for _, tc := range tcs {
 		r := web.NewRouter()
@@ -230,6 +258,9 @@ func TestListClusters(t *testing.T) {
 		for i, v := range tc.filter {
 			tc.filter[i] = fmt.Sprintf("conditions=%s", v)
 		}
+		if tc.stale != "" {
+			tc.filter = append(tc.filter, fmt.Sprintf("stale=%s", tc.stale))
+		}
 		filterQuery := strings.Join(tc.filter, "&")
 
 		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/v2/clusters?%s", filterQuery), nil)
@@ -264,6 +295,9 @@ func TestListClusters(t *testing.T) {
 
 		test.NoError(err)
 		test.Equal(tc.expectedStatus, rec.Code)
+		if tc.stale != "" {
+			test.Equal("no-store", rec.Header().Get(echo.HeaderCacheControl))
+		}
 
 		if rec.Code == http.StatusOK {
 			var cl clusterList
@@ -303,7 +337,7 @@ func TestPatchCluster(t *testing.T) {
 				Status: ptr.To[string]("inactive"),
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":{"body":"Key: 'ClusterSpec.Status' Error:Field validation for 'Status' failed on the 'oneof' tag"}}`,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"Key: 'ClusterSpec.Status' Error:Field validation for 'Status' failed on the 'oneof' tag","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
 		},
 		{
 			name: "invalid phase (case sensitive)",
@@ -322,7 +356,7 @@ func TestPatchCluster(t *testing.T) {
 				Phase:  ptr.To[string]("upgrading"),
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":{"body":"Key: 'ClusterSpec.Phase' Error:Field validation for 'Phase' failed on the 'oneof' tag"}}`,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"Key: 'ClusterSpec.Phase' Error:Field validation for 'Phase' failed on the 'oneof' tag","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
 		},
 		{
 			name: "invalid value for `scaling` tag",
@@ -344,7 +378,7 @@ func TestPatchCluster(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":{"body":"scaling tag value must be 'on' or 'off'"}}`,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"scaling tag value must be 'on' or 'off'","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
 		},
 		{
 			name: "invalid value for `onboarding` tag",
@@ -365,7 +399,7 @@ func TestPatchCluster(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":{"body":"onboarding tag value must be 'on' or 'off'"}}`,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"onboarding tag value must be 'on' or 'off'","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
 		},
 		{
 			name: "invalid tag",
@@ -386,7 +420,47 @@ func TestPatchCluster(t *testing.T) {
 				},
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":{"body":"invalid tag some-made-up-tag"}}`,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"invalid tag some-made-up-tag","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
+		},
+		{
+			name: "expiry in the past",
+			cluster: &registryv1.Cluster{
+				Spec: registryv1.ClusterSpec{
+					Name:         "cluster1",
+					LastUpdated:  "2020-02-14T06:15:32Z",
+					RegisteredAt: "2019-02-14T06:15:32Z",
+					Status:       "Active",
+					Phase:        "Running",
+					Tags:         map[string]string{"onboarding": "on", "scaling": "off"},
+				},
+			},
+			clusterSpec: ClusterSpec{
+				Tags: &map[string]string{
+					"onboarding": "off",
+				},
+				ExpiresAt: ptr.To(time.Now().Add(-time.Hour)),
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"expiresAt must be in the future","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
+		},
+		{
+			name: "expiry without tags",
+			cluster: &registryv1.Cluster{
+				Spec: registryv1.ClusterSpec{
+					Name:         "cluster1",
+					LastUpdated:  "2020-02-14T06:15:32Z",
+					RegisteredAt: "2019-02-14T06:15:32Z",
+					Status:       "Active",
+					Phase:        "Running",
+					Tags:         map[string]string{"onboarding": "on", "scaling": "off"},
+				},
+			},
+			clusterSpec: ClusterSpec{
+				Status:    ptr.To[string]("Inactive"),
+				ExpiresAt: ptr.To(time.Now().Add(time.Hour)),
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"type":"urn:cluster-registry:error:forbidden_field","title":"Bad Request","status":400,"detail":"expiresAt requires tags to revert","instance":"/api/v2/clusters/:name","code":"forbidden_field"}`,
 		},
 		// TODO: add more test cases (success, unauthorized, etc.)
 	}
@@ -426,6 +500,58 @@ func TestPatchCluster(t *testing.T) {
 	}
 }
 
+func TestNewClusterSpecPatch(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test patching the overrides reverting the patched tags.")
+
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cluster := &registryv1.Cluster{
+		Spec: registryv1.ClusterSpec{
+			Name: "cluster1",
+			Tags: map[string]string{"onboarding": "on", "scaling": "off"},
+			TagOverrides: map[string]registryv1.TagOverride{
+				"scaling": {Value: "off", PreviousValue: ptr.To("on"), ExpiresAt: "2029-01-01T00:00:00Z"},
+			},
+		},
+	}
+
+	tcs := []struct {
+		name          string
+		clusterSpec   ClusterSpec
+		expectedPatch string
+	}{
+		{
+			name:          "tags without expiry",
+			clusterSpec:   ClusterSpec{Tags: &map[string]string{"onboarding": "off", "scaling": "on"}},
+			expectedPatch: `{"spec":{"tags":{"onboarding":"off","scaling":"on"},"tagOverrides":{"scaling":null}}}`,
+		},
+		{
+			name: "tags with expiry",
+			clusterSpec: ClusterSpec{
+				Tags:      &map[string]string{"onboarding": "off", "scaling": "off"},
+				ExpiresAt: &expiresAt,
+				Reason:    ptr.To("incident"),
+			},
+			expectedPatch: `{"spec":{"tags":{"onboarding":"off","scaling":"off"},"tagOverrides":{` +
+				`"onboarding":{"value":"off","previousValue":"on","expiresAt":"2030-01-02T03:04:05Z","setBy":"user1","reason":"incident"},` +
+				`"scaling":{"value":"off","previousValue":"on","expiresAt":"2030-01-02T03:04:05Z","setBy":"user1","reason":"incident"}}}}`,
+		},
+		{
+			name:          "no tags",
+			clusterSpec:   ClusterSpec{Status: ptr.To("Inactive")},
+			expectedPatch: `{"spec":{"status":"Inactive"}}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen patching cluster %s", tc.name, cluster.Spec.Name)
+		patch, err := json.Marshal(&ClusterPatch{Spec: newClusterSpecPatch(cluster, tc.clusterSpec, "user1")})
+		test.NoError(err)
+		test.JSONEq(tc.expectedPatch, string(patch))
+	}
+}
+
 func TestListClustersWithEmptyCache(t *testing.T) {
 	test := assert.New(t)
 
@@ -485,15 +611,33 @@ func TestListClustersWithEmptyCache(t *testing.T) {
 	expectedBody = append(expectedBody, "\n"...)
 
 	expectedResponse := web.Response{
-		Value:  expectedBody,
-		Header: http.Header{"Content-Type": []string{echo.MIMEApplicationJSON}},
+		Value: expectedBody,
+		Header: http.Header{
+			"Content-Type": []string{echo.MIMEApplicationJSON},
+			"Etag":         []string{web.GenerateETag(expectedBody)},
+			"Vary":         []string{echo.HeaderAccept},
+		},
+		ETag: web.GenerateETag(expectedBody),
 	}
 
-	key := web.GenerateKey(ctx.Request().URL.String())
+	key := web.CacheKey(ctx.Request())
 
 	redisMock.ExpectGet(key).SetVal("")
 
-	redisMock.ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("OK")
+	// the gob encoding of the response header is not deterministic, so compare the decoded values
+	redisMock.CustomMatch(func(expected, actual []interface{}) error {
+		if len(actual) < 3 {
+			return fmt.Errorf("unexpected arguments: %v", actual)
+		}
+		value, ok := actual[2].(string)
+		if !ok {
+			return fmt.Errorf("unexpected cache value type: %T", actual[2])
+		}
+		if !assert.ObjectsAreEqual(expectedResponse, web.StringToResponse(value)) {
+			return fmt.Errorf("unexpected cache value: %+v", web.StringToResponse(value))
+		}
+		return nil
+	}).ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("OK")
 
 	err = web.HTTPCache(cacheManager, appConfig, []string{"clusters"})(h.ListClusters)(ctx)
 	test.NoError(err)
@@ -552,7 +696,7 @@ func TestListClustersWithCache(t *testing.T) {
 		Header: http.Header{"Content-Type": []string{echo.MIMEApplicationJSON}},
 	}
 
-	key := web.GenerateKey(ctx.Request().URL.String())
+	key := web.CacheKey(ctx.Request())
 
 	redisMock.ExpectSet(key, expectedResponse.String(), appConfig.ApiCacheTTL).SetVal("")
 	err = redisStore.Set(context.Background(), key, expectedResponse.String(), store.WithExpiration(appConfig.ApiCacheTTL))
@@ -567,3 +711,370 @@ func TestListClustersWithCache(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestListClustersWithCacheNotModified(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test conditional requests against cached cluster list results.")
+
+	redisMock.MatchExpectationsInOrder(true)
+
+	r := web.NewRouter()
+	h := NewHandler(appConfig, db, m, &TestClientProvider{}, cacheManager)
+
+	expectedBody := []byte(`{"items":[],"itemsCount":0,"offset":0,"limit":200,"more":false}` + "\n")
+	cachedResponse := web.Response{
+		Value:  expectedBody,
+		Header: http.Header{"Content-Type": []string{echo.MIMEApplicationJSON}},
+		ETag:   web.GenerateETag(expectedBody),
+	}
+
+	req := httptest.NewRequest(echo.GET, "/api/v2/clusters", nil)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(web.HeaderIfNoneMatch, cachedResponse.ETag)
+	rec := httptest.NewRecorder()
+	ctx := r.NewContext(req, rec)
+
+	key := web.CacheKey(ctx.Request())
+	redisMock.ExpectGet(key).SetVal(cachedResponse.String())
+
+	err := web.HTTPCache(cacheManager, appConfig, []string{"clusters"})(h.ListClusters)(ctx)
+	test.NoError(err)
+
+	test.Equal(http.StatusNotModified, rec.Code)
+	test.Equal(cachedResponse.ETag, rec.Header().Get(web.HeaderETag))
+	test.Empty(rec.Body.String())
+
+	if err := redisMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetClusterConditional(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test conditional requests for a single cluster.")
+
+	cluster := &registryv1.Cluster{
+		Spec: registryv1.ClusterSpec{
+			Name:         "cluster1",
+			LastUpdated:  "2020-02-14T06:15:32Z",
+			RegisteredAt: "2019-02-14T06:15:32Z",
+			Status:       "Active",
+			Phase:        "Running",
+		},
+	}
+	expectedBody, err := json.Marshal(newClusterResponse(cluster.DeepCopy()))
+	test.NoError(err)
+	etag := web.GenerateETag(append(expectedBody, "\n"...))
+
+	tcs := []struct {
+		name           string
+		header         string
+		value          string
+		expectedStatus int
+	}{
+		{
+			name:           "no preconditions",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "matching etag",
+			header:         web.HeaderIfNoneMatch,
+			value:          etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "matching etag in list",
+			header:         web.HeaderIfNoneMatch,
+			value:          `"foo", ` + etag,
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "stale etag",
+			header:         web.HeaderIfNoneMatch,
+			value:          `"foo"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not modified since",
+			header:         echo.HeaderIfModifiedSince,
+			value:          "Fri, 14 Feb 2020 06:15:32 GMT",
+			expectedStatus: http.StatusNotModified,
+		},
+		{
+			name:           "modified since",
+			header:         echo.HeaderIfModifiedSince,
+			value:          "Fri, 14 Feb 2020 06:15:31 GMT",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tcs {
+		r := web.NewRouter()
+		h := NewHandler(appConfig, db, m, &TestClientProvider{}, cacheManager)
+
+		req := httptest.NewRequest(echo.GET, "/api/v2/clusters/:name", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		rec := httptest.NewRecorder()
+
+		ctx := r.NewContext(req, rec)
+		ctx.SetPath("/api/v2/clusters/:name")
+		ctx.SetParamNames("name")
+		ctx.SetParamValues(cluster.Spec.Name)
+
+		expectedItem, err := dynamodbattribute.MarshalMap(database.ClusterDb{Cluster: cluster})
+		test.NoError(err)
+		dbMock.ExpectGetItem().WillReturns(dynamodb.GetItemOutput{Item: expectedItem})
+
+		t.Logf("\tTest %s:\tWhen checking for http status code %d", tc.name, tc.expectedStatus)
+
+		err = h.GetCluster(ctx)
+		test.NoError(err)
+
+		test.Equal(tc.expectedStatus, rec.Code)
+		test.Equal(etag, rec.Header().Get(web.HeaderETag))
+		test.Equal("Fri, 14 Feb 2020 06:15:32 GMT", rec.Header().Get(echo.HeaderLastModified))
+		if rec.Code == http.StatusNotModified {
+			test.Empty(rec.Body.String())
+		}
+	}
+}
+
+func TestListClustersContentNegotiation(t *testing.T) {
+	test := assert.New(t)
+	appConfig := &config.AppConfig{}
+
+	t.Log("Test listing clusters in different media types.")
+
+	clusters := []registryv1.Cluster{
+		{
+			Spec: registryv1.ClusterSpec{
+				Name:         "cluster1",
+				Region:       "va7",
+				LastUpdated:  "2020-02-14T06:15:32Z",
+				RegisteredAt: "2019-02-14T06:15:32Z",
+				Status:       "Active",
+				Phase:        "Running",
+				Tags:         map[string]string{"onboarding": "on", "scaling": "off"},
+			},
+		},
+		{
+			Spec: registryv1.ClusterSpec{
+				Name:         "cluster2",
+				Region:       "va6",
+				LastUpdated:  "2020-03-14T06:15:32Z",
+				RegisteredAt: "2019-03-14T06:15:32Z",
+				Status:       "Active",
+				Phase:        "Upgrading",
+				Tags:         map[string]string{"onboarding": "on", "scaling": "on"},
+			},
+		},
+	}
+
+	tcs := []struct {
+		name                string
+		accept              string
+		query               string
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "yaml",
+			accept:              web.MIMEApplicationYAML,
+			expectedContentType: web.MIMEApplicationYAML,
+		},
+		{
+			name:                "csv with columns",
+			accept:              web.MIMETextCSV,
+			query:               "columns=name,region,tags.scaling",
+			expectedContentType: web.MIMETextCSV,
+			expectedBody:        "name,region,tags.scaling\ncluster1,va7,off\ncluster2,va6,on\n",
+		},
+		{
+			name:                "ndjson",
+			accept:              web.MIMEApplicationNDJSON,
+			expectedContentType: web.MIMEApplicationNDJSON,
+		},
+	}
+
+	for _, tc := range tcs {
+		r := web.NewRouter()
+		h := NewHandler(appConfig, db, m, &TestClientProvider{}, cacheManager)
+
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/v2/clusters?%s", tc.query), nil)
+		req.Header.Set(echo.HeaderAccept, tc.accept)
+		rec := httptest.NewRecorder()
+		ctx := r.NewContext(req, rec)
+
+		var expectedItems []map[string]*dynamodb.AttributeValue
+		for _, c := range clusters {
+			item, err := dynamodbattribute.MarshalMap(database.ClusterDb{
+				Cluster: &c,
+			})
+			test.NoError(err)
+			expectedItems = append(expectedItems, item)
+		}
+		dbMock.ExpectQuery().WillReturns(dynamodb.QueryOutput{Items: expectedItems})
+
+		t.Logf("\tTest %s:\tWhen checking for content type %s", tc.name, tc.expectedContentType)
+
+		err := h.ListClusters(ctx)
+		test.NoError(err)
+
+		test.Equal(http.StatusOK, rec.Code)
+		test.Equal(tc.expectedContentType, rec.Header().Get(echo.HeaderContentType))
+		test.Equal(echo.HeaderAccept, rec.Header().Get(web.HeaderVary))
+		test.Empty(rec.Header().Get(echo.HeaderLastModified))
+
+		switch tc.expectedContentType {
+		case web.MIMEApplicationYAML:
+			var cl clusterList
+			test.NoError(yaml.Unmarshal(rec.Body.Bytes(), &cl))
+			test.Equal(len(clusters), cl.ItemsCount)
+			test.Equal("cluster2", cl.Items[1].Name)
+		case web.MIMEApplicationNDJSON:
+			lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+			test.Len(lines, len(clusters))
+			for i, line := range lines {
+				var cs registryv1.ClusterSpec
+				test.NoError(json.Unmarshal([]byte(line), &cs))
+				test.Equal(clusters[i].Spec.Name, cs.Name)
+			}
+		default:
+			test.Equal(tc.expectedBody, rec.Body.String())
+		}
+	}
+}
+
+func TestListClustersErrors(t *testing.T) {
+	test := assert.New(t)
+	appConfig := &config.AppConfig{}
+
+	t.Log("Test errors when listing clusters.")
+
+	tcs := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCode   errors.Code
+	}{
+		{
+			name:           "invalid condition",
+			query:          "conditions=status:~Active",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   errors.CodeInvalidCondition,
+		},
+		{
+			name:           "invalid stale filter",
+			query:          "stale=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   errors.CodeInvalidCondition,
+		},
+		{
+			name:           "database error",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCode:   errors.CodeUpstreamUnavailable,
+		},
+	}
+
+	for _, tc := range tcs {
+		r := web.NewRouter()
+		h := NewHandler(appConfig, db, m, &TestClientProvider{}, cacheManager)
+
+		// no query expectation is set, so the database mock fails
+		req := httptest.NewRequest(echo.GET, fmt.Sprintf("/api/v2/clusters?%s", tc.query), nil)
+		rec := httptest.NewRecorder()
+		ctx := r.NewContext(req, rec)
+
+		t.Logf("\tTest %s:\tWhen checking for status code %d and error code %s", tc.name, tc.expectedStatus, tc.expectedCode)
+
+		err := h.ListClusters(ctx)
+		test.NoError(err)
+
+		test.Equal(tc.expectedStatus, rec.Code)
+		test.Equal(errors.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+
+		var p errors.Problem
+		test.NoError(json.Unmarshal(rec.Body.Bytes(), &p))
+		test.Equal(tc.expectedCode, p.Code)
+		test.Equal("/api/v2/clusters", p.Instance)
+	}
+}
+
+func TestGetClusterTracing(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test tracing of requests and database calls.")
+
+	_, err := tracing.Setup(context.Background(), tracing.Config{})
+	test.NoError(err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), web.ServiceName, 1)
+	otel.SetTracerProvider(tp)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tcs := []struct {
+		name           string
+		found          bool
+		expectedStatus int
+		expectedError  bool
+	}{
+		{
+			name:           "cluster found",
+			found:          true,
+			expectedStatus: http.StatusOK,
+			expectedError:  false,
+		},
+		{
+			name:           "database error",
+			found:          false,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedError:  true,
+		},
+	}
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	for _, tc := range tcs {
+		exporter.Reset()
+
+		r := web.NewRouter()
+		h := NewHandler(appConfig, db, m, &TestClientProvider{}, cacheManager)
+		r.GET("/api/v2/clusters/:name", h.GetCluster)
+
+		if tc.found {
+			expectedItem, err := dynamodbattribute.MarshalMap(database.ClusterDb{
+				Cluster: &registryv1.Cluster{Spec: registryv1.ClusterSpec{Name: "cluster1"}},
+			})
+			test.NoError(err)
+			dbMock.ExpectGetItem().WillReturns(dynamodb.GetItemOutput{Item: expectedItem})
+		}
+
+		req := httptest.NewRequest(echo.GET, "/api/v2/clusters/cluster1", nil)
+		req.Header.Set("traceparent", fmt.Sprintf("00-%s-00f067aa0ba902b7-01", traceID))
+		rec := httptest.NewRecorder()
+
+		t.Logf("\tTest %s:\tWhen checking the spans of trace %s", tc.name, traceID)
+		r.ServeHTTP(rec, req)
+
+		test.Equal(tc.expectedStatus, rec.Code)
+
+		spans := map[string]tracetest.SpanStub{}
+		for _, s := range exporter.GetSpans() {
+			spans[s.Name] = s
+		}
+		server, ok := spans["/api/v2/clusters/:name"]
+		test.True(ok, "the request should be traced")
+		dbSpan, ok := spans["database.GetCluster"]
+		test.True(ok, "the database call should be traced")
+
+		test.Equal(traceID, server.SpanContext.TraceID().String())
+		test.Equal(traceID, dbSpan.SpanContext.TraceID().String())
+		test.Equal(server.SpanContext.SpanID(), dbSpan.Parent.SpanID())
+		test.Equal(tc.expectedError, dbSpan.Status.Code == codes.Error)
+	}
+}
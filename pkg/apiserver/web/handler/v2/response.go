@@ -13,9 +13,16 @@ governing permissions and limitations under the License.
 package v2
 
 import (
+	"time"
+
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/apiserver/web"
+	"github.com/labstack/echo/v4"
 )
 
+// serviceMetadataColumns are the fields included in CSV responses if the columns query parameter is missing
+var serviceMetadataColumns = []string{"name", "services"}
+
 type clusterList struct {
 	Items      []*registryv1.ClusterSpec `json:"items"`
 	ItemsCount int                       `json:"itemsCount"`
@@ -43,14 +50,18 @@ func newClusterResponse(cluster *registryv1.Cluster) *registryv1.ClusterSpec {
 	return cs
 }
 
+func newClusterItem(cluster registryv1.Cluster) *registryv1.ClusterSpec {
+	cs := cluster.Spec
+	cs.ServiceMetadata = nil
+	return &cs
+}
+
 func newClusterListResponse(clusters []registryv1.Cluster, count int, offset int, limit int, more bool) *clusterList {
 	r := new(clusterList)
 	r.Items = make([]*registryv1.ClusterSpec, 0) // TODO: check memory allocation
 
 	for _, c := range clusters {
-		cs := c.Spec
-		cs.ServiceMetadata = nil
-		r.Items = append(r.Items, &cs)
+		r.Items = append(r.Items, newClusterItem(c))
 	}
 
 	r.ItemsCount = count
@@ -72,12 +83,8 @@ func newServiceMetadataListResponse(clusters []registryv1.Cluster, count int, of
 	r := new(serviceMetadataList)
 	r.Items = make([]*ServiceMetadata, 0)
 
-	for _, c := range clusters {
-		sm := ServiceMetadata{
-			Name:            c.Spec.Name,
-			ServiceMetadata: c.Spec.ServiceMetadata,
-		}
-		r.Items = append(r.Items, &sm)
+	for i := range clusters {
+		r.Items = append(r.Items, newServiceMetadataResponse(&clusters[i]))
 	}
 
 	r.ItemsCount = count
@@ -87,3 +94,24 @@ func newServiceMetadataListResponse(clusters []registryv1.Cluster, count int, of
 
 	return r
 }
+
+// renderClusterList sends the clusters in the negotiated media type, streaming
+// them one by one for NDJSON responses. Lists have no Last-Modified header, as
+// removing a cluster from a list does not make it more recent, so they are
+// only validated by their ETag.
+func renderClusterList(c echo.Context, clusters []registryv1.Cluster, count int, offset int, limit int, more bool) error {
+	return web.RenderList(c, len(clusters),
+		func(i int) interface{} { return newClusterItem(clusters[i]) },
+		func() interface{} { return newClusterListResponse(clusters, count, offset, limit, more) },
+		time.Time{}, web.DefaultClusterColumns)
+}
+
+// renderServiceMetadataList sends the service metadata in the negotiated media
+// type, streaming it cluster by cluster for NDJSON responses. Like the cluster
+// lists, it is only validated by its ETag.
+func renderServiceMetadataList(c echo.Context, clusters []registryv1.Cluster, count int, offset int, limit int, more bool) error {
+	return web.RenderList(c, len(clusters),
+		func(i int) interface{} { return newServiceMetadataResponse(&clusters[i]) },
+		func() interface{} { return newServiceMetadataListResponse(clusters, count, offset, limit, more) },
+		time.Time{}, serviceMetadataColumns)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRequestIDLength limits the size of the request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID sets the X-Request-ID header on both the request and the response.
+// The ID sent by the client is reused if valid, otherwise a new one is generated.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			rid := c.Request().Header.Get(echo.HeaderXRequestID)
+			if !validRequestID(rid) {
+				rid = uuid.New().String()
+				c.Request().Header.Set(echo.HeaderXRequestID, rid)
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, rid)
+			return next(c)
+		}
+	}
+}
+
+// GetRequestID returns the ID of the current request
+func GetRequestID(c echo.Context) string {
+	if rid := c.Response().Header().Get(echo.HeaderXRequestID); rid != "" {
+		return rid
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// AccessLogger logs every request as a JSON object, along with the identity
+// which performed it and the matched route
+func AccessLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		HandleError:   true,
+		LogLatency:    true,
+		LogMethod:     true,
+		LogURI:        true,
+		LogRoutePath:  true,
+		LogStatus:     true,
+		LogRequestID:  true,
+		LogRemoteIP:   true,
+		LogUserAgent:  true,
+		LogError:      true,
+		LogValuesFunc: logValues,
+	})
+}
+
+func logValues(c echo.Context, v middleware.RequestLoggerValues) error {
+	entry := log.JSON{
+		"message":    "request",
+		"request_id": v.RequestID,
+		"method":     v.Method,
+		"uri":        v.URI,
+		"route":      v.RoutePath,
+		"status":     v.Status,
+		"latency":    v.Latency.Seconds(),
+		"remote_ip":  v.RemoteIP,
+		"user_agent": v.UserAgent,
+	}
+	if sc := trace.SpanContextFromContext(c.Request().Context()); sc.IsValid() {
+		entry["trace_id"] = sc.TraceID().String()
+	}
+	if oid, ok := c.Get("oid").(string); ok {
+		entry["oid"] = oid
+	}
+	if v.Error != nil {
+		entry["error"] = v.Error.Error()
+	}
+
+	if v.Status >= 500 {
+		log.Errorj(entry)
+	} else {
+		log.Infoj(entry)
+	}
+	return nil
+}
+
+// validRequestID checks that the request ID is short and only contains
+// printable ASCII characters, so that it can be safely logged
+func validRequestID(rid string) bool {
+	if rid == "" || len(rid) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(rid); i++ {
+		if rid[i] < 0x21 || rid[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test request ID generation and propagation.")
+
+	tcs := []struct {
+		name       string
+		requestID  string
+		expectSame bool
+	}{
+		{
+			name:       "missing request ID",
+			expectSame: false,
+		},
+		{
+			name:       "valid request ID",
+			requestID:  "3b1f4c2e-reconcile-42",
+			expectSame: true,
+		},
+		{
+			name:       "request ID with control characters",
+			requestID:  "foo\nbar",
+			expectSame: false,
+		},
+		{
+			name:       "request ID too long",
+			requestID:  strings.Repeat("a", maxRequestIDLength+1),
+			expectSame: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		e := echo.New()
+		var handlerID string
+		e.GET("/", func(c echo.Context) error {
+			handlerID = GetRequestID(c)
+			return c.NoContent(http.StatusOK)
+		}, RequestID())
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.requestID != "" {
+			req.Header.Set(echo.HeaderXRequestID, tc.requestID)
+		}
+		rec := httptest.NewRecorder()
+
+		t.Logf("\tTest %s:\tWhen sending request ID %q", tc.name, tc.requestID)
+		e.ServeHTTP(rec, req)
+
+		rid := rec.Header().Get(echo.HeaderXRequestID)
+		test.Equal(rid, handlerID)
+		if tc.expectSame {
+			test.Equal(tc.requestID, rid)
+		} else {
+			_, err := uuid.Parse(rid)
+			test.NoError(err)
+		}
+	}
+}
+
+func TestAccessLogger(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test structured access logs.")
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	e := NewRouter()
+	e.GET("/api/v2/clusters/:name", func(c echo.Context) error {
+		c.Set("oid", "00000000-0000-0000-0000-000000000001")
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/clusters/cluster1", nil)
+	req.Header.Set(echo.HeaderXRequestID, "request-1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	var entry map[string]interface{}
+	test.NoError(json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &entry))
+
+	test.Equal("request", entry["message"])
+	test.Equal("request-1", entry["request_id"])
+	test.Equal("00000000-0000-0000-0000-000000000001", entry["oid"])
+	test.Equal("/api/v2/clusters/:name", entry["route"])
+	test.Equal("/api/v2/clusters/cluster1", entry["uri"])
+	test.Equal(float64(http.StatusOK), entry["status"])
+	test.Contains(entry, "latency")
+}
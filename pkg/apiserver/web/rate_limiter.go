@@ -13,9 +13,9 @@ governing permissions and limitations under the License.
 package web
 
 import (
-	"net/http"
 	"time"
 
+	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
 	"github.com/adobe/cluster-registry/pkg/config"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -38,10 +38,10 @@ func RateLimiter(appConfig *config.AppConfig) echo.MiddlewareFunc {
 			return oid, nil
 		},
 		ErrorHandler: func(context echo.Context, err error) error {
-			return context.JSON(http.StatusForbidden, nil)
+			return errors.Render(context, errors.Forbidden(err))
 		},
 		DenyHandler: func(context echo.Context, identifier string, err error) error {
-			return context.JSON(http.StatusTooManyRequests, nil)
+			return errors.Render(context, errors.RateLimited())
 		},
 	})
 }
@@ -13,6 +13,7 @@ governing permissions and limitations under the License.
 package web
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/adobe/cluster-registry/pkg/config"
@@ -31,21 +32,21 @@ type status struct {
 // StatusSessions is used to keep the same objects and state for the database
 // and sqs that are used for the rest of the calls inside the project
 type StatusSessions struct {
-	SQS       *sqs.Config
+	Queue     sqs.Queue
 	Db        database.Db
 	AppConfig *config.AppConfig
 	Metrics   monitoring.MetricsI
 }
 
-func (s *StatusSessions) checkDBStatus() bool {
-	if err := s.Db.Status(); err != nil {
+func (s *StatusSessions) checkDBStatus(ctx context.Context) bool {
+	if err := s.Db.Status(ctx); err != nil {
 		return false
 	}
 	return true
 }
 
 func (s *StatusSessions) checkSqsStatus() bool {
-	if err := s.SQS.Status(); err != nil {
+	if err := s.Queue.Status(); err != nil {
 		return false
 	}
 	return true
@@ -55,7 +56,7 @@ func (s *StatusSessions) checkSqsStatus() bool {
 func (s *StatusSessions) Readyz(c echo.Context) error {
 
 	readyResponse := status{
-		Database: s.checkDBStatus(),
+		Database: s.checkDBStatus(c.Request().Context()),
 		Sqs:      s.checkSqsStatus(),
 	}
 
@@ -0,0 +1,256 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MIMEApplicationYAML is the media type for YAML responses
+	MIMEApplicationYAML = "application/yaml"
+
+	// MIMETextCSV is the media type for CSV responses
+	MIMETextCSV = "text/csv"
+
+	// MIMEApplicationNDJSON is the media type for newline delimited JSON responses
+	MIMEApplicationNDJSON = "application/x-ndjson"
+
+	// HeaderVary is the response header listing the request headers used for content negotiation
+	HeaderVary = "Vary"
+)
+
+// mediaTypes maps the accepted media types, including common aliases, to the
+// media type used in the response
+var mediaTypes = map[string]string{
+	echo.MIMEApplicationJSON: echo.MIMEApplicationJSON,
+	MIMEApplicationYAML:      MIMEApplicationYAML,
+	"application/x-yaml":     MIMEApplicationYAML,
+	"text/yaml":              MIMEApplicationYAML,
+	MIMETextCSV:              MIMETextCSV,
+	MIMEApplicationNDJSON:    MIMEApplicationNDJSON,
+	"application/ndjson":     MIMEApplicationNDJSON,
+	"application/*":          echo.MIMEApplicationJSON,
+	"text/*":                 MIMETextCSV,
+	"*/*":                    echo.MIMEApplicationJSON,
+}
+
+// NegotiateMediaType returns the response media type that best matches the
+// Accept header of the request. JSON is returned if the header is missing or
+// none of the requested media types is supported.
+func NegotiateMediaType(r *http.Request) string {
+	accept := r.Header.Get(echo.HeaderAccept)
+	if accept == "" {
+		return echo.MIMEApplicationJSON
+	}
+
+	mediaType := echo.MIMEApplicationJSON
+	bestQuality := -1.0
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		t, ok := mediaTypes[strings.ToLower(strings.TrimSpace(params[0]))]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		for _, p := range params[1:] {
+			k, v, found := strings.Cut(strings.TrimSpace(p), "=")
+			if !found || strings.TrimSpace(k) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err == nil {
+				quality = q
+			}
+		}
+
+		// the first media type wins in case of equal quality
+		if quality > 0 && quality > bestQuality {
+			mediaType = t
+			bestQuality = quality
+		}
+	}
+
+	return mediaType
+}
+
+// DefaultClusterColumns are the ClusterSpec fields included in CSV responses
+// if the columns query parameter is missing
+var DefaultClusterColumns = []string{
+	"name",
+	"shortName",
+	"region",
+	"cloudType",
+	"cloudProviderRegion",
+	"environment",
+	"businessUnit",
+	"status",
+	"phase",
+	"lastUpdated",
+}
+
+// Render sends a single object encoded in the negotiated media type. CSV
+// responses contain one row with the requested columns, or defaultColumns if
+// the columns query parameter is missing.
+func Render(c echo.Context, i interface{}, lastModified time.Time, defaultColumns []string) error {
+	return RenderList(c, 1, func(int) interface{} { return i }, func() interface{} { return i }, lastModified, defaultColumns)
+}
+
+// RenderList sends a list of n items encoded in the negotiated media type.
+// JSON and YAML responses are built from list, which wraps the items along
+// with the pagination information. CSV and NDJSON responses are built from the
+// items returned by item, the latter being streamed one item at a time.
+func RenderList(c echo.Context, n int, item func(i int) interface{}, list func() interface{}, lastModified time.Time, defaultColumns []string) error {
+	c.Response().Header().Add(HeaderVary, echo.HeaderAccept)
+
+	switch NegotiateMediaType(c.Request()) {
+	case MIMEApplicationYAML:
+		b, err := yaml.Marshal(list())
+		if err != nil {
+			return err
+		}
+		return ConditionalBlob(c, http.StatusOK, MIMEApplicationYAML, b, lastModified)
+
+	case MIMETextCSV:
+		columns := defaultColumns
+		if q := c.QueryParam("columns"); q != "" {
+			columns = strings.Split(q, ",")
+		}
+		b, err := encodeCSV(columns, n, item)
+		if err != nil {
+			return err
+		}
+		return ConditionalBlob(c, http.StatusOK, MIMETextCSV, b, lastModified)
+
+	case MIMEApplicationNDJSON:
+		if !lastModified.IsZero() {
+			c.Response().Header().Set(echo.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+		}
+		c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationNDJSON)
+		c.Response().WriteHeader(http.StatusOK)
+
+		// the status is already sent, so a failure can only end the stream,
+		// which the client sees as truncated
+		enc := json.NewEncoder(c.Response())
+		for i := 0; i < n; i++ {
+			if err := enc.Encode(item(i)); err != nil {
+				log.Errorj(log.JSON{
+					"message":    "failed to stream list",
+					"request_id": c.Response().Header().Get(echo.HeaderXRequestID),
+					"item":       i,
+					"error":      err.Error(),
+				})
+				return nil
+			}
+			c.Response().Flush()
+		}
+		return nil
+
+	default:
+		return ConditionalJSON(c, http.StatusOK, list(), lastModified)
+	}
+}
+
+// encodeCSV writes a header row with the column paths followed by one row per item
+func encodeCSV(columns []string, n int, item func(i int) interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < n; i++ {
+		// use the JSON representation so that the column paths match the API fields
+		var obj interface{}
+		data, err := json.Marshal(item(i))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return nil, err
+		}
+
+		row := make([]string, len(columns))
+		for j, column := range columns {
+			row[j] = csvValue(lookupPath(obj, strings.Split(strings.TrimSpace(column), ".")))
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// lookupPath returns the value found at the given path, where each element is
+// either an object key or an array index
+func lookupPath(obj interface{}, path []string) interface{} {
+	for _, p := range path {
+		switch v := obj.(type) {
+		case map[string]interface{}:
+			obj = v[p]
+		case []interface{}:
+			index, err := strconv.Atoi(p)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			obj = v[index]
+		default:
+			return nil
+		}
+	}
+	return obj
+}
+
+// csvValue formats scalar values as they are and any other value as JSON
+func csvValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		// lists of scalars are joined, which is easier to consume in spreadsheets
+		items := make([]string, 0, len(value))
+		for _, i := range value {
+			switch i.(type) {
+			case map[string]interface{}, []interface{}:
+				b, _ := json.Marshal(value)
+				return string(b)
+			}
+			items = append(items, csvValue(i))
+		}
+		return strings.Join(items, ";")
+	default:
+		b, _ := json.Marshal(value)
+		return string(b)
+	}
+}
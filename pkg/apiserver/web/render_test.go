@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	test := assert.New(t)
+
+	tcs := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{
+			name:     "no accept header",
+			expected: echo.MIMEApplicationJSON,
+		},
+		{
+			name:     "json",
+			accept:   echo.MIMEApplicationJSON,
+			expected: echo.MIMEApplicationJSON,
+		},
+		{
+			name:     "yaml alias",
+			accept:   "application/x-yaml",
+			expected: MIMEApplicationYAML,
+		},
+		{
+			name:     "csv with parameters",
+			accept:   "text/csv; charset=utf-8",
+			expected: MIMETextCSV,
+		},
+		{
+			name:     "ndjson",
+			accept:   MIMEApplicationNDJSON,
+			expected: MIMEApplicationNDJSON,
+		},
+		{
+			name:     "highest quality wins",
+			accept:   "application/json;q=0.5, text/csv;q=0.9, application/yaml;q=0.1",
+			expected: MIMETextCSV,
+		},
+		{
+			name:     "first media type wins on equal quality",
+			accept:   "application/x-ndjson, application/json",
+			expected: MIMEApplicationNDJSON,
+		},
+		{
+			name:     "unsupported media types are ignored",
+			accept:   "text/html, application/yaml;q=0.8",
+			expected: MIMEApplicationYAML,
+		},
+		{
+			name:     "zero quality is not acceptable",
+			accept:   "text/csv;q=0",
+			expected: echo.MIMEApplicationJSON,
+		},
+		{
+			name:     "wildcard",
+			accept:   "*/*",
+			expected: echo.MIMEApplicationJSON,
+		},
+	}
+
+	for _, tc := range tcs {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.accept != "" {
+			req.Header.Set(echo.HeaderAccept, tc.accept)
+		}
+		t.Logf("\tTest %s:\tWhen accepting %q", tc.name, tc.accept)
+		test.Equal(tc.expected, NegotiateMediaType(req))
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	test := assert.New(t)
+
+	clusters := []registryv1.ClusterSpec{
+		{
+			Name:   "cluster1",
+			Region: "va7",
+			Tags:   map[string]string{"scaling": "off"},
+			Extra: registryv1.Extra{
+				DomainName: "cluster1.example.com",
+			},
+			Capabilities: []string{"gpu", "ipv6"},
+			AvailabilityZones: []registryv1.AvailabilityZone{
+				{Name: "us-east-1a"},
+				{Name: "us-east-1b"},
+			},
+			Capacity: registryv1.Capacity{
+				ClusterCapacity: 100,
+			},
+		},
+		{
+			Name:   "cluster2",
+			Region: "va6, east",
+		},
+	}
+
+	b, err := encodeCSV(
+		[]string{"name", "region", "tags.scaling", "extra.domainName", "capabilities", "availabilityZones", "availabilityZones.1.name", "capacity.clusterCapacity", "missing.field"},
+		len(clusters),
+		func(i int) interface{} { return clusters[i] },
+	)
+	test.NoError(err)
+	test.Equal(
+		"name,region,tags.scaling,extra.domainName,capabilities,availabilityZones,availabilityZones.1.name,capacity.clusterCapacity,missing.field\n"+
+			"cluster1,va7,off,cluster1.example.com,gpu;ipv6,\"[{\"\"name\"\":\"\"us-east-1a\"\"},{\"\"name\"\":\"\"us-east-1b\"\"}]\",us-east-1b,100,\n"+
+			"cluster2,\"va6, east\",,,,,,0,\n",
+		string(b))
+}
+
+func TestCacheKey(t *testing.T) {
+	test := assert.New(t)
+
+	jsonReq := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", nil)
+	csvReq := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", nil)
+	csvReq.Header.Set(echo.HeaderAccept, MIMETextCSV)
+
+	test.Equal(CacheKey(jsonReq), CacheKey(httptest.NewRequest(http.MethodGet, "/api/v2/clusters", nil)))
+	test.NotEqual(CacheKey(jsonReq), CacheKey(csvReq))
+}
+
+func TestRenderListNDJSONError(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test ending the NDJSON stream when an item cannot be encoded.")
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/v2/clusters", nil)
+	req.Header.Set(echo.HeaderAccept, MIMEApplicationNDJSON)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	items := []interface{}{
+		registryv1.ClusterSpec{Name: "cluster1"},
+		map[string]interface{}{"name": func() {}},
+		registryv1.ClusterSpec{Name: "cluster3"},
+	}
+	err := RenderList(ctx, len(items), func(i int) interface{} { return items[i] }, nil, time.Time{}, nil)
+	test.NoError(err)
+	test.Equal(http.StatusOK, rec.Code)
+	test.Contains(rec.Body.String(), "cluster1")
+	test.NotContains(rec.Body.String(), "cluster3")
+}
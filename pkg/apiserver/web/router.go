@@ -13,29 +13,38 @@ governing permissions and limitations under the License.
 package web
 
 import (
-	"github.com/google/uuid"
+	"slices"
+
+	"github.com/adobe/cluster-registry/pkg/apiserver/errors"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
+// ServiceName is the name of the API server in traces
+const ServiceName = "cluster-registry-api"
+
+// untracedPaths are the probes and metrics endpoints, which are not traced
+var untracedPaths = []string{"/livez", "/readyz", "/metrics"}
+
 // NewRouter func
 func NewRouter() *echo.Echo {
 	e := echo.New()
 	e.Logger.SetLevel(log.DEBUG)
 	e.Pre(middleware.RemoveTrailingSlash())
-	e.Use(middleware.Logger())
+	e.Use(RequestID())
+	e.Use(otelecho.Middleware(ServiceName, otelecho.WithSkipper(func(c echo.Context) bool {
+		return slices.Contains(untracedPaths, c.Request().URL.Path)
+	})))
+	e.Use(AccessLogger())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		AllowMethods: []string{echo.GET, echo.HEAD},
+		AllowOrigins:  []string{"*"},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, echo.HeaderXRequestID, "traceparent", "tracestate"},
+		AllowMethods:  []string{echo.GET, echo.HEAD},
+		ExposeHeaders: []string{echo.HeaderXRequestID},
 	}))
 	e.Validator = NewValidator()
-	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
-		Generator: func() string {
-			id := uuid.New()
-			return id.String()
-		},
-	}))
+	e.HTTPErrorHandler = errors.HTTPErrorHandler
 	return e
 }
@@ -15,15 +15,18 @@ package auth
 import (
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
+	apierrors "github.com/adobe/cluster-registry/pkg/apiserver/errors"
 	"github.com/adobe/cluster-registry/pkg/config"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/apiserver"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"golang.org/x/net/context"
 )
@@ -74,11 +77,20 @@ func NewAuthenticator(appConfig *config.AppConfig, m monitoring.MetricsI) (*Auth
 	}, nil
 }
 
-// verify check if the token is valid by both verifiers, to allow tokens with or without the 'spn' prefix
-func (a *Authenticator) verify(ctx context.Context, rawToken string) (*oidc.IDToken, error) {
-	token, err := a.verifier.Verify(ctx, rawToken)
+// verify check if the token is valid by both verifiers, to allow tokens with or without the 'spn' prefix.
+// The span is started from the request context, while the verifiers use the context of the
+// authenticator, so that fetching the shared key set is not cancelled along with a request.
+func (a *Authenticator) verify(ctx context.Context, rawToken string) (_ *oidc.IDToken, err error) {
+	_, span := tracing.Tracer().Start(ctx, "auth.verify", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	token, err := a.verifier.Verify(a.ctx, rawToken)
 	if err != nil && strings.Contains(err.Error(), "spn:") {
-		token, err = a.spnVerifier.Verify(ctx, rawToken)
+		span.SetAttributes(attribute.Bool("auth.spn", true))
+		token, err = a.spnVerifier.Verify(a.ctx, rawToken)
 	}
 	return token, err
 }
@@ -91,18 +103,18 @@ func (a *Authenticator) VerifyToken() echo.MiddlewareFunc {
 
 			rawToken, err := extractToken(authorization)
 			if err != nil {
-				return c.JSON(http.StatusBadRequest, NewError(err))
+				return apierrors.Render(c, apierrors.BadRequest(err))
 			}
 
 			start := time.Now()
-			token, err := a.verify(a.ctx, rawToken)
+			token, err := a.verify(c.Request().Context(), rawToken)
 			elapsed := float64(time.Since(start)) / float64(time.Second)
 
 			a.metrics.RecordEgressRequestCnt(egressTarget)
 			a.metrics.RecordEgressRequestDur(egressTarget, elapsed)
 
 			if err != nil {
-				return c.JSON(http.StatusForbidden, NewError(err))
+				return apierrors.Render(c, apierrors.Forbidden(err))
 			}
 
 			var claims struct {
@@ -110,13 +122,17 @@ func (a *Authenticator) VerifyToken() echo.MiddlewareFunc {
 				Groups []string `json:"groups"`
 			}
 			if err := token.Claims(&claims); err != nil {
-				return c.JSON(http.StatusForbidden, NewError(err))
+				return apierrors.Render(c, apierrors.Forbidden(err))
 			}
 
 			c.Set("oid", claims.Oid)
 			c.Set("groups", claims.Groups)
 
-			log.Info("Identity logged in: ", claims.Oid)
+			log.Debugj(log.JSON{
+				"message":    "identity logged in",
+				"oid":        claims.Oid,
+				"request_id": c.Response().Header().Get(echo.HeaderXRequestID),
+			})
 			return next(c)
 		}
 	}
@@ -129,12 +145,12 @@ func (a *Authenticator) VerifyGroupAccess(group string) echo.MiddlewareFunc {
 			oid := c.Get("oid").(string)
 
 			if c.Get("groups") == nil {
-				return c.JSON(http.StatusForbidden, NewError(fmt.Errorf("identity %s is not authorized to perform this request", oid)))
+				return apierrors.Render(c, apierrors.Forbidden(fmt.Errorf("identity %s is not authorized to perform this request", oid)))
 			}
 			groups := c.Get("groups").([]string)
 
 			if !contains(groups, group) {
-				return c.JSON(http.StatusForbidden, NewError(fmt.Errorf("identity %s is not authorized to perform this request", oid)))
+				return apierrors.Render(c, apierrors.Forbidden(fmt.Errorf("identity %s is not authorized to perform this request", oid)))
 			}
 
 			return next(c)
@@ -165,21 +181,3 @@ func extractToken(authorization string) (string, error) {
 	}
 	return "", errors.New("missing or malformed jwt")
 }
-
-// Error struct
-type Error struct {
-	Errors map[string]interface{} `json:"errors"`
-}
-
-// NewError constructor
-func NewError(err error) Error {
-	e := Error{}
-	e.Errors = make(map[string]interface{})
-	switch v := err.(type) {
-	case *echo.HTTPError:
-		e.Errors["body"] = v.Message
-	default:
-		e.Errors["body"] = v.Error()
-	}
-	return e
-}
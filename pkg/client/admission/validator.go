@@ -0,0 +1,158 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/validate-registry-ethos-adobe-com-v1-cluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=registry.ethos.adobe.com,resources=clusters,verbs=create;update,versions=v1,name=vcluster.registry.ethos.adobe.com,admissionReviewVersions=v1
+//+kubebuilder:webhook:path=/validate-registry-ethos-adobe-com-v1alpha1-servicemetadatawatcher,mutating=false,failurePolicy=fail,sideEffects=None,groups=registry.ethos.adobe.com,resources=servicemetadatawatchers,verbs=create;update,versions=v1alpha1,name=vservicemetadatawatcher.registry.ethos.adobe.com,admissionReviewVersions=v1
+
+// SetupWebhooksWithManager registers the validating webhooks of the Cluster
+// and ServiceMetadataWatcher objects
+func SetupWebhooksWithManager(mgr ctrl.Manager) error {
+	if err := ctrl.NewWebhookManagedBy(mgr).
+		For(&registryv1.Cluster{}).
+		WithValidator(&ClusterValidator{}).
+		Complete(); err != nil {
+		return err
+	}
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&registryv1alpha1.ServiceMetadataWatcher{}).
+		WithValidator(&ServiceMetadataWatcherValidator{}).
+		Complete()
+}
+
+// ClusterValidator rejects the Cluster objects which the registry would not
+// accept
+type ClusterValidator struct{}
+
+var _ admission.CustomValidator = &ClusterValidator{}
+
+// ValidateCreate validates the created cluster
+func (v *ClusterValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate validates the updated cluster, unless it is being deleted or
+// its spec is unchanged. Only the fields which the update makes invalid are
+// rejected, so that the clusters admitted before a stricter validation can
+// still be updated.
+func (v *ClusterValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	cluster, ok := newObj.(*registryv1.Cluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a Cluster but got a %T", newObj)
+	}
+	if cluster.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	errs := cluster.Spec.ValidateFields(field.NewPath("spec"))
+	if old, ok := oldObj.(*registryv1.Cluster); ok {
+		if equality.Semantic.DeepEqual(old.Spec, cluster.Spec) {
+			return nil, nil
+		}
+		errs = newlyInvalid(old.Spec.ValidateFields(field.NewPath("spec")), errs)
+	}
+	return nil, invalid(registryv1.GroupVersion.WithKind("Cluster").GroupKind(), cluster.Name, errs)
+}
+
+// ValidateDelete accepts the deletion of any cluster
+func (v *ClusterValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ClusterValidator) validate(obj runtime.Object) error {
+	cluster, ok := obj.(*registryv1.Cluster)
+	if !ok {
+		return fmt.Errorf("expected a Cluster but got a %T", obj)
+	}
+	return invalid(registryv1.GroupVersion.WithKind("Cluster").GroupKind(), cluster.Name, cluster.Spec.ValidateFields(field.NewPath("spec")))
+}
+
+// ServiceMetadataWatcherValidator rejects the ServiceMetadataWatcher objects
+// whose watched objects or fields cannot be resolved
+type ServiceMetadataWatcherValidator struct{}
+
+var _ admission.CustomValidator = &ServiceMetadataWatcherValidator{}
+
+// ValidateCreate validates the created watcher
+func (v *ServiceMetadataWatcherValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.validate(obj)
+}
+
+// ValidateUpdate validates the updated watcher, unless it is being deleted or
+// its spec is unchanged, so that the watchers admitted before a stricter
+// validation can still be updated, e.g. to remove their finalizers
+func (v *ServiceMetadataWatcherValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	watcher, ok := newObj.(*registryv1alpha1.ServiceMetadataWatcher)
+	if !ok {
+		return nil, fmt.Errorf("expected a ServiceMetadataWatcher but got a %T", newObj)
+	}
+	if watcher.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	if old, ok := oldObj.(*registryv1alpha1.ServiceMetadataWatcher); ok && equality.Semantic.DeepEqual(old.Spec, watcher.Spec) {
+		return nil, nil
+	}
+	return nil, v.validate(watcher)
+}
+
+// ValidateDelete accepts the deletion of any watcher
+func (v *ServiceMetadataWatcherValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *ServiceMetadataWatcherValidator) validate(obj runtime.Object) error {
+	watcher, ok := obj.(*registryv1alpha1.ServiceMetadataWatcher)
+	if !ok {
+		return fmt.Errorf("expected a ServiceMetadataWatcher but got a %T", obj)
+	}
+	return invalid(registryv1alpha1.GroupVersion.WithKind("ServiceMetadataWatcher").GroupKind(), watcher.Name, watcher.Spec.ValidateFields(field.NewPath("spec")))
+}
+
+// newlyInvalid returns the errors of the fields which were valid before the
+// update, or whose invalid value was changed
+func newlyInvalid(oldErrs, errs field.ErrorList) field.ErrorList {
+	var newErrs field.ErrorList
+	for _, err := range errs {
+		if !slices.ContainsFunc(oldErrs, func(oldErr *field.Error) bool {
+			return oldErr.Type == err.Type && oldErr.Field == err.Field &&
+				equality.Semantic.DeepEqual(oldErr.BadValue, err.BadValue)
+		}) {
+			newErrs = append(newErrs, err)
+		}
+	}
+	return newErrs
+}
+
+// invalid returns the Invalid status error of the object, if any field is invalid
+func invalid(gk schema.GroupKind, name string, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(gk, name, errs)
+}
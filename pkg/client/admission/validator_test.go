@@ -0,0 +1,131 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+func TestClusterValidator(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test admitting the valid clusters only.")
+
+	v := &ClusterValidator{}
+	old := &registryv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1"},
+		Spec: registryv1.ClusterSpec{
+			Name:  "cluster1",
+			Tiers: []registryv1.Tier{{Name: "worker", MinCapacity: 1, MaxCapacity: 3}},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), old)
+	test.NoError(err)
+
+	invalid := old.DeepCopy()
+	invalid.Spec.Tiers[0].MinCapacity = 5
+	_, err = v.ValidateUpdate(context.Background(), old, invalid)
+	test.True(apierrors.IsInvalid(err))
+	test.Contains(err.Error(), "spec.tiers[0].minCapacity")
+
+	t.Logf("\tTest deletion:\tWhen deleting an invalid cluster")
+	_, err = v.ValidateDelete(context.Background(), invalid)
+	test.NoError(err)
+
+	t.Logf("\tTest unchanged spec:\tWhen removing the finalizers of an invalid cluster")
+	withFinalizer := invalid.DeepCopy()
+	withFinalizer.Finalizers = []string{"registry.ethos.adobe.com/cluster"}
+	_, err = v.ValidateUpdate(context.Background(), withFinalizer, invalid.DeepCopy())
+	test.NoError(err)
+
+	t.Logf("\tTest being deleted:\tWhen updating an invalid cluster being deleted")
+	deleted := invalid.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	deleted.Spec.Tiers[0].MaxCapacity = 2
+	_, err = v.ValidateUpdate(context.Background(), invalid, deleted)
+	test.NoError(err)
+
+	t.Logf("\tTest already invalid:\tWhen updating another field of an invalid cluster")
+	updated := invalid.DeepCopy()
+	updated.Spec.Tiers[0].Name = "control-plane"
+	_, err = v.ValidateUpdate(context.Background(), invalid, updated)
+	test.NoError(err)
+
+	t.Logf("\tTest newly invalid:\tWhen making another field of an invalid cluster invalid")
+	updated = invalid.DeepCopy()
+	updated.Spec.Tiers = append(updated.Spec.Tiers, registryv1.Tier{Name: "gpu", MinCapacity: 4, MaxCapacity: 2})
+	_, err = v.ValidateUpdate(context.Background(), invalid, updated)
+	test.True(apierrors.IsInvalid(err))
+	test.Contains(err.Error(), "spec.tiers[1].minCapacity")
+	test.NotContains(err.Error(), "spec.tiers[0].minCapacity")
+
+	t.Logf("\tTest wrong type:\tWhen validating another object")
+	_, err = v.ValidateCreate(context.Background(), &registryv1alpha1.ServiceMetadataWatcher{})
+	test.Error(err)
+}
+
+func TestServiceMetadataWatcherValidator(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test admitting the watchers with valid paths only.")
+
+	v := &ServiceMetadataWatcherValidator{}
+	watcher := &registryv1alpha1.ServiceMetadataWatcher{
+		ObjectMeta: metav1.ObjectMeta{Name: "watcher1"},
+		Spec: registryv1alpha1.ServiceMetadataWatcherSpec{
+			WatchedServiceObjects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: registryv1alpha1.ObjectReference{Name: "app", APIVersion: "apps/v1", Kind: "Deployment"},
+				WatchedFields:   []registryv1alpha1.WatchedField{{Source: "spec.replicas", Destination: "replicas"}},
+			}},
+		},
+	}
+
+	_, err := v.ValidateCreate(context.Background(), watcher)
+	test.NoError(err)
+
+	invalid := watcher.DeepCopy()
+	invalid.Spec.WatchedServiceObjects[0].WatchedFields[0].Source = "spec.[replicas"
+	_, err = v.ValidateCreate(context.Background(), invalid)
+	test.True(apierrors.IsInvalid(err))
+	test.Contains(err.Error(), "spec.watchedServiceObjects[0].watchedFields[0].src")
+
+	t.Logf("\tTest unchanged spec:\tWhen removing the finalizers of an invalid watcher")
+	withFinalizer := invalid.DeepCopy()
+	withFinalizer.Finalizers = []string{"registry.ethos.adobe.com/service-metadata"}
+	withoutFinalizer := invalid.DeepCopy()
+	_, err = v.ValidateUpdate(context.Background(), withFinalizer, withoutFinalizer)
+	test.NoError(err)
+
+	t.Logf("\tTest deletion:\tWhen updating an invalid watcher being deleted")
+	deleted := invalid.DeepCopy()
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	deleted.Spec.WatchedServiceObjects[0].WatchedFields[0].Destination = "instances"
+	_, err = v.ValidateUpdate(context.Background(), invalid, deleted)
+	test.NoError(err)
+
+	t.Logf("\tTest changed spec:\tWhen updating the spec of an invalid watcher")
+	changed := invalid.DeepCopy()
+	changed.Spec.WatchedServiceObjects[0].WatchedFields[0].Destination = "instances"
+	_, err = v.ValidateUpdate(context.Background(), invalid, changed)
+	test.True(apierrors.IsInvalid(err))
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+)
+
+// ClusterAckHandler marks the clusters as acknowledged once the API server
+// stored their current spec
+type ClusterAckHandler struct {
+	sqs.EventHandler
+	Client client.Client
+	Log    logr.Logger
+}
+
+func (h *ClusterAckHandler) Type() string {
+	return sqs.ClusterAckEvent
+}
+
+func (h *ClusterAckHandler) Handle(ctx context.Context, event *sqs.Event) error {
+	if event == nil {
+		return errors.New("event is nil")
+	}
+
+	if event.Type != h.Type() {
+		return errors.New("event type does not match handler type")
+	}
+
+	msg := event.Message
+	clusterName := msg.Attributes[sqs.MessageAttributeClusterName]
+	hash := msg.Attributes[sqs.MessageAttributeHash]
+	revision := msg.Attributes[sqs.MessageAttributeRevision]
+	log := h.Log.WithValues("cluster", clusterName, "requestID", event.RequestID())
+
+	clusters := new(registryv1.ClusterList)
+	if err := h.Client.List(ctx, clusters); err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters.Items {
+		if cluster.Spec.Name != clusterName {
+			continue
+		}
+
+		acknowledged := false
+		err := updateClusterStatus(ctx, h.Client, client.ObjectKeyFromObject(&cluster), func(status *registryv1.ClusterStatus) {
+			// the acknowledgements of the previous specs are outdated
+			if status.LastEnqueuedHash != hash {
+				return
+			}
+			acknowledged = true
+			status.RegistryRevision = revision
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               registryv1.ConditionAcknowledged,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: cluster.Generation,
+				Reason:             ReasonStored,
+				Message:            fmt.Sprintf("Stored by the registry with revision %s", revision),
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+		if acknowledged {
+			log.Info("Cluster update acknowledged", "revision", revision)
+		} else {
+			log.Info("Skipping outdated acknowledgement", "hash", hash)
+		}
+		return nil
+	}
+
+	log.Info("Skipping acknowledgement of unknown cluster")
+	return nil
+}
+
+// Acknowledgements receives the acknowledgements of the cluster updates from
+// the reply queue
+type Acknowledgements struct {
+	Client client.Client
+	Log    logr.Logger
+	Queue  sqs.Queue
+}
+
+// Start polls the reply queue until the context is cancelled
+func (a *Acknowledgements) Start(ctx context.Context) error {
+	dispatcher, err := sqs.NewDispatcher(a.Queue, sqs.DispatcherOptions{
+		UnknownEvents: sqs.RequeueUnknownEvents,
+		RequeueDelay:  30,
+	})
+	if err != nil {
+		return err
+	}
+	dispatcher.Register(&ClusterAckHandler{Client: a.Client, Log: a.Log}, sqs.AckOnSuccess)
+
+	return a.Queue.Poll(ctx, dispatcher.Handle)
+}
+
+// NeedLeaderElection makes only the leader update the status
+func (a *Acknowledgements) NeedLeaderElection() bool {
+	return true
+}
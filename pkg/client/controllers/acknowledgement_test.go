@@ -0,0 +1,138 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+)
+
+func newStatusClient(t *testing.T) client.Client {
+	s := runtime.NewScheme()
+	assert.NoError(t, registryv1.AddToScheme(s))
+
+	cluster := &registryv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"},
+		Spec:       registryv1.ClusterSpec{Name: "cluster1"},
+	}
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(cluster).WithStatusSubresource(cluster).Build()
+}
+
+func TestClusterStatus(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test recording the delivery and the acknowledgement of the cluster updates.")
+
+	tcs := []struct {
+		name                 string
+		replyTo              string
+		enqueueErr           error
+		ackHash              func(enqueued string) string
+		expectedEnqueued     metav1.ConditionStatus
+		expectedAcknowledged metav1.ConditionStatus
+		expectedRevision     string
+	}{
+		{
+			name:                 "acknowledged update",
+			replyTo:              "cluster1-acks",
+			ackHash:              func(enqueued string) string { return enqueued },
+			expectedEnqueued:     metav1.ConditionTrue,
+			expectedAcknowledged: metav1.ConditionTrue,
+			expectedRevision:     "2024-01-01T00:00:00Z",
+		},
+		{
+			name:                 "outdated acknowledgement",
+			replyTo:              "cluster1-acks",
+			ackHash:              func(_ string) string { return "previous" },
+			expectedEnqueued:     metav1.ConditionTrue,
+			expectedAcknowledged: metav1.ConditionFalse,
+		},
+		{
+			name:                 "acknowledgements disabled",
+			expectedEnqueued:     metav1.ConditionTrue,
+			expectedAcknowledged: metav1.ConditionUnknown,
+		},
+		{
+			name:                 "enqueue error",
+			replyTo:              "cluster1-acks",
+			enqueueErr:           errors.New("queue unavailable"),
+			expectedEnqueued:     metav1.ConditionFalse,
+			expectedAcknowledged: metav1.ConditionFalse,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen reconciling a cluster replying to %q", tc.name, tc.replyTo)
+		ctx := context.Background()
+
+		c := newStatusClient(t)
+		q := &fakeQueue{err: tc.enqueueErr}
+		r := &ClusterReconciler{Client: c, Log: logr.Discard(), Queue: q, ReplyTo: tc.replyTo}
+
+		instance := new(registryv1.Cluster)
+		key := client.ObjectKey{Name: "cluster1", Namespace: "cluster-registry"}
+		test.NoError(c.Get(ctx, key, instance))
+
+		_, err := r.ReconcileCreateUpdate(ctx, instance, logr.Discard())
+		if tc.enqueueErr != nil {
+			test.Error(err)
+		} else {
+			test.NoError(err)
+			test.Len(q.batches, 1)
+			msg := q.batches[0][0]
+			test.Equal(hashCluster(instance), msg.Attributes[sqs.MessageAttributeHash])
+			test.Equal(tc.replyTo, msg.Attributes[sqs.MessageAttributeReplyTo])
+			test.NotContains(msg.Body, "conditions")
+		}
+
+		if tc.ackHash != nil {
+			handler := &ClusterAckHandler{Client: c, Log: logr.Discard()}
+			ack := &sqs.Message{Attributes: map[string]string{
+				sqs.MessageAttributeType:        sqs.ClusterAckEvent,
+				sqs.MessageAttributeClusterName: "cluster1",
+				sqs.MessageAttributeHash:        tc.ackHash(q.batches[0][0].Attributes[sqs.MessageAttributeHash]),
+				sqs.MessageAttributeRevision:    "2024-01-01T00:00:00Z",
+			}}
+			test.NoError(handler.Handle(ctx, &sqs.Event{Type: sqs.ClusterAckEvent, Message: ack}))
+		}
+
+		updated := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, key, updated))
+		status := updated.Status
+
+		test.Equal(tc.expectedEnqueued, meta.FindStatusCondition(status.Conditions, registryv1.ConditionEnqueued).Status)
+		test.Equal(tc.expectedAcknowledged, meta.FindStatusCondition(status.Conditions, registryv1.ConditionAcknowledged).Status)
+		test.Equal(tc.expectedRevision, status.RegistryRevision)
+		if tc.enqueueErr != nil {
+			test.Equal(tc.enqueueErr.Error(), status.LastError)
+			test.Empty(status.LastEnqueuedHash)
+			test.Nil(status.LastEnqueuedTime)
+		} else {
+			test.Empty(status.LastError)
+			test.Equal(hashCluster(updated), status.LastEnqueuedHash)
+			test.NotNil(status.LastEnqueuedTime)
+		}
+	}
+}
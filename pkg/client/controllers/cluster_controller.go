@@ -17,21 +17,25 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"github.com/aws/aws-sdk-go/aws"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/google/uuid"
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/sqs"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 )
 
 // ClusterReconciler reconciles a Cluster object
@@ -39,8 +43,12 @@ type ClusterReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
-	Queue  *sqs.Config
+	Queue  sqs.Queue
 	CAData string
+
+	// ReplyTo is the name of the queue where the API server acknowledges the
+	// updates. The updates are not acknowledged if it is empty.
+	ReplyTo string
 }
 
 const (
@@ -81,11 +89,24 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
-	return r.ReconcileCreateUpdate(instance, log)
+	return r.ReconcileCreateUpdate(ctx, instance, log)
 }
 
 // ReconcileCreateUpdate ...
-func (r *ClusterReconciler) ReconcileCreateUpdate(instance *registryv1.Cluster, log logr.Logger) (ctrl.Result, error) {
+func (r *ClusterReconciler) ReconcileCreateUpdate(ctx context.Context, instance *registryv1.Cluster, log logr.Logger) (_ ctrl.Result, err error) {
+	requestID := requestIDFromContext(ctx)
+
+	ctx, span := tracing.Tracer().Start(ctx, "ClusterReconciler.ReconcileCreateUpdate",
+		trace.WithAttributes(
+			attribute.String("cluster.name", instance.Spec.Name),
+			attribute.String("request.id", requestID),
+		),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	hash := hashCluster(instance)
 
 	annotations := instance.GetAnnotations()
@@ -103,15 +124,79 @@ func (r *ClusterReconciler) ReconcileCreateUpdate(instance *registryv1.Cluster,
 
 	instance.SetAnnotations(annotations)
 
-	err := r.enqueue(instance, skipCacheInvalidation)
+	// the hash is recorded before enqueuing, for the acknowledgement not to
+	// arrive before it
+	key := client.ObjectKeyFromObject(instance)
+	var previousHash string
+	if err := updateClusterStatus(ctx, r.Client, key, func(status *registryv1.ClusterStatus) {
+		previousHash = status.LastEnqueuedHash
+		status.LastEnqueuedHash = hash
+		r.setPendingAcknowledgement(status, instance.Generation)
+	}); err != nil {
+		// the status is informative, and does not block the update
+		log.Error(err, "unable to update status", "requestID", requestID)
+	}
+
+	err = r.enqueue(ctx, instance, hash, skipCacheInvalidation, requestID)
+	if statusErr := updateClusterStatus(ctx, r.Client, key, func(status *registryv1.ClusterStatus) {
+		setEnqueuedStatus(status, instance.Generation, previousHash, err)
+	}); statusErr != nil {
+		log.Error(statusErr, "unable to update status", "requestID", requestID)
+	}
 	if err != nil {
-		r.Log.Error(err, "error enqueuing message")
+		r.Log.Error(err, "error enqueuing message", "requestID", requestID)
 		return ctrl.Result{}, err
 	}
+	log.Info("Enqueued cluster update", "requestID", requestID)
 
 	return ctrl.Result{}, nil
 }
 
+// setPendingAcknowledgement resets the Acknowledged condition for a new spec
+func (r *ClusterReconciler) setPendingAcknowledgement(status *registryv1.ClusterStatus, generation int64) {
+	condition := metav1.Condition{
+		Type:               registryv1.ConditionAcknowledged,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             ReasonPending,
+		Message:            "Waiting for the registry to acknowledge the spec",
+	}
+	if r.ReplyTo == "" {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = ReasonAcknowledgementDisabled
+		condition.Message = "The reply queue is not configured"
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// setEnqueuedStatus records the result of enqueuing the spec, restoring the
+// previous hash if it failed
+func setEnqueuedStatus(status *registryv1.ClusterStatus, generation int64, previousHash string, err error) {
+	if err != nil {
+		status.LastEnqueuedHash = previousHash
+		status.LastError = err.Error()
+		meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:               registryv1.ConditionEnqueued,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             ReasonEnqueueFailed,
+			Message:            err.Error(),
+		})
+		return
+	}
+
+	now := metav1.Now()
+	status.LastEnqueuedTime = &now
+	status.LastError = ""
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               registryv1.ConditionEnqueued,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             ReasonEnqueued,
+		Message:            "Sent to the registry",
+	})
+}
+
 func (r *ClusterReconciler) hasDifferentHash(object runtime.Object) bool {
 	instance := object.(*registryv1.Cluster)
 	oldHash := instance.GetAnnotations()[HashAnnotation]
@@ -134,6 +219,10 @@ func (r *ClusterReconciler) eventFilters() predicate.Predicate {
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			r.Log.Info("UpdateEvent", "event", e.ObjectNew)
+			// the status updates of the reconciler do not change the hash
+			if hashCluster(e.ObjectOld.(*registryv1.Cluster)) == hashCluster(e.ObjectNew.(*registryv1.Cluster)) {
+				return false
+			}
 			return r.hasDifferentHash(e.ObjectNew)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
@@ -143,40 +232,35 @@ func (r *ClusterReconciler) eventFilters() predicate.Predicate {
 	}
 }
 
-func (r *ClusterReconciler) enqueue(instance *registryv1.Cluster, skipCacheInvalidation bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (r *ClusterReconciler) enqueue(ctx context.Context, instance *registryv1.Cluster, hash string, skipCacheInvalidation bool, requestID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	obj, err := json.Marshal(instance)
+	// the delivery status is local to the cluster
+	clone := instance.DeepCopy()
+	clone.Status = registryv1.ClusterStatus{}
+	obj, err := json.Marshal(clone)
 	if err != nil {
 		return err
 	}
 
-	id, err := uuid.NewUUID()
-	if err != nil {
-		return err
+	attributes := map[string]string{
+		sqs.MessageAttributeType:                  sqs.ClusterUpdateEvent,
+		sqs.MessageAttributeClusterName:           instance.Spec.Name,
+		sqs.MessageAttributeSkipCacheInvalidation: fmt.Sprintf("%t", skipCacheInvalidation),
+		sqs.MessageAttributeRequestID:             requestID,
+		sqs.MessageAttributeHash:                  hash,
+	}
+	if r.ReplyTo != "" {
+		attributes[sqs.MessageAttributeReplyTo] = r.ReplyTo
 	}
 
 	start := time.Now()
-	err = r.Queue.Enqueue(ctx, []*awssqs.SendMessageBatchRequestEntry{
+	err = r.Queue.Enqueue(ctx, []*sqs.Message{
 		{
-			Id:           aws.String(id.String()),
-			DelaySeconds: aws.Int64(10),
-			MessageAttributes: map[string]*awssqs.MessageAttributeValue{
-				"Type": {
-					DataType:    aws.String("String"),
-					StringValue: aws.String(sqs.ClusterUpdateEvent),
-				},
-				"ClusterName": {
-					DataType:    aws.String("String"),
-					StringValue: aws.String(instance.Spec.Name),
-				},
-				"SkipCacheInvalidation": {
-					DataType:    aws.String("String"),
-					StringValue: aws.String(fmt.Sprintf("%t", skipCacheInvalidation)),
-				},
-			},
-			MessageBody: aws.String(string(obj)),
+			DelaySeconds: 10,
+			Attributes:   attributes,
+			Body:         string(obj),
 		},
 	})
 	elapsed := float64(time.Since(start)) / float64(time.Second)
@@ -189,6 +273,16 @@ func (r *ClusterReconciler) enqueue(instance *registryv1.Cluster, skipCacheInval
 	return nil
 }
 
+// requestIDFromContext returns the ID of the current reconciliation, so that the
+// API server logs can be correlated with the controller logs, or a new ID if
+// the context does not belong to a reconciliation
+func requestIDFromContext(ctx context.Context) string {
+	if id := controller.ReconcileIDFromContext(ctx); id != "" {
+		return string(id)
+	}
+	return uuid.New().String()
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -208,6 +302,7 @@ func hashCluster(instance *registryv1.Cluster) string {
 
 	clone.SetResourceVersion("")
 	clone.SetManagedFields(nil)
+	clone.Status = registryv1.ClusterStatus{}
 
 	b, _ := json.Marshal(clone)
 	h := sha256.New()
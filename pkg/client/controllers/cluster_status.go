@@ -0,0 +1,45 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+// Reasons of the Enqueued and Acknowledged conditions
+const (
+	ReasonEnqueued                = "Enqueued"
+	ReasonEnqueueFailed           = "EnqueueFailed"
+	ReasonPending                 = "Pending"
+	ReasonStored                  = "Stored"
+	ReasonAcknowledgementDisabled = "AcknowledgementDisabled"
+)
+
+// updateClusterStatus applies mutate to the latest status of the cluster,
+// retrying on conflicts, so that the concurrent updates of the reconciler and
+// of the acknowledgements are not lost
+func updateClusterStatus(ctx context.Context, c client.Client, key client.ObjectKey, mutate func(status *registryv1.ClusterStatus)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance := new(registryv1.Cluster)
+		if err := c.Get(ctx, key, instance); err != nil {
+			return err
+		}
+		mutate(&instance.Status)
+		return c.Status().Update(ctx, instance)
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+// ReasonTagReverted is the reason of the events of the reverted tags
+const ReasonTagReverted = "TagReverted"
+
+// ClusterTagReconciler reverts the tag overrides of the clusters once they
+// expire
+type ClusterTagReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=registry.ethos.adobe.com,resources=clusters,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+// Reconcile reverts the expired tag overrides of the cluster, and requeues it
+// until the next one expires
+func (r *ClusterTagReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("name", req.NamespacedName)
+
+	instance := new(registryv1.Cluster)
+	if err := r.Get(ctx, req.NamespacedName, instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var events []string
+	var next time.Duration
+	changed := false
+	now := time.Now()
+
+	for key, override := range instance.Spec.TagOverrides {
+		expiry, err := override.Expiry()
+		if err != nil {
+			log.Error(err, "dropping tag override with an invalid expiry", "tag", key)
+			delete(instance.Spec.TagOverrides, key)
+			changed = true
+			continue
+		}
+		if remaining := expiry.Sub(now); remaining > 0 {
+			if next == 0 || remaining < next {
+				next = remaining
+			}
+			continue
+		}
+
+		delete(instance.Spec.TagOverrides, key)
+		changed = true
+
+		// the tag is left alone if it is excluded from automatic changes, or
+		// if it was changed since the override
+		if instance.TagExcluded(key) || instance.Spec.Tags[key] != override.Value {
+			log.Info("dropping expired tag override of a changed tag", "tag", key)
+			continue
+		}
+		if override.PreviousValue != nil {
+			if instance.Spec.Tags == nil {
+				instance.Spec.Tags = make(map[string]string)
+			}
+			instance.Spec.Tags[key] = *override.PreviousValue
+		} else {
+			delete(instance.Spec.Tags, key)
+		}
+		events = append(events, describeRevertedTag(key, override))
+	}
+
+	if !changed {
+		return ctrl.Result{RequeueAfter: next}, nil
+	}
+	if len(instance.Spec.TagOverrides) == 0 {
+		instance.Spec.TagOverrides = nil
+	}
+	if err := r.Update(ctx, instance); err != nil {
+		log.Error(err, "unable to revert the expired tags")
+		return ctrl.Result{}, err
+	}
+	for _, event := range events {
+		log.Info(event)
+		r.Recorder.Event(instance, corev1.EventTypeNormal, ReasonTagReverted, event)
+	}
+
+	return ctrl.Result{RequeueAfter: next}, nil
+}
+
+// describeRevertedTag records who set the reverted tag and why
+func describeRevertedTag(key string, override registryv1.TagOverride) string {
+	previous := "removed"
+	if override.PreviousValue != nil {
+		previous = fmt.Sprintf("reverted to %q", *override.PreviousValue)
+	}
+	return fmt.Sprintf("Tag %s %s, it was set to %q by %s until %s: %s",
+		key, previous, override.Value, override.SetBy, override.ExpiresAt, override.Reason)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterTagReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("clustertags").
+		For(&registryv1.Cluster{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			cluster, ok := obj.(*registryv1.Cluster)
+			return ok && len(cluster.Spec.TagOverrides) > 0
+		}))).
+		Complete(r)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+func TestClusterTagReconciler(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test reverting the tag overrides of the clusters once they expire.")
+
+	expired := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	pending := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tcs := []struct {
+		name              string
+		annotations       map[string]string
+		tags              map[string]string
+		override          registryv1.TagOverride
+		expectedTags      map[string]string
+		expectedOverrides int
+		expectedRequeue   bool
+		expectedEvent     bool
+	}{
+		{
+			name:          "reverted tag",
+			tags:          map[string]string{"onboarding": "off"},
+			override:      registryv1.TagOverride{Value: "off", PreviousValue: ptr.To("on"), ExpiresAt: expired, SetBy: "user1", Reason: "incident"},
+			expectedTags:  map[string]string{"onboarding": "on"},
+			expectedEvent: true,
+		},
+		{
+			name:          "removed tag",
+			tags:          map[string]string{"onboarding": "off"},
+			override:      registryv1.TagOverride{Value: "off", ExpiresAt: expired},
+			expectedTags:  nil,
+			expectedEvent: true,
+		},
+		{
+			name:              "pending override",
+			tags:              map[string]string{"onboarding": "off"},
+			override:          registryv1.TagOverride{Value: "off", PreviousValue: ptr.To("on"), ExpiresAt: pending},
+			expectedTags:      map[string]string{"onboarding": "off"},
+			expectedOverrides: 1,
+			expectedRequeue:   true,
+		},
+		{
+			name:         "changed tag",
+			tags:         map[string]string{"onboarding": "on"},
+			override:     registryv1.TagOverride{Value: "off", PreviousValue: ptr.To("on"), ExpiresAt: expired},
+			expectedTags: map[string]string{"onboarding": "on"},
+		},
+		{
+			name:         "excluded tag",
+			annotations:  map[string]string{registryv1.ExcludedTagsAnnotation: "scaling,onboarding"},
+			tags:         map[string]string{"onboarding": "off"},
+			override:     registryv1.TagOverride{Value: "off", PreviousValue: ptr.To("on"), ExpiresAt: expired},
+			expectedTags: map[string]string{"onboarding": "off"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen reconciling a cluster with an onboarding tag override", tc.name)
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(registryv1.AddToScheme(s))
+
+		cluster := &registryv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry", Annotations: tc.annotations},
+			Spec: registryv1.ClusterSpec{
+				Tags:         tc.tags,
+				TagOverrides: map[string]registryv1.TagOverride{"onboarding": tc.override},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(cluster).Build()
+
+		recorder := record.NewFakeRecorder(10)
+		r := &ClusterTagReconciler{
+			Client:   c,
+			Log:      logr.Discard(),
+			Recorder: recorder,
+		}
+
+		result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(cluster)})
+		test.NoError(err)
+		test.Equal(tc.expectedRequeue, result.RequeueAfter > 0)
+
+		updated := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKeyFromObject(cluster), updated))
+		if tc.expectedTags == nil {
+			test.Empty(updated.Spec.Tags)
+		} else {
+			test.Equal(tc.expectedTags, updated.Spec.Tags)
+		}
+		test.Len(updated.Spec.TagOverrides, tc.expectedOverrides)
+		test.Equal(tc.expectedEvent, len(recorder.Events) > 0)
+		if tc.expectedEvent {
+			// the event records who set the tag and why
+			test.Contains(<-recorder.Events, tc.override.Reason)
+		}
+	}
+}
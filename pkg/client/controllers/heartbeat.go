@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+)
+
+// heartbeatBatchSize is the maximum number of messages of an SQS batch
+const heartbeatBatchSize = 10
+
+// Heartbeat periodically tells the API server that the clusters are still
+// alive, so that the ones whose client stopped reporting can be detected
+type Heartbeat struct {
+	Client   client.Client
+	Log      logr.Logger
+	Queue    sqs.Queue
+	Interval time.Duration
+}
+
+// Start sends the heartbeats until the context is cancelled
+func (h *Heartbeat) Start(ctx context.Context) error {
+	ticker := time.NewTicker(h.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := h.Beat(ctx); err != nil {
+			h.Log.Error(err, "error sending heartbeats")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader send the heartbeats
+func (h *Heartbeat) NeedLeaderElection() bool {
+	return true
+}
+
+// Beat enqueues a heartbeat for each of the clusters
+func (h *Heartbeat) Beat(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	clusters := new(registryv1.ClusterList)
+	if err := h.Client.List(ctx, clusters); err != nil {
+		return err
+	}
+	if len(clusters.Items) == 0 {
+		return nil
+	}
+
+	messages := make([]*sqs.Message, 0, len(clusters.Items))
+	for _, cluster := range clusters.Items {
+		messages = append(messages, &sqs.Message{
+			// SQS does not accept empty bodies
+			Body: "{}",
+			Attributes: map[string]string{
+				sqs.MessageAttributeType:                  sqs.ClusterHeartbeatEvent,
+				sqs.MessageAttributeClusterName:           cluster.Spec.Name,
+				sqs.MessageAttributeSkipCacheInvalidation: "true",
+				sqs.MessageAttributeRequestID:             uuid.New().String(),
+			},
+		})
+	}
+
+	for start := 0; start < len(messages); start += heartbeatBatchSize {
+		end := min(start+heartbeatBatchSize, len(messages))
+		if err := h.Queue.Enqueue(ctx, messages[start:end]); err != nil {
+			return err
+		}
+	}
+	h.Log.V(1).Info("Enqueued heartbeats", "clusters", len(messages))
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"github.com/adobe/cluster-registry/pkg/sqs"
+)
+
+type fakeQueue struct {
+	sqs.Queue
+	batches [][]*sqs.Message
+	err     error
+}
+
+func (q *fakeQueue) Enqueue(_ context.Context, msgs []*sqs.Message) error {
+	if q.err != nil {
+		return q.err
+	}
+	q.batches = append(q.batches, msgs)
+	return nil
+}
+
+func TestHeartbeat(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test sending a heartbeat for each cluster.")
+
+	s := runtime.NewScheme()
+	test.NoError(registryv1.AddToScheme(s))
+
+	tcs := []struct {
+		name            string
+		clusters        int
+		expectedBatches int
+	}{
+		{name: "no clusters", clusters: 0, expectedBatches: 0},
+		{name: "single cluster", clusters: 1, expectedBatches: 1},
+		{name: "more clusters than a batch", clusters: 25, expectedBatches: 3},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen sending the heartbeats of %d clusters", tc.name, tc.clusters)
+
+		builder := fake.NewClientBuilder().WithScheme(s)
+		for i := 0; i < tc.clusters; i++ {
+			builder = builder.WithObjects(&registryv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("cluster%d", i), Namespace: "cluster-registry"},
+				Spec:       registryv1.ClusterSpec{Name: fmt.Sprintf("cluster%d", i)},
+			})
+		}
+
+		q := &fakeQueue{}
+		h := &Heartbeat{Client: builder.Build(), Log: logr.Discard(), Queue: q}
+		test.NoError(h.Beat(context.Background()))
+
+		test.Len(q.batches, tc.expectedBatches)
+		received := map[string]bool{}
+		for _, batch := range q.batches {
+			test.LessOrEqual(len(batch), heartbeatBatchSize)
+			for _, msg := range batch {
+				test.Equal(sqs.ClusterHeartbeatEvent, msg.Attributes[sqs.MessageAttributeType])
+				test.Equal("true", msg.Attributes[sqs.MessageAttributeSkipCacheInvalidation])
+				test.NotEmpty(msg.Body)
+				received[msg.Attributes[sqs.MessageAttributeClusterName]] = true
+			}
+		}
+		test.Len(received, tc.clusters)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+func TestServiceMetadataWatcherCleanup(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test removing the service metadata of deleted watchers, objects and fields.")
+
+	configMap := registryv1alpha1.ObjectReference{Name: "app", APIVersion: "v1", Kind: "ConfigMap"}
+
+	tcs := []struct {
+		name            string
+		fields          []registryv1alpha1.WatchedField
+		deleteConfigMap bool
+		deleteWatcher   bool
+		expectedValues  registryv1.ServiceMetadataMap
+	}{
+		{
+			name:           "synced fields",
+			fields:         []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			expectedValues: registryv1.ServiceMetadataMap{"tier": "gold", "manual": "value"},
+		},
+		{
+			name:           "dropped field",
+			fields:         []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "level"}},
+			expectedValues: registryv1.ServiceMetadataMap{"level": "gold", "manual": "value"},
+		},
+		{
+			name:           "deleted watcher",
+			fields:         []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			deleteWatcher:  true,
+			expectedValues: registryv1.ServiceMetadataMap{"manual": "value"},
+		},
+		{
+			name:            "deleted object, remove policy",
+			fields:          []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			deleteConfigMap: true,
+			expectedValues:  registryv1.ServiceMetadataMap{"manual": "value"},
+		},
+		{
+			name: "deleted object, keep policy",
+			fields: []registryv1alpha1.WatchedField{
+				{Source: "data.tier", Destination: "tier", OnDelete: registryv1alpha1.OnDeleteKeep},
+			},
+			deleteConfigMap: true,
+			expectedValues:  registryv1.ServiceMetadataMap{"tier": "gold", "manual": "value"},
+		},
+		{
+			name: "deleted object, default policy",
+			fields: []registryv1alpha1.WatchedField{
+				{Source: "data.tier", Destination: "tier", OnDelete: registryv1alpha1.OnDeleteDefault, Default: "bronze"},
+			},
+			deleteConfigMap: true,
+			expectedValues:  registryv1.ServiceMetadataMap{"tier": "bronze", "manual": "value"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen reconciling a watcher which synced the tier field", tc.name)
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(corev1.AddToScheme(s))
+		test.NoError(registryv1.AddToScheme(s))
+		test.NoError(registryv1alpha1.AddToScheme(s))
+
+		// the watcher already synced the tier field, next to a field which
+		// was set by someone else
+		watcher := &registryv1alpha1.ServiceMetadataWatcher{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "watcher1",
+				Namespace:  "app",
+				Generation: 2,
+				Finalizers: []string{ServiceMetadataFinalizer},
+			},
+			Spec: registryv1alpha1.ServiceMetadataWatcherSpec{
+				WatchedServiceObjects: []registryv1alpha1.WatchedServiceObject{{
+					ObjectReference: configMap,
+					WatchedFields:   tc.fields,
+				}},
+			},
+			Status: registryv1alpha1.ServiceMetadataWatcherStatus{
+				ServiceID: "12345",
+				WatchedServiceObjects: []registryv1alpha1.WatchedServiceObjectStatus{{
+					ObjectReference: configMap,
+					Values:          map[string]string{"tier": "gold"},
+				}},
+			},
+		}
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app"}, Data: map[string]string{"tier": "gold"}}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Annotations: map[string]string{"adobe.serviceid": "12345"}}},
+				cm,
+				&registryv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"},
+					Spec: registryv1.ClusterSpec{
+						ServiceMetadata: registryv1.ServiceMetadata{
+							"12345": {"app": {"tier": "gold", "manual": "value"}},
+						},
+					},
+				},
+				watcher,
+			).
+			WithStatusSubresource(watcher).
+			Build()
+
+		if tc.deleteConfigMap {
+			test.NoError(c.Delete(ctx, cm))
+		}
+		if tc.deleteWatcher {
+			test.NoError(c.Delete(ctx, watcher))
+		}
+
+		r := &ServiceMetadataWatcherReconciler{
+			Client:              c,
+			Log:                 logr.Discard(),
+			Scheme:              s,
+			WatchedGVKs:         []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}},
+			ServiceIdAnnotation: "adobe.serviceid",
+			Namespace:           "cluster-registry",
+			Recorder:            record.NewFakeRecorder(10),
+		}
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(watcher)})
+		test.NoError(err)
+
+		cluster := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKey{Name: "cluster1", Namespace: "cluster-registry"}, cluster))
+		test.Equal(tc.expectedValues, cluster.Spec.ServiceMetadata["12345"]["app"])
+
+		updated := new(registryv1alpha1.ServiceMetadataWatcher)
+		err = c.Get(ctx, client.ObjectKeyFromObject(watcher), updated)
+		if tc.deleteWatcher {
+			// the watcher is gone once its finalizer is removed
+			test.True(apierrors.IsNotFound(err))
+			continue
+		}
+		test.NoError(err)
+		test.Contains(updated.Finalizers, ServiceMetadataFinalizer)
+		test.Equal("12345", updated.Status.ServiceID)
+	}
+}
+
+func TestApplyServiceMetadataPatches(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test applying the patches of the service metadata with a single patch per cluster.")
+
+	tcs := []struct {
+		name          string
+		failingPatch  bool
+		expectedError bool
+	}{
+		{
+			name: "patched clusters",
+		},
+		{
+			name:          "failing patch",
+			failingPatch:  true,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen applying three patches to two clusters", tc.name)
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(registryv1.AddToScheme(s))
+
+		cluster := func(name, namespace string) *registryv1.Cluster {
+			return &registryv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: registryv1.ClusterSpec{
+					ServiceMetadata: registryv1.ServiceMetadata{"12345": {"app": {"stale": "value"}}},
+				},
+			}
+		}
+
+		patched := map[string]int{}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(
+				cluster("cluster1", "registry"),
+				cluster("cluster2", "registry"),
+				cluster("cluster3", "other"),
+			).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					patched[obj.GetName()]++
+					if tc.failingPatch && obj.GetName() == "cluster1" {
+						return errors.New("conflict")
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).
+			Build()
+
+		r := &ServiceMetadataWatcherReconciler{
+			Client:    c,
+			Log:       logr.Discard(),
+			Namespace: "registry",
+		}
+
+		var patches [][]byte
+		for _, field := range []string{"tier", "owner"} {
+			patch, err := createServiceMetadataPatch("12345", "app", field, "value")
+			test.NoError(err)
+			patches = append(patches, patch)
+		}
+		patch, err := createServiceMetadataRemovalPatch("12345", "app", []string{"stale"})
+		test.NoError(err)
+		patches = append(patches, patch)
+
+		err = r.applyServiceMetadataPatches(ctx, patches)
+		test.Equal(tc.expectedError, err != nil)
+		test.Equal(map[string]int{"cluster1": 1, "cluster2": 1}, patched)
+
+		updated := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKey{Name: "cluster2", Namespace: "registry"}, updated))
+		test.Equal(registryv1.ServiceMetadataMap{"tier": "value", "owner": "value"}, updated.Spec.ServiceMetadata["12345"]["app"])
+	}
+}
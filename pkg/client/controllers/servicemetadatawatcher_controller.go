@@ -14,9 +14,13 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -26,13 +30,19 @@ import (
 	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	crevent "sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -42,6 +52,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ServiceMetadataFinalizer lets the watchers remove their fields from the
+// service metadata before they are deleted
+const ServiceMetadataFinalizer = "registry.ethos.adobe.com/service-metadata"
+
 // ServiceMetadataWatcherReconciler reconciles a ServiceMetadataWatcher object
 type ServiceMetadataWatcherReconciler struct {
 	client.Client
@@ -49,11 +63,20 @@ type ServiceMetadataWatcherReconciler struct {
 	Scheme              *runtime.Scheme
 	WatchedGVKs         []schema.GroupVersionKind
 	ServiceIdAnnotation string
+	Recorder            record.EventRecorder
+
+	// Namespace of the Cluster objects
+	Namespace string
+
+	// Debounce delays the sync of the watchers after an update of their
+	// watched objects, so that a burst of updates is synced once
+	Debounce time.Duration
 }
 
 //+kubebuilder:rbac:groups=registry.ethos.adobe.com,resources=servicemetadatawatchers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=registry.ethos.adobe.com,resources=servicemetadatawatchers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=registry.ethos.adobe.com,resources=servicemetadatawatchers/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -73,104 +96,482 @@ func (r *ServiceMetadataWatcherReconciler) Reconcile(ctx context.Context, req ct
 	}
 
 	if instance.ObjectMeta.DeletionTimestamp != nil {
-		return noRequeue()
+		return r.reconcileDelete(ctx, log, instance)
 	}
 
+	if controllerutil.AddFinalizer(instance, ServiceMetadataFinalizer) {
+		if err := r.Update(ctx, instance); err != nil {
+			log.Error(err, "cannot add finalizer")
+			return requeueIfError(err)
+		}
+	}
+
+	key := client.ObjectKeyFromObject(instance)
+	status := instance.Status.DeepCopy()
+
 	if len(instance.Spec.WatchedServiceObjects) == 0 {
 		log.Info("no watched objects")
-		// TODO: probably update status with error
-		return noRequeue()
+		return r.fail(ctx, instance, status, ReasonNoWatchedObjects, "No watched objects")
 	}
 
 	serviceId, err := r.getServiceIdFromNamespaceAnnotation(ctx, instance.GetNamespace())
 	if err != nil {
 		log.Error(err, "cannot get serviceId from namespace annotation", "namespace", instance.GetNamespace())
-		return noRequeue()
+		return r.fail(ctx, instance, status, ReasonMissingServiceID, err.Error())
 	}
 
 	var patches [][]byte
+	var syncErr error
+	statuses := make([]registryv1alpha1.WatchedServiceObjectStatus, 0, len(instance.Spec.WatchedServiceObjects))
 
 	for _, wso := range instance.Spec.WatchedServiceObjects {
-		gv, err := schema.ParseGroupVersion(wso.ObjectReference.APIVersion)
+		previous := watchedObjectStatus(instance.Status, wso.ObjectReference, wso.LabelSelector)
+		wsoStatus, wsoPatches, err := r.syncObject(ctx, log, instance.Namespace, serviceId, wso, previous)
 		if err != nil {
-			log.Error(err, "cannot parse APIVersion", "apiVersion", wso.ObjectReference.APIVersion)
-			// TODO: update status with error
+			syncErr = err
+		}
+		if previous != nil {
+			wsoStatus.LastUpdated = previous.LastUpdated
+		}
+		statuses = append(statuses, wsoStatus)
+		patches = append(patches, wsoPatches...)
+	}
+	status.WatchedServiceObjects = statuses
+	status.ServiceID = serviceId
+
+	// remove the fields which are no longer watched, or which were synced
+	// under another service ID
+	removed, err := staleServiceMetadataPatches(instance, serviceId, statuses)
+	if err != nil {
+		log.Error(err, "cannot create patch")
+		return requeueIfError(err)
+	}
+	patches = append(patches, removed...)
+
+	if len(patches) > 0 {
+		if err := r.applyServiceMetadataPatches(ctx, patches); err != nil {
+			log.Error(err, "cannot update cluster service metadata")
+			if _, statusErr := r.fail(ctx, instance, status, ReasonPatchFailed, err.Error()); statusErr != nil {
+				log.Error(statusErr, "cannot update status")
+			}
 			return requeueIfError(err)
 		}
+	}
+
+	now := metav1.Now()
+	for i, wso := range status.WatchedServiceObjects {
+		if len(wso.Errors) > 0 {
+			r.Recorder.Event(instance, corev1.EventTypeWarning, ReasonSyncFailed,
+				fmt.Sprintf("%s: %s", describeWatchedObject(wso), strings.Join(wso.Errors, "; ")))
+			continue
+		}
+		status.WatchedServiceObjects[i].LastUpdated = &now
+
+		previous := watchedObjectStatus(instance.Status, wso.ObjectReference, wso.LabelSelector)
+		if previous == nil || !reflect.DeepEqual(previous.Values, wso.Values) {
+			r.Recorder.Event(instance, corev1.EventTypeNormal, ReasonSynced,
+				fmt.Sprintf("%s: synced %d watched fields", describeWatchedObject(wso), len(wso.Values)))
+		}
+	}
+	setWatcherSynced(status, instance.Generation)
+
+	if err := updateWatcherStatus(ctx, r.Client, key, status); err != nil {
+		log.Error(err, "cannot update status")
+		return requeueIfError(err)
+	}
+
+	// the transient errors are retried, the others wait for the watched
+	// objects or the watcher to change
+	return requeueIfError(syncErr)
+}
+
+// syncObject resolves the watched fields of the object, or of the objects
+// selected by the label selector, and returns their status and the patches of
+// the service metadata. The errors which depend on the watcher or the objects
+// are only reported in the status, the transient ones are also returned. The
+// fields which cannot be resolved keep their previous value, which is still in
+// the service metadata.
+func (r *ServiceMetadataWatcherReconciler) syncObject(ctx context.Context, log logr.Logger, namespace, serviceId string, wso registryv1alpha1.WatchedServiceObject, previous *registryv1alpha1.WatchedServiceObjectStatus) (registryv1alpha1.WatchedServiceObjectStatus, [][]byte, error) {
+	status := registryv1alpha1.WatchedServiceObjectStatus{
+		ObjectReference: wso.ObjectReference,
+		LabelSelector:   wso.LabelSelector,
+	}
+	status.Values, status.Objects = previousValues(previous, wso, namespace)
+	fail := func(err error) {
+		status.Errors = append(status.Errors, err.Error())
+	}
+
+	gv, err := schema.ParseGroupVersion(wso.ObjectReference.APIVersion)
+	if err != nil {
+		log.Error(err, "cannot parse APIVersion", "apiVersion", wso.ObjectReference.APIVersion)
+		fail(fmt.Errorf("cannot parse apiVersion %q: %w", wso.ObjectReference.APIVersion, err))
+		return status, nil, nil
+	}
+
+	gvk := schema.GroupVersionKind{
+		Group:   gv.Group,
+		Version: gv.Version,
+		Kind:    wso.ObjectReference.Kind,
+	}
+
+	// check if gvk is allowed
+	if !r.isAllowedGVK(gvk) {
+		log.Info("watched object GVK is not allowed, skipping", "gvk", gvk.String())
+		fail(fmt.Errorf("%s is not a watched kind", gvk.String()))
+		return status, nil, nil
+	}
+
+	if len(wso.WatchedFields) == 0 {
+		fail(fmt.Errorf("no watched fields"))
+		return status, nil, nil
+	}
+
+	var selector labels.Selector
+	if wso.LabelSelector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(wso.LabelSelector)
+		if err != nil {
+			log.Error(err, "cannot parse label selector")
+			fail(fmt.Errorf("cannot parse labelSelector: %w", err))
+			return status, nil, nil
+		}
+	}
+
+	objects, err := r.getWatchedObjects(ctx, namespace, gvk, wso.ObjectReference.Name, selector)
+	if err != nil {
+		log.Error(err, "cannot get object",
+			"name", wso.ObjectReference.Name,
+			"namespace", namespace,
+			"gvk", gvk.String())
+		fail(fmt.Errorf("cannot get object: %w", err))
+		return status, nil, err
+	}
+
+	// values of the watched fields, by destination, and the objects which
+	// set them, ordered by name so that the aggregation is deterministic
+	values := map[string]string{}
+	owners := map[string]string{}
+	var names []string
+
+	for i := range objects {
+		obj := &objects[i]
+		names = append(names, obj.GetName())
+
+		resolved, errs := resolveFields(log, obj, wso.WatchedFields)
+		for _, err := range errs {
+			if selector != nil {
+				err = fmt.Errorf("%s: %w", obj.GetName(), err)
+			}
+			fail(err)
+		}
+
+		for _, dst := range slices.Sorted(maps.Keys(resolved)) {
+			if owner, ok := owners[dst]; ok {
+				switch wso.Aggregation {
+				case registryv1alpha1.AggregationFirst:
+					continue
+				case registryv1alpha1.AggregationLast:
+				default:
+					fail(fmt.Errorf("%s: destination %q is already set by %s", obj.GetName(), dst, owner))
+					continue
+				}
+			}
+			owners[dst] = obj.GetName()
+			values[dst] = resolved[dst]
+		}
+	}
+
+	// the objects which are gone follow the deletion policies of the fields
+	var deleted []string
+	if selector == nil {
+		if len(objects) == 0 {
+			deleted = []string{wso.ObjectReference.Name}
+		}
+	} else if previous != nil {
+		for _, name := range previous.Objects {
+			if !slices.Contains(names, name) {
+				deleted = append(deleted, name)
+			}
+		}
+	}
+	for _, name := range deleted {
+		log.Info("watched object not found, applying the deletion policies",
+			"name", name,
+			"namespace", namespace,
+			"gvk", gvk.String())
+		if applyDeletionPolicies(name, namespace, wso.WatchedFields, &status, values, owners) {
+			names = append(names, name)
+		}
+	}
 
-		gvk := schema.GroupVersionKind{
-			Group:   gv.Group,
-			Version: gv.Version,
-			Kind:    wso.ObjectReference.Kind,
+	var patches [][]byte
+	for _, dst := range slices.Sorted(maps.Keys(values)) {
+		patch, err := createServiceMetadataPatch(serviceId, namespace, dst, values[dst])
+		if err != nil {
+			log.Error(err, "cannot create patch")
+			fail(fmt.Errorf("cannot create patch of %q: %w", dst, err))
+			continue
 		}
+		patches = append(patches, patch)
 
-		// check if gvk is allowed
-		if !r.isAllowedGVK(gvk) {
-			log.Info("watched object GVK is not allowed, skipping", "gvk", gvk.String())
-			// TODO: update status with error
-			return noRequeue()
+		if status.Values == nil {
+			status.Values = map[string]string{}
 		}
+		status.Values[dst] = values[dst]
+	}
+
+	if selector != nil {
+		slices.Sort(names)
+		status.Objects = names
+	}
+	if len(status.Values) == 0 {
+		status.Values = nil
+	}
+	return status, patches, nil
+}
 
+// getWatchedObjects returns the watched object, if it exists, or the objects
+// selected by the label selector, ordered by name
+func (r *ServiceMetadataWatcherReconciler) getWatchedObjects(ctx context.Context, namespace string, gvk schema.GroupVersionKind, name string, selector labels.Selector) ([]unstructured.Unstructured, error) {
+	if selector == nil {
 		obj := new(unstructured.Unstructured)
 		obj.SetGroupVersionKind(gvk)
-
-		if err := r.Client.Get(ctx, types.NamespacedName{
-			Namespace: instance.Namespace,
-			Name:      wso.ObjectReference.Name,
-		}, obj); err != nil {
-			log.Error(err, "cannot get object",
-				"name", wso.ObjectReference.Name,
-				"namespace", instance.Namespace,
-				"gvk", obj.GroupVersionKind().String())
-			return requeueIfError(err)
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, obj); err != nil {
+			return nil, client.IgnoreNotFound(err)
 		}
+		return []unstructured.Unstructured{*obj}, nil
+	}
+
+	list := new(unstructured.UnstructuredList)
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.Client.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].GetName() < list.Items[j].GetName()
+	})
+	return list.Items, nil
+}
+
+// resolveFields returns the values of the watched fields of the object, by
+// destination, and the errors of the fields which cannot be resolved
+func resolveFields(log logr.Logger, obj *unstructured.Unstructured, fields []registryv1alpha1.WatchedField) (map[string]string, []error) {
+	values := map[string]string{}
+	var errs []error
 
-		if len(wso.WatchedFields) == 0 {
-			// TODO: update status with error
-			return noRequeue()
+	for _, field := range fields {
+		dst, err := registryv1alpha1.RenderDestination(field.Destination, obj.GetName(), obj.GetNamespace())
+		if err != nil {
+			log.Error(err, "cannot render destination", "dst", field.Destination)
+			errs = append(errs, fmt.Errorf("cannot render destination %q: %w", field.Destination, err))
+			continue
 		}
 
-		for _, field := range wso.WatchedFields {
-			// TODO: validate field Source & Destination somewhere
+		var value string
+		var found bool
+		source := field.Source
+
+		if field.Expression != "" {
+			source = field.Expression
+			jp, err := registryv1alpha1.ParseExpression(field.Expression)
+			if err != nil {
+				log.Error(err, "cannot compile expression", "expression", field.Expression)
+				errs = append(errs, fmt.Errorf("cannot compile expression %q: %w", field.Expression, err))
+				continue
+			}
 
+			value, found, err = getExpressionString(obj.Object, jp)
+			if err != nil {
+				log.Error(err, "cannot evaluate expression", "expression", field.Expression)
+				errs = append(errs, fmt.Errorf("cannot evaluate expression %q: %w", field.Expression, err))
+				continue
+			}
+		} else {
 			path, err := parsePath(field.Source)
 			if err != nil {
-				// TODO: update status with error
 				log.Error(err, "cannot parse path", "field", field.Source)
+				errs = append(errs, fmt.Errorf("cannot parse path %q: %w", field.Source, err))
 				continue
 			}
 
-			value, found, err := getNestedString(obj.Object, path)
+			value, found, err = getNestedString(obj.Object, path)
 			if err != nil {
-				// TODO: update status with error
 				log.Error(err, "cannot get field", "field", field.Source)
+				errs = append(errs, fmt.Errorf("cannot get field %q: %w", field.Source, err))
 				continue
 			}
+		}
 
-			if !found {
-				log.Error(err, "field not found", "field", field.Source)
-				// TODO: update status with error
-				continue
-			}
+		if !found {
+			log.Info("field not found", "field", source)
+			errs = append(errs, fmt.Errorf("field %q not found", source))
+			continue
+		}
+
+		values[dst] = value
+	}
+
+	return values, errs
+}
 
-			patch, err := createServiceMetadataPatch(serviceId, instance.Namespace, field.Destination, value)
+// previousValues returns the previous values of the watched fields, which are
+// kept while the fields cannot be resolved, and the previous selected objects
+func previousValues(previous *registryv1alpha1.WatchedServiceObjectStatus, wso registryv1alpha1.WatchedServiceObject, namespace string) (map[string]string, []string) {
+	if previous == nil {
+		return nil, nil
+	}
+
+	names := previous.Objects
+	if wso.LabelSelector == nil {
+		names = []string{wso.ObjectReference.Name}
+	}
+
+	var values map[string]string
+	for _, name := range names {
+		for _, field := range wso.WatchedFields {
+			dst, err := registryv1alpha1.RenderDestination(field.Destination, name, namespace)
 			if err != nil {
-				log.Error(err, "cannot create patch")
 				continue
 			}
-			patches = append(patches, patch)
+			if value, ok := previous.Values[dst]; ok {
+				if values == nil {
+					values = map[string]string{}
+				}
+				values[dst] = value
+			}
 		}
 	}
+	return values, slices.Clone(previous.Objects)
+}
 
-	if len(patches) == 0 {
+// applyDeletionPolicies removes, keeps or sets to their default value the
+// fields of a deleted object, unless another object set them, and returns
+// whether the object still has fields in the service metadata
+func applyDeletionPolicies(name, namespace string, fields []registryv1alpha1.WatchedField, status *registryv1alpha1.WatchedServiceObjectStatus, values, owners map[string]string) bool {
+	kept := false
+	for _, field := range fields {
+		dst, err := registryv1alpha1.RenderDestination(field.Destination, name, namespace)
+		if err != nil {
+			continue
+		}
+		if _, ok := owners[dst]; ok {
+			continue
+		}
+
+		switch field.OnDelete {
+		case registryv1alpha1.OnDeleteKeep:
+			// the previous value is still in the status
+			if _, ok := status.Values[dst]; ok {
+				kept = true
+			}
+		case registryv1alpha1.OnDeleteDefault:
+			values[dst] = field.Default
+			owners[dst] = name
+			kept = true
+		default:
+			// the removed fields are garbage collected with the stale ones
+			delete(status.Values, dst)
+		}
+	}
+	return kept
+}
+
+// reconcileDelete removes the fields synced by the watcher from the service
+// metadata, before letting the watcher go
+func (r *ServiceMetadataWatcherReconciler) reconcileDelete(ctx context.Context, log logr.Logger, instance *registryv1alpha1.ServiceMetadataWatcher) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(instance, ServiceMetadataFinalizer) {
 		return noRequeue()
 	}
 
-	if err := r.applyServiceMetadataPatches(ctx, patches); err != nil {
-		log.Error(err, "cannot update cluster service metadata")
+	serviceId := instance.Status.ServiceID
+	if serviceId == "" {
+		// the watcher was never synced, but its fields may have been
+		serviceId, _ = r.getServiceIdFromNamespaceAnnotation(ctx, instance.GetNamespace())
+	}
+
+	if serviceId != "" {
+		destinations := map[string]bool{}
+		for _, wso := range instance.Status.WatchedServiceObjects {
+			for dst := range wso.Values {
+				destinations[dst] = true
+			}
+		}
+		for _, wso := range instance.Spec.WatchedServiceObjects {
+			if wso.LabelSelector != nil {
+				continue
+			}
+			for _, field := range wso.WatchedFields {
+				if dst, err := registryv1alpha1.RenderDestination(field.Destination, wso.ObjectReference.Name, instance.Namespace); err == nil {
+					destinations[dst] = true
+				}
+			}
+		}
+
+		if len(destinations) > 0 {
+			patch, err := createServiceMetadataRemovalPatch(serviceId, instance.Namespace, slices.Sorted(maps.Keys(destinations)))
+			if err != nil {
+				log.Error(err, "cannot create patch")
+				return requeueIfError(err)
+			}
+			if err := r.applyServiceMetadataPatches(ctx, [][]byte{patch}); err != nil {
+				log.Error(err, "cannot remove cluster service metadata")
+				return requeueIfError(err)
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(instance, ServiceMetadataFinalizer)
+	if err := r.Update(ctx, instance); err != nil {
+		log.Error(err, "cannot remove finalizer")
 		return requeueIfError(err)
 	}
+	log.Info("removed the service metadata of the watcher")
+	return noRequeue()
+}
+
+// staleServiceMetadataPatches returns the patches removing the fields which
+// were synced by the watcher but are no longer in its status, or which were
+// synced under a previous service ID
+func staleServiceMetadataPatches(instance *registryv1alpha1.ServiceMetadataWatcher, serviceId string, statuses []registryv1alpha1.WatchedServiceObjectStatus) ([][]byte, error) {
+	previousServiceId := instance.Status.ServiceID
+	if previousServiceId == "" {
+		previousServiceId = serviceId
+	}
+
+	current := map[string]bool{}
+	if previousServiceId == serviceId {
+		for _, wso := range statuses {
+			for dst := range wso.Values {
+				current[dst] = true
+			}
+		}
+	}
+
+	stale := map[string]bool{}
+	for _, wso := range instance.Status.WatchedServiceObjects {
+		for dst := range wso.Values {
+			if !current[dst] {
+				stale[dst] = true
+			}
+		}
+	}
+	if len(stale) == 0 {
+		return nil, nil
+	}
+
+	patch, err := createServiceMetadataRemovalPatch(previousServiceId, instance.Namespace, slices.Sorted(maps.Keys(stale)))
+	if err != nil {
+		return nil, err
+	}
+	return [][]byte{patch}, nil
+}
 
+// fail reports a watcher which could not be synced at all
+func (r *ServiceMetadataWatcherReconciler) fail(ctx context.Context, instance *registryv1alpha1.ServiceMetadataWatcher, status *registryv1alpha1.ServiceMetadataWatcherStatus, reason, message string) (ctrl.Result, error) {
+	r.Recorder.Event(instance, corev1.EventTypeWarning, reason, message)
+	setWatcherFailed(status, instance.Generation, reason, message)
+	if err := updateWatcherStatus(ctx, r.Client, client.ObjectKeyFromObject(instance), status); err != nil {
+		return requeueIfError(err)
+	}
 	return noRequeue()
 }
 
@@ -186,11 +587,12 @@ func parsePath(path string) ([]string, error) {
 // SetupWithManager sets up the controller with the Manager.
 func (r *ServiceMetadataWatcherReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager) error {
 	options := controller.Options{MaxConcurrentReconciles: 10}
-	b := ctrl.NewControllerManagedBy(mgr).For(&registryv1alpha1.ServiceMetadataWatcher{}, builder.WithPredicates(r.eventFilters()))
+	// the status updates do not change the generation, so they are ignored
+	b := ctrl.NewControllerManagedBy(mgr).For(&registryv1alpha1.ServiceMetadataWatcher{}, builder.WithPredicates(r.eventFilters(), predicate.GenerationChangedPredicate{}))
 	for _, gvk := range r.WatchedGVKs {
 		obj := new(unstructured.Unstructured)
 		obj.SetGroupVersionKind(gvk)
-		b.Watches(obj, handler.EnqueueRequestsFromMapFunc(r.enqueueRequestsFromMapFunc(gvk)), builder.WithPredicates(predicate.Funcs{
+		b.Watches(obj, &debouncedHandler{mapFunc: r.enqueueRequestsFromMapFunc(gvk), delay: r.Debounce}, builder.WithPredicates(predicate.Funcs{
 			CreateFunc: func(e crevent.CreateEvent) bool {
 				return true
 			},
@@ -198,8 +600,8 @@ func (r *ServiceMetadataWatcherReconciler) SetupWithManager(ctx context.Context,
 				return true
 			},
 			DeleteFunc: func(e crevent.DeleteEvent) bool {
-				// not interested in ServiceObject delete events (for now)
-				return false
+				// the deletion policies of the watched fields are applied
+				return true
 			},
 		}))
 	}
@@ -207,6 +609,49 @@ func (r *ServiceMetadataWatcherReconciler) SetupWithManager(ctx context.Context,
 	return err
 }
 
+// debouncedHandler enqueues the watchers of the watched objects after a delay.
+// The requests which are already waiting are not enqueued again, so that the
+// updates of a rollout within the delay result in a single sync.
+type debouncedHandler struct {
+	mapFunc handler.MapFunc
+	delay   time.Duration
+}
+
+var _ handler.EventHandler = &debouncedHandler{}
+
+// Create enqueues the watchers of the created object
+func (h *debouncedHandler) Create(ctx context.Context, e crevent.CreateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.enqueue(ctx, q, e.Object)
+}
+
+// Update enqueues the watchers of both the old and the new object, whose
+// labels may differ
+func (h *debouncedHandler) Update(ctx context.Context, e crevent.UpdateEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.enqueue(ctx, q, e.ObjectOld, e.ObjectNew)
+}
+
+// Delete enqueues the watchers of the deleted object
+func (h *debouncedHandler) Delete(ctx context.Context, e crevent.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.enqueue(ctx, q, e.Object)
+}
+
+// Generic enqueues the watchers of the object
+func (h *debouncedHandler) Generic(ctx context.Context, e crevent.GenericEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	h.enqueue(ctx, q, e.Object)
+}
+
+func (h *debouncedHandler) enqueue(ctx context.Context, q workqueue.TypedRateLimitingInterface[reconcile.Request], objs ...client.Object) {
+	requests := map[reconcile.Request]bool{}
+	for _, obj := range objs {
+		for _, req := range h.mapFunc(ctx, obj) {
+			if !requests[req] {
+				requests[req] = true
+				q.AddAfter(req, h.delay)
+			}
+		}
+	}
+}
+
 func (r *ServiceMetadataWatcherReconciler) isAllowedGVK(gvk schema.GroupVersionKind) bool {
 	for _, watchedGVK := range r.WatchedGVKs {
 		if gvk.String() == watchedGVK.String() {
@@ -216,23 +661,36 @@ func (r *ServiceMetadataWatcherReconciler) isAllowedGVK(gvk schema.GroupVersionK
 	return false
 }
 
+// applyServiceMetadataPatches merges the patches of the service metadata and
+// applies them with a single patch per cluster, so that each cluster is
+// enqueued once
 func (r *ServiceMetadataWatcherReconciler) applyServiceMetadataPatches(ctx context.Context, patches [][]byte) error {
+	if len(patches) == 0 {
+		return nil
+	}
+	patch := patches[0]
+	for _, p := range patches[1:] {
+		var err error
+		if patch, err = jsonpatch.MergeMergePatches(patch, p); err != nil {
+			return fmt.Errorf("cannot merge patches: %w", err)
+		}
+	}
+
 	clusterList := &registryv1.ClusterList{}
-	// TODO: get namespace from config
-	if err := r.Client.List(ctx, clusterList, &client.ListOptions{Namespace: "cluster-registry"}); err != nil {
+	if err := r.Client.List(ctx, clusterList, client.InNamespace(r.Namespace)); err != nil {
 		return err
 	}
 
+	var errs []error
 	for i := range clusterList.Items {
-		for _, patch := range patches {
-			// TODO: find a better way to do this (i.e. group patches)
-			if err := r.Client.Patch(ctx, &clusterList.Items[i], client.RawPatch(types.MergePatchType, patch)); err != nil {
-				r.Log.Info("cannot patch cluster", "name", clusterList.Items[i].Name, "namespace", clusterList.Items[i].Namespace, "error", err)
-			}
+		cluster := &clusterList.Items[i]
+		if err := r.Client.Patch(ctx, cluster, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			r.Log.Error(err, "cannot patch cluster", "name", cluster.Name, "namespace", cluster.Namespace)
+			errs = append(errs, fmt.Errorf("cannot patch cluster %s: %w", cluster.Name, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func (r *ServiceMetadataWatcherReconciler) eventFilters() predicate.Predicate {
@@ -276,7 +734,7 @@ func (r *ServiceMetadataWatcherReconciler) enqueueRequestsFromMapFunc(gvk schema
 				if err != nil || gv != gvk.GroupVersion() || wso.ObjectReference.Kind != gvk.Kind {
 					continue
 				}
-				if smw.Namespace == obj.GetNamespace() && wso.ObjectReference.Name == obj.GetName() {
+				if smw.Namespace == obj.GetNamespace() && watchesObject(wso, obj) {
 					r.Log.Info("Watched object was updated, enqueueing watcher reconcile request",
 						"name", obj.GetName(),
 						"namespace", smw.Namespace,
@@ -296,6 +754,20 @@ func (r *ServiceMetadataWatcherReconciler) enqueueRequestsFromMapFunc(gvk schema
 	}
 }
 
+// watchesObject returns whether the object is the watched object, or is selected
+// by the label selector. The updates of the labels enqueue the watcher through
+// both the old and the new object, so that it notices the unselected objects.
+func watchesObject(wso registryv1alpha1.WatchedServiceObject, obj client.Object) bool {
+	if wso.LabelSelector == nil {
+		return wso.ObjectReference.Name == obj.GetName()
+	}
+	selector, err := metav1.LabelSelectorAsSelector(wso.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(obj.GetLabels()))
+}
+
 func (r *ServiceMetadataWatcherReconciler) getServiceIdFromNamespaceAnnotation(ctx context.Context, namespace string) (string, error) {
 	ns := &corev1.Namespace{}
 	if err := r.Client.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
@@ -335,6 +807,68 @@ func createServiceMetadataPatch(serviceId string, namespace string, field string
 	return jsonpatch.CreateMergePatch(oldClusterJSON, newClusterJSON)
 }
 
+// createServiceMetadataRemovalPatch returns the merge patch removing the
+// fields from the service metadata, by setting them to null
+func createServiceMetadataRemovalPatch(serviceId string, namespace string, fields []string) ([]byte, error) {
+	removed := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		removed[field] = nil
+	}
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"services": map[string]interface{}{
+				serviceId: map[string]interface{}{
+					namespace: removed,
+				},
+			},
+		},
+	})
+}
+
+// getExpressionString evaluates the JSONPath expression against the object,
+// and returns its scalar results converted to strings and joined with commas
+func getExpressionString(object map[string]interface{}, jp *jsonpath.JSONPath) (string, bool, error) {
+	results, err := jp.FindResults(object)
+	if err != nil {
+		return "", false, err
+	}
+
+	var values []string
+	for _, result := range results {
+		for _, v := range result {
+			value, err := scalarString(v.Interface())
+			if err != nil {
+				return "", false, err
+			}
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return "", false, nil
+	}
+	return strings.Join(values, ","), true, nil
+}
+
+// scalarString converts the scalar values of an unstructured object to strings
+func scalarString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("cannot convert %T to string, the expression must select scalar values", value)
+	}
+}
+
 // getNestedString returns the value of a nested field in the provided object
 // path is a list of strings separated by dots, e.g. "spec.template.spec.containers[0].image"
 // If the field is a slice, the last string must be in the form of "field[index]", where index is an integer
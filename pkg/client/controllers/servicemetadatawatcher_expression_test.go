@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+func TestGetExpressionString(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test evaluating the JSONPath expressions of the watched fields.")
+
+	object := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   false,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "sidecar", "image": "envoy:1.30"},
+						map[string]interface{}{"name": "app", "image": "app:2.1.0"},
+					},
+				},
+			},
+			"rules": []interface{}{
+				map[string]interface{}{"host": "a.example.com"},
+				map[string]interface{}{"host": "b.example.com"},
+			},
+		},
+		"status": map[string]interface{}{
+			"ratio": 0.75,
+		},
+	}
+
+	tcs := []struct {
+		name          string
+		expression    string
+		expectedValue string
+		expectedFound bool
+		expectedError bool
+	}{
+		{
+			name:          "filtered container",
+			expression:    `{.spec.template.spec.containers[?(@.name=="app")].image}`,
+			expectedValue: "app:2.1.0",
+			expectedFound: true,
+		},
+		{
+			name:          "integer",
+			expression:    ".spec.replicas",
+			expectedValue: "3",
+			expectedFound: true,
+		},
+		{
+			name:          "boolean",
+			expression:    "{.spec.paused}",
+			expectedValue: "false",
+			expectedFound: true,
+		},
+		{
+			name:          "float",
+			expression:    "{.status.ratio}",
+			expectedValue: "0.75",
+			expectedFound: true,
+		},
+		{
+			name:          "joined list",
+			expression:    "{.spec.rules[*].host}",
+			expectedValue: "a.example.com,b.example.com",
+			expectedFound: true,
+		},
+		{
+			name:       "missing field",
+			expression: "{.status.readyReplicas}",
+		},
+		{
+			name:          "not a scalar",
+			expression:    "{.spec.rules}",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen evaluating %s", tc.name, tc.expression)
+
+		jp, err := registryv1alpha1.ParseExpression(tc.expression)
+		test.NoError(err)
+
+		value, found, err := getExpressionString(object, jp)
+		test.Equal(tc.expectedError, err != nil)
+		test.Equal(tc.expectedFound, found)
+		test.Equal(tc.expectedValue, value)
+	}
+}
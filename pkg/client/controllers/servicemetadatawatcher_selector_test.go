@@ -0,0 +1,259 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	crevent "sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+func TestServiceMetadataWatcherSelector(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test watching the objects selected by a label selector.")
+
+	configMaps := registryv1alpha1.ObjectReference{APIVersion: "v1", Kind: "ConfigMap"}
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}
+
+	tcs := []struct {
+		name            string
+		aggregation     string
+		field           registryv1alpha1.WatchedField
+		expectedValues  map[string]string
+		expectedObjects []string
+		expectedErrors  int
+	}{
+		{
+			name:            "map by name",
+			field:           registryv1alpha1.WatchedField{Source: "data.tier", Destination: "{{.name}}.tier"},
+			expectedValues:  map[string]string{"app1.tier": "gold", "app2.tier": "silver"},
+			expectedObjects: []string{"app1", "app2"},
+		},
+		{
+			name:            "first object",
+			aggregation:     registryv1alpha1.AggregationFirst,
+			field:           registryv1alpha1.WatchedField{Source: "data.tier", Destination: "tier"},
+			expectedValues:  map[string]string{"tier": "gold"},
+			expectedObjects: []string{"app1", "app2"},
+		},
+		{
+			name:            "last object",
+			aggregation:     registryv1alpha1.AggregationLast,
+			field:           registryv1alpha1.WatchedField{Source: "data.tier", Destination: "tier"},
+			expectedValues:  map[string]string{"tier": "silver"},
+			expectedObjects: []string{"app1", "app2"},
+		},
+		{
+			name:            "colliding destinations",
+			field:           registryv1alpha1.WatchedField{Source: "data.tier", Destination: "tier"},
+			expectedValues:  map[string]string{"tier": "gold"},
+			expectedObjects: []string{"app1", "app2"},
+			expectedErrors:  1,
+		},
+		{
+			name: "unselected object kept",
+			field: registryv1alpha1.WatchedField{
+				Source: "data.tier", Destination: "{{.name}}.tier", OnDelete: registryv1alpha1.OnDeleteKeep,
+			},
+			expectedValues:  map[string]string{"app1.tier": "gold", "app2.tier": "silver", "app3.tier": "bronze"},
+			expectedObjects: []string{"app1", "app2", "app3"},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen reconciling a watcher of the ConfigMaps of team a", tc.name)
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(corev1.AddToScheme(s))
+		test.NoError(registryv1.AddToScheme(s))
+		test.NoError(registryv1alpha1.AddToScheme(s))
+
+		// app3 was selected before its labels changed
+		watcher := &registryv1alpha1.ServiceMetadataWatcher{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "watcher1",
+				Namespace:  "app",
+				Generation: 2,
+				Finalizers: []string{ServiceMetadataFinalizer},
+			},
+			Spec: registryv1alpha1.ServiceMetadataWatcherSpec{
+				WatchedServiceObjects: []registryv1alpha1.WatchedServiceObject{{
+					ObjectReference: configMaps,
+					LabelSelector:   selector,
+					Aggregation:     tc.aggregation,
+					WatchedFields:   []registryv1alpha1.WatchedField{tc.field},
+				}},
+			},
+			Status: registryv1alpha1.ServiceMetadataWatcherStatus{
+				ServiceID: "12345",
+				WatchedServiceObjects: []registryv1alpha1.WatchedServiceObjectStatus{{
+					ObjectReference: configMaps,
+					LabelSelector:   selector,
+					Objects:         []string{"app3"},
+					Values:          map[string]string{"app3.tier": "bronze"},
+				}},
+			},
+		}
+		configMap := func(name, team, tier string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", Labels: map[string]string{"team": team}},
+				Data:       map[string]string{"tier": tier},
+			}
+		}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Annotations: map[string]string{"adobe.serviceid": "12345"}}},
+				configMap("app2", "a", "silver"),
+				configMap("app1", "a", "gold"),
+				configMap("app3", "b", "bronze"),
+				&registryv1.Cluster{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"},
+					Spec: registryv1.ClusterSpec{
+						ServiceMetadata: registryv1.ServiceMetadata{
+							"12345": {"app": {"app3.tier": "bronze", "manual": "value"}},
+						},
+					},
+				},
+				watcher,
+			).
+			WithStatusSubresource(watcher).
+			Build()
+
+		r := &ServiceMetadataWatcherReconciler{
+			Client:              c,
+			Log:                 logr.Discard(),
+			Scheme:              s,
+			WatchedGVKs:         []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}},
+			ServiceIdAnnotation: "adobe.serviceid",
+			Namespace:           "cluster-registry",
+			Recorder:            record.NewFakeRecorder(10),
+		}
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(watcher)})
+		test.NoError(err)
+
+		updated := new(registryv1alpha1.ServiceMetadataWatcher)
+		test.NoError(c.Get(ctx, client.ObjectKeyFromObject(watcher), updated))
+		test.Len(updated.Status.WatchedServiceObjects, 1)
+		wso := updated.Status.WatchedServiceObjects[0]
+		test.Equal(tc.expectedValues, wso.Values)
+		test.Equal(tc.expectedObjects, wso.Objects)
+		test.Len(wso.Errors, tc.expectedErrors)
+
+		expected := registryv1.ServiceMetadataMap{"manual": "value"}
+		for k, v := range tc.expectedValues {
+			expected[k] = v
+		}
+		cluster := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKey{Name: "cluster1", Namespace: "cluster-registry"}, cluster))
+		test.Equal(expected, cluster.Spec.ServiceMetadata["12345"]["app"])
+	}
+}
+
+func TestWatchesObject(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test matching the watched objects by name or by label selector.")
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app1", Labels: map[string]string{"team": "a"}}}
+
+	tcs := []struct {
+		name     string
+		wso      registryv1alpha1.WatchedServiceObject
+		expected bool
+	}{
+		{
+			name:     "same name",
+			wso:      registryv1alpha1.WatchedServiceObject{ObjectReference: registryv1alpha1.ObjectReference{Name: "app1"}},
+			expected: true,
+		},
+		{
+			name: "other name",
+			wso:  registryv1alpha1.WatchedServiceObject{ObjectReference: registryv1alpha1.ObjectReference{Name: "app2"}},
+		},
+		{
+			name: "matching selector",
+			wso: registryv1alpha1.WatchedServiceObject{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			},
+			expected: true,
+		},
+		{
+			name: "other selector",
+			wso: registryv1alpha1.WatchedServiceObject{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen matching the object app1 of team a", tc.name)
+		test.Equal(tc.expected, watchesObject(tc.wso, obj))
+	}
+}
+
+// delayedQueue records the requests added after a delay
+type delayedQueue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+	added map[reconcile.Request]time.Duration
+}
+
+func (q *delayedQueue) AddAfter(req reconcile.Request, delay time.Duration) {
+	q.added[req] = delay
+}
+
+func TestDebouncedHandler(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test enqueueing the watchers of the updated objects after a delay.")
+
+	watcher1 := reconcile.Request{NamespacedName: types.NamespacedName{Name: "watcher1", Namespace: "app"}}
+	watcher2 := reconcile.Request{NamespacedName: types.NamespacedName{Name: "watcher2", Namespace: "app"}}
+	h := &debouncedHandler{
+		mapFunc: func(_ context.Context, obj client.Object) []reconcile.Request {
+			if obj.GetLabels()["team"] == "a" {
+				return []reconcile.Request{watcher1, watcher2}
+			}
+			return []reconcile.Request{watcher1}
+		},
+		delay: 10 * time.Second,
+	}
+
+	t.Logf("\tTest update:\tWhen the labels of a watched object change")
+	q := &delayedQueue{added: map[reconcile.Request]time.Duration{}}
+	h.Update(context.Background(), crevent.UpdateEvent{
+		ObjectOld: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app1", Labels: map[string]string{"team": "a"}}},
+		ObjectNew: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app1", Labels: map[string]string{"team": "b"}}},
+	}, q)
+	test.Equal(map[reconcile.Request]time.Duration{watcher1: 10 * time.Second, watcher2: 10 * time.Second}, q.added)
+}
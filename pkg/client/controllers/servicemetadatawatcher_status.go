@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+// Reasons of the Ready and Degraded conditions, which are also the reasons of
+// the events of the watchers
+const (
+	ReasonSynced           = "Synced"
+	ReasonPartiallySynced  = "PartiallySynced"
+	ReasonSyncFailed       = "SyncFailed"
+	ReasonNoWatchedObjects = "NoWatchedObjects"
+	ReasonMissingServiceID = "MissingServiceID"
+	ReasonPatchFailed      = "PatchFailed"
+)
+
+// setWatcherSynced sets the conditions of the watcher from the errors of its
+// watched objects
+func setWatcherSynced(status *registryv1alpha1.ServiceMetadataWatcherStatus, generation int64) {
+	errors, values := 0, 0
+	for _, wso := range status.WatchedServiceObjects {
+		errors += len(wso.Errors)
+		values += len(wso.Values)
+	}
+
+	switch {
+	case errors == 0:
+		setWatcherConditions(status, generation, true, false, ReasonSynced, "All the watched fields were synced")
+	case values > 0:
+		setWatcherConditions(status, generation, true, true, ReasonPartiallySynced,
+			fmt.Sprintf("%d watched fields were synced, but the sync failed with %d errors", values, errors))
+	default:
+		setWatcherConditions(status, generation, false, true, ReasonSyncFailed,
+			fmt.Sprintf("No watched field was synced, the sync failed with %d errors", errors))
+	}
+}
+
+// setWatcherFailed sets the conditions of a watcher which could not be synced
+// at all
+func setWatcherFailed(status *registryv1alpha1.ServiceMetadataWatcherStatus, generation int64, reason, message string) {
+	setWatcherConditions(status, generation, false, true, reason, message)
+}
+
+func setWatcherConditions(status *registryv1alpha1.ServiceMetadataWatcherStatus, generation int64, ready, degraded bool, reason, message string) {
+	conditionStatus := func(b bool) metav1.ConditionStatus {
+		if b {
+			return metav1.ConditionTrue
+		}
+		return metav1.ConditionFalse
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               registryv1alpha1.ConditionReady,
+		Status:             conditionStatus(ready),
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               registryv1alpha1.ConditionDegraded,
+		Status:             conditionStatus(degraded),
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// watchedObjectStatus returns the previous status of the watched object, or
+// of the objects selected by the label selector, if any
+func watchedObjectStatus(status registryv1alpha1.ServiceMetadataWatcherStatus, ref registryv1alpha1.ObjectReference, selector *metav1.LabelSelector) *registryv1alpha1.WatchedServiceObjectStatus {
+	for i := range status.WatchedServiceObjects {
+		wso := &status.WatchedServiceObjects[i]
+		if wso.ObjectReference == ref && equality.Semantic.DeepEqual(wso.LabelSelector, selector) {
+			return wso
+		}
+	}
+	return nil
+}
+
+// describeWatchedObject returns the reference of the watched object, or its
+// label selector, for the events
+func describeWatchedObject(wso registryv1alpha1.WatchedServiceObjectStatus) string {
+	if wso.LabelSelector == nil {
+		return wso.ObjectReference.String()
+	}
+	return wso.ObjectReference.APIVersion + "/" + wso.ObjectReference.Kind + " " + metav1.FormatLabelSelector(wso.LabelSelector)
+}
+
+// updateWatcherStatus replaces the status of the latest version of the
+// watcher, retrying on conflicts
+func updateWatcherStatus(ctx context.Context, c client.Client, key client.ObjectKey, status *registryv1alpha1.ServiceMetadataWatcherStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		instance := new(registryv1alpha1.ServiceMetadataWatcher)
+		if err := c.Get(ctx, key, instance); err != nil {
+			return err
+		}
+		status.DeepCopyInto(&instance.Status)
+		return c.Status().Update(ctx, instance)
+	})
+}
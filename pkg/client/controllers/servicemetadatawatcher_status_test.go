@@ -0,0 +1,217 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+)
+
+func TestServiceMetadataWatcherStatus(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test reporting the sync of the watched fields in the watcher status.")
+
+	configMap := registryv1alpha1.ObjectReference{Name: "app", APIVersion: "v1", Kind: "ConfigMap"}
+	secret := registryv1alpha1.ObjectReference{Name: "app", APIVersion: "v1", Kind: "Secret"}
+
+	tcs := []struct {
+		name              string
+		annotations       map[string]string
+		objects           []registryv1alpha1.WatchedServiceObject
+		expectedReady     metav1.ConditionStatus
+		expectedDegraded  metav1.ConditionStatus
+		expectedReason    string
+		expectedObjects   int
+		expectedValues    map[string]string
+		expectedErrors    int
+		expectedEventType string
+	}{
+		{
+			name:        "synced fields",
+			annotations: map[string]string{"adobe.serviceid": "12345"},
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: configMap,
+				WatchedFields:   []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			}},
+			expectedReady:     metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionFalse,
+			expectedReason:    ReasonSynced,
+			expectedObjects:   1,
+			expectedValues:    map[string]string{"tier": "gold"},
+			expectedEventType: corev1.EventTypeNormal,
+		},
+		{
+			name:        "missing field",
+			annotations: map[string]string{"adobe.serviceid": "12345"},
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: configMap,
+				WatchedFields: []registryv1alpha1.WatchedField{
+					{Source: "data.tier", Destination: "tier"},
+					{Source: "data.owner", Destination: "owner"},
+				},
+			}},
+			expectedReady:     metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionTrue,
+			expectedReason:    ReasonPartiallySynced,
+			expectedObjects:   1,
+			expectedValues:    map[string]string{"tier": "gold"},
+			expectedErrors:    1,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:        "expression",
+			annotations: map[string]string{"adobe.serviceid": "12345"},
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: configMap,
+				WatchedFields:   []registryv1alpha1.WatchedField{{Expression: "{.data.tier}", Destination: "tier"}},
+			}},
+			expectedReady:     metav1.ConditionTrue,
+			expectedDegraded:  metav1.ConditionFalse,
+			expectedReason:    ReasonSynced,
+			expectedObjects:   1,
+			expectedValues:    map[string]string{"tier": "gold"},
+			expectedEventType: corev1.EventTypeNormal,
+		},
+		{
+			name:        "invalid expression",
+			annotations: map[string]string{"adobe.serviceid": "12345"},
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: configMap,
+				WatchedFields:   []registryv1alpha1.WatchedField{{Expression: "{.data[?(@.tier==]}", Destination: "tier"}},
+			}},
+			expectedReady:     metav1.ConditionFalse,
+			expectedDegraded:  metav1.ConditionTrue,
+			expectedReason:    ReasonSyncFailed,
+			expectedObjects:   1,
+			expectedErrors:    1,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:        "not watched kind",
+			annotations: map[string]string{"adobe.serviceid": "12345"},
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: secret,
+				WatchedFields:   []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			}},
+			expectedReady:     metav1.ConditionFalse,
+			expectedDegraded:  metav1.ConditionTrue,
+			expectedReason:    ReasonSyncFailed,
+			expectedObjects:   1,
+			expectedErrors:    1,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+		{
+			name: "missing service ID",
+			objects: []registryv1alpha1.WatchedServiceObject{{
+				ObjectReference: configMap,
+				WatchedFields:   []registryv1alpha1.WatchedField{{Source: "data.tier", Destination: "tier"}},
+			}},
+			expectedReady:     metav1.ConditionFalse,
+			expectedDegraded:  metav1.ConditionTrue,
+			expectedReason:    ReasonMissingServiceID,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+		{
+			name:              "no watched objects",
+			annotations:       map[string]string{"adobe.serviceid": "12345"},
+			expectedReady:     metav1.ConditionFalse,
+			expectedDegraded:  metav1.ConditionTrue,
+			expectedReason:    ReasonNoWatchedObjects,
+			expectedEventType: corev1.EventTypeWarning,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen reconciling a watcher of %d objects", tc.name, len(tc.objects))
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(corev1.AddToScheme(s))
+		test.NoError(registryv1.AddToScheme(s))
+		test.NoError(registryv1alpha1.AddToScheme(s))
+
+		watcher := &registryv1alpha1.ServiceMetadataWatcher{
+			ObjectMeta: metav1.ObjectMeta{Name: "watcher1", Namespace: "app", Generation: 2},
+			Spec:       registryv1alpha1.ServiceMetadataWatcherSpec{WatchedServiceObjects: tc.objects},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "app", Annotations: tc.annotations}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "app"}, Data: map[string]string{"tier": "gold"}},
+				&registryv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"}},
+				watcher,
+			).
+			WithStatusSubresource(watcher).
+			Build()
+
+		recorder := record.NewFakeRecorder(10)
+		r := &ServiceMetadataWatcherReconciler{
+			Client:              c,
+			Log:                 logr.Discard(),
+			Scheme:              s,
+			WatchedGVKs:         []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}},
+			ServiceIdAnnotation: "adobe.serviceid",
+			Namespace:           "cluster-registry",
+			Recorder:            recorder,
+		}
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(watcher)})
+		test.NoError(err)
+
+		updated := new(registryv1alpha1.ServiceMetadataWatcher)
+		test.NoError(c.Get(ctx, client.ObjectKeyFromObject(watcher), updated))
+		status := updated.Status
+
+		ready := meta.FindStatusCondition(status.Conditions, registryv1alpha1.ConditionReady)
+		degraded := meta.FindStatusCondition(status.Conditions, registryv1alpha1.ConditionDegraded)
+		test.Equal(tc.expectedReady, ready.Status)
+		test.Equal(tc.expectedDegraded, degraded.Status)
+		test.Equal(tc.expectedReason, ready.Reason)
+		test.Equal(int64(2), ready.ObservedGeneration)
+
+		test.Len(status.WatchedServiceObjects, tc.expectedObjects)
+		if len(status.WatchedServiceObjects) > 0 {
+			wso := status.WatchedServiceObjects[0]
+			test.Equal(tc.expectedValues, wso.Values)
+			test.Len(wso.Errors, tc.expectedErrors)
+			test.Equal(tc.expectedErrors == 0, wso.LastUpdated != nil)
+		}
+
+		test.NotEmpty(recorder.Events)
+		test.Contains(<-recorder.Events, tc.expectedEventType)
+
+		cluster := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKey{Name: "cluster1", Namespace: "cluster-registry"}, cluster))
+		if tc.expectedValues != nil {
+			test.Equal(registryv1.ServiceMetadataMap(tc.expectedValues), cluster.Spec.ServiceMetadata["12345"]["app"])
+		} else {
+			test.Empty(cluster.Spec.ServiceMetadata)
+		}
+	}
+}
@@ -66,11 +66,14 @@ var _ = BeforeSuite(func() {
 	metrics := monitoring.NewMetrics()
 	metrics.Init(true)
 
+	queue, err := sqs.NewMemory(sqs.Config{})
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&ClusterReconciler{
 		Client: k8sManager.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
 		Scheme: k8sManager.GetScheme(),
-		Queue:  &sqs.Config{},
+		Queue:  queue,
 		CAData: "_cert_data_",
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
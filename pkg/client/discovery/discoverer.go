@@ -0,0 +1,195 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+// Fields which can be discovered, named after their JSON fields
+const (
+	FieldKubernetesVersion = "kubernetesVersion"
+	FieldAvailabilityZones = "availabilityZones"
+	FieldTiers             = "tiers"
+	FieldOidcIssuer        = "oidcIssuer"
+)
+
+// Discoverer periodically discovers the facts of the cluster and merges them
+// into the Cluster objects. The manual fields are owned by the Cluster
+// objects, so they are only discovered while they are empty.
+type Discoverer struct {
+	Client       client.Client
+	Log          logr.Logger
+	Interval     time.Duration
+	ManualFields []string
+	Sources      []Source
+}
+
+// Start discovers the cluster until the context is cancelled
+func (d *Discoverer) Start(ctx context.Context) error {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Discover(ctx); err != nil {
+			d.Log.Error(err, "error discovering the cluster")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection makes only the leader update the Cluster objects
+func (d *Discoverer) NeedLeaderElection() bool {
+	return true
+}
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:urls=/.well-known/openid-configuration,verbs=get
+
+// Discover runs the sources and updates the Cluster objects whose spec
+// changed. A failed source is skipped, so that it does not hold back the
+// facts discovered by the others.
+func (d *Discoverer) Discover(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	discovered := new(registryv1.ClusterSpec)
+	for _, source := range d.Sources {
+		spec, err := source.Discover(ctx)
+		if err != nil {
+			d.Log.Error(err, "error running discovery source", "source", source.Name())
+			continue
+		}
+		if err := discovered.Merge(spec); err != nil {
+			return err
+		}
+	}
+
+	clusters := new(registryv1.ClusterList)
+	if err := d.Client.List(ctx, clusters); err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters.Items {
+		key := client.ObjectKeyFromObject(&cluster)
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			instance := new(registryv1.Cluster)
+			if err := d.Client.Get(ctx, key, instance); err != nil {
+				return err
+			}
+
+			spec := instance.Spec.DeepCopy()
+			if err := Apply(spec, discovered, d.ManualFields); err != nil {
+				return err
+			}
+			if equality.Semantic.DeepEqual(spec, &instance.Spec) {
+				return nil
+			}
+
+			instance.Spec = *spec
+			return d.Client.Update(ctx, instance)
+		})
+		if err != nil {
+			d.Log.Error(err, "error updating discovered facts", "cluster", key.String())
+			continue
+		}
+	}
+	return nil
+}
+
+// Apply merges the discovered facts into the spec, keeping the non-empty
+// manual fields
+func Apply(spec, discovered *registryv1.ClusterSpec, manualFields []string) error {
+	src := discovered.DeepCopy()
+	manual := func(field string) bool {
+		return slices.Contains(manualFields, field)
+	}
+
+	if manual(FieldKubernetesVersion) && spec.KubernetesVersion != "" {
+		src.KubernetesVersion = ""
+	}
+	if manual(FieldAvailabilityZones) && len(spec.AvailabilityZones) > 0 {
+		src.AvailabilityZones = nil
+	}
+	if manual(FieldOidcIssuer) && spec.Extra.OidcIssuer != "" {
+		src.Extra.OidcIssuer = ""
+	}
+
+	// the tiers are merged by name, and the new ones are added only when
+	// they are valid
+	for i, tier := range src.Tiers {
+		j := slices.IndexFunc(spec.Tiers, func(t registryv1.Tier) bool { return t.Name == tier.Name })
+		if j < 0 {
+			if !manual(FieldTiers) && tier.InstanceType != "" && tier.ContainerRuntime != "" {
+				// the taints are appended by the merge below
+				added := tier
+				added.Taints = nil
+				spec.Tiers = append(spec.Tiers, added)
+			}
+			continue
+		}
+
+		dst := &spec.Tiers[j]
+		if manual(FieldTiers) {
+			fillTier(dst, &src.Tiers[i])
+			continue
+		}
+		// the labels would be added to the stale ones and the taints appended
+		dst.Labels = nil
+		dst.Taints = nil
+	}
+
+	// the tiers which were not added must not be copied into empty tiers
+	src.Tiers = slices.DeleteFunc(src.Tiers, func(tier registryv1.Tier) bool {
+		return !slices.ContainsFunc(spec.Tiers, func(t registryv1.Tier) bool { return t.Name == tier.Name })
+	})
+	if len(src.Tiers) == 0 {
+		src.Tiers = nil
+	}
+
+	return spec.Merge(src)
+}
+
+// fillTier drops the discovered attributes which are already set in the
+// manual tier, so that the merge only fills in the empty ones
+func fillTier(dst, src *registryv1.Tier) {
+	if dst.InstanceType != "" {
+		src.InstanceType = ""
+	}
+	if dst.ContainerRuntime != "" {
+		src.ContainerRuntime = ""
+	}
+	if dst.NodeCount != 0 {
+		src.NodeCount = 0
+	}
+	if len(dst.Labels) > 0 {
+		src.Labels = nil
+	}
+	if len(dst.Taints) > 0 {
+		src.Taints = nil
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+type fakeSource struct {
+	spec *registryv1.ClusterSpec
+	err  error
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) Discover(_ context.Context) (*registryv1.ClusterSpec, error) {
+	return s.spec, s.err
+}
+
+func newCluster() registryv1.ClusterSpec {
+	return registryv1.ClusterSpec{
+		Name:              "cluster1",
+		KubernetesVersion: "v1.29.0",
+		AvailabilityZones: []registryv1.AvailabilityZone{{Name: "us-east-1a", ID: "use1-az1"}},
+		Tiers: []registryv1.Tier{
+			{
+				Name:             "worker",
+				InstanceType:     "m5.large",
+				ContainerRuntime: "docker",
+				MinCapacity:      1,
+				MaxCapacity:      5,
+				NodeCount:        1,
+				Labels:           map[string]string{"team": "ethos"},
+				Taints:           []string{"old=true:NoSchedule"},
+			},
+		},
+		Extra: registryv1.Extra{OidcIssuer: "https://manual"},
+	}
+}
+
+func newDiscovered() *registryv1.ClusterSpec {
+	return &registryv1.ClusterSpec{
+		KubernetesVersion: "v1.30.2",
+		AvailabilityZones: []registryv1.AvailabilityZone{
+			{Name: "us-east-1a", ID: "use1-az1"},
+			{Name: "us-east-1b", ID: "use1-az2"},
+		},
+		Tiers: []registryv1.Tier{
+			{
+				Name:             "system",
+				InstanceType:     "m5.xlarge",
+				ContainerRuntime: "containerd",
+				NodeCount:        3,
+				Taints:           []string{"system=true:NoSchedule"},
+			},
+			{
+				Name:             "worker",
+				InstanceType:     "m5.xlarge",
+				ContainerRuntime: "containerd",
+				NodeCount:        4,
+				Labels:           map[string]string{"tier": "worker"},
+				Taints:           []string{"dedicated=worker:NoSchedule"},
+			},
+			{
+				Name:         "mixed",
+				InstanceType: "m5.xlarge",
+				NodeCount:    2,
+			},
+		},
+		Extra: registryv1.Extra{OidcIssuer: "https://discovered"},
+	}
+}
+
+func TestApply(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test merging the discovered facts into the cluster spec.")
+
+	tcs := []struct {
+		name         string
+		manualFields []string
+		expected     func(spec *registryv1.ClusterSpec)
+	}{
+		{
+			name: "discovery owned fields",
+			expected: func(spec *registryv1.ClusterSpec) {
+				spec.KubernetesVersion = "v1.30.2"
+				spec.AvailabilityZones = newDiscovered().AvailabilityZones
+				spec.Tiers[0].InstanceType = "m5.xlarge"
+				spec.Tiers[0].ContainerRuntime = "containerd"
+				spec.Tiers[0].NodeCount = 4
+				spec.Tiers[0].Labels = map[string]string{"tier": "worker"}
+				spec.Tiers[0].Taints = []string{"dedicated=worker:NoSchedule"}
+				spec.Tiers = append(spec.Tiers, registryv1.Tier{
+					Name:             "system",
+					InstanceType:     "m5.xlarge",
+					ContainerRuntime: "containerd",
+					NodeCount:        3,
+					Taints:           []string{"system=true:NoSchedule"},
+				})
+				spec.Extra.OidcIssuer = "https://discovered"
+			},
+		},
+		{
+			name:         "manual fields",
+			manualFields: []string{FieldKubernetesVersion, FieldAvailabilityZones, FieldTiers, FieldOidcIssuer},
+			expected:     func(_ *registryv1.ClusterSpec) {},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen the manual fields are %v", tc.name, tc.manualFields)
+
+		expected := newCluster()
+		tc.expected(&expected)
+
+		spec := newCluster()
+		test.NoError(Apply(&spec, newDiscovered(), tc.manualFields))
+		test.Equal(expected, spec)
+
+		// applying the same facts again must not change the spec
+		test.NoError(Apply(&spec, newDiscovered(), tc.manualFields))
+		test.Equal(expected, spec)
+	}
+
+	t.Logf("\tTest empty manual fields:\tWhen the manual fields are not set in the cluster")
+	spec := registryv1.ClusterSpec{Name: "cluster1"}
+	test.NoError(Apply(&spec, newDiscovered(), []string{FieldKubernetesVersion, FieldTiers}))
+	test.Equal("v1.30.2", spec.KubernetesVersion)
+	test.Len(spec.AvailabilityZones, 2)
+	test.Empty(spec.Tiers)
+}
+
+func TestDiscoverer(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test updating the clusters with the discovered facts.")
+
+	s := runtime.NewScheme()
+	test.NoError(registryv1.AddToScheme(s))
+	cluster := &registryv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"},
+		Spec:       registryv1.ClusterSpec{Name: "cluster1", KubernetesVersion: "v1.29.0"},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cluster).Build()
+
+	d := &Discoverer{
+		Client: c,
+		Log:    logr.Discard(),
+		Sources: []Source{
+			&fakeSource{err: errors.New("forbidden")},
+			&fakeSource{spec: &registryv1.ClusterSpec{KubernetesVersion: "v1.30.2"}},
+		},
+	}
+	test.NoError(d.Discover(context.Background()))
+
+	updated := new(registryv1.Cluster)
+	test.NoError(c.Get(context.Background(), client.ObjectKeyFromObject(cluster), updated))
+	test.Equal("v1.30.2", updated.Spec.KubernetesVersion)
+
+	t.Logf("\tTest unchanged:\tWhen the discovered facts are already in the cluster")
+	test.NoError(d.Discover(context.Background()))
+	unchanged := new(registryv1.Cluster)
+	test.NoError(c.Get(context.Background(), client.ObjectKeyFromObject(cluster), unchanged))
+	test.Equal(updated.ResourceVersion, unchanged.ResourceVersion)
+}
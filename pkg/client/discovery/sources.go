@@ -0,0 +1,260 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+const (
+	// LabelZoneID is the label of the nodes with the ID of their zone, which is
+	// set on EKS
+	LabelZoneID = "topology.k8s.aws/zone-id"
+
+	// oidcDiscoveryPath is the path of the service account issuer discovery document
+	oidcDiscoveryPath = "/.well-known/openid-configuration"
+)
+
+// containerRuntimes maps the runtime of the node info to the supported values
+// of the tiers
+var containerRuntimes = map[string]string{
+	"docker":     "docker",
+	"cri-o":      "cri-o",
+	"containerd": "containerd",
+}
+
+// Source discovers a part of the cluster spec
+type Source interface {
+	Name() string
+	Discover(ctx context.Context) (*registryv1.ClusterSpec, error)
+}
+
+type kubernetesVersion struct {
+	discovery discovery.ServerVersionInterface
+}
+
+// KubernetesVersion discovers the version of the API server
+func KubernetesVersion(d discovery.ServerVersionInterface) Source {
+	return &kubernetesVersion{discovery: d}
+}
+
+func (s *kubernetesVersion) Name() string {
+	return "kubernetesVersion"
+}
+
+func (s *kubernetesVersion) Discover(_ context.Context) (*registryv1.ClusterSpec, error) {
+	info, err := s.discovery.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	return &registryv1.ClusterSpec{KubernetesVersion: info.GitVersion}, nil
+}
+
+type oidcIssuer struct {
+	client rest.Interface
+}
+
+// OidcIssuer discovers the issuer of the service account tokens, from the
+// issuer discovery document of the API server
+func OidcIssuer(c rest.Interface) Source {
+	return &oidcIssuer{client: c}
+}
+
+func (s *oidcIssuer) Name() string {
+	return "oidcIssuer"
+}
+
+func (s *oidcIssuer) Discover(ctx context.Context) (*registryv1.ClusterSpec, error) {
+	data, err := s.client.Get().AbsPath(oidcDiscoveryPath).DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Issuer string `json:"issuer"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Issuer == "" {
+		return nil, errors.New("the issuer discovery document has no issuer")
+	}
+	return &registryv1.ClusterSpec{Extra: registryv1.Extra{OidcIssuer: doc.Issuer}}, nil
+}
+
+type nodes struct {
+	client    client.Client
+	tierLabel string
+}
+
+// Nodes discovers the availability zones from the topology labels of the
+// nodes, and the tiers from the nodes grouped by the tier label, if any
+func Nodes(c client.Client, tierLabel string) Source {
+	return &nodes{client: c, tierLabel: tierLabel}
+}
+
+func (s *nodes) Name() string {
+	return "nodes"
+}
+
+func (s *nodes) Discover(ctx context.Context) (*registryv1.ClusterSpec, error) {
+	list := new(corev1.NodeList)
+	if err := s.client.List(ctx, list); err != nil {
+		return nil, err
+	}
+
+	spec := new(registryv1.ClusterSpec)
+	spec.AvailabilityZones = availabilityZones(list.Items)
+	if s.tierLabel != "" {
+		spec.Tiers = tiers(list.Items, s.tierLabel)
+	}
+	return spec, nil
+}
+
+// availabilityZones returns the zones of the nodes, sorted by name
+func availabilityZones(nodes []corev1.Node) []registryv1.AvailabilityZone {
+	zones := map[string]string{}
+	for _, node := range nodes {
+		zone := node.Labels[corev1.LabelTopologyZone]
+		if zone == "" {
+			continue
+		}
+		if id := node.Labels[LabelZoneID]; id != "" || zones[zone] == "" {
+			zones[zone] = id
+		}
+	}
+
+	var azs []registryv1.AvailabilityZone
+	for name, id := range zones {
+		azs = append(azs, registryv1.AvailabilityZone{Name: name, ID: id})
+	}
+	sort.Slice(azs, func(i, j int) bool { return azs[i].Name < azs[j].Name })
+	return azs
+}
+
+// tiers groups the nodes by the value of the tier label, and returns the
+// attributes shared by all the nodes of each tier, sorted by name
+func tiers(nodes []corev1.Node, tierLabel string) []registryv1.Tier {
+	groups := map[string][]corev1.Node{}
+	for _, node := range nodes {
+		if name := node.Labels[tierLabel]; name != "" {
+			groups[name] = append(groups[name], node)
+		}
+	}
+
+	var ts []registryv1.Tier
+	for name, group := range groups {
+		tier := registryv1.Tier{
+			Name:             name,
+			InstanceType:     common(group, func(n corev1.Node) string { return n.Labels[corev1.LabelInstanceTypeStable] }),
+			ContainerRuntime: common(group, containerRuntime),
+			NodeCount:        len(group),
+			Labels:           commonLabels(group),
+			Taints:           commonTaints(group),
+		}
+		ts = append(ts, tier)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	return ts
+}
+
+// containerRuntime returns the supported runtime of the node, from a version
+// such as containerd://1.7.11
+func containerRuntime(node corev1.Node) string {
+	name, _, _ := strings.Cut(node.Status.NodeInfo.ContainerRuntimeVersion, "://")
+	return containerRuntimes[name]
+}
+
+// common returns the value shared by all the nodes, or an empty string
+func common(nodes []corev1.Node, value func(corev1.Node) string) string {
+	v := value(nodes[0])
+	for _, node := range nodes[1:] {
+		if value(node) != v {
+			return ""
+		}
+	}
+	return v
+}
+
+// systemKey tells if the key of a label or a taint is reserved for
+// Kubernetes or the cloud provider, such as the hostname or the not-ready
+// taint, which are not attributes of a tier
+func systemKey(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
+	}
+	for _, domain := range []string{"kubernetes.io", "k8s.io", "k8s.aws"} {
+		if prefix == domain || strings.HasSuffix(prefix, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// commonLabels returns the labels shared by all the nodes
+func commonLabels(nodes []corev1.Node) map[string]string {
+	labels := map[string]string{}
+	for k, v := range nodes[0].Labels {
+		if !systemKey(k) {
+			labels[k] = v
+		}
+	}
+	for _, node := range nodes[1:] {
+		for k, v := range labels {
+			if node.Labels[k] != v {
+				delete(labels, k)
+			}
+		}
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+	return labels
+}
+
+// commonTaints returns the taints shared by all the nodes, sorted
+func commonTaints(nodes []corev1.Node) []string {
+	counts := map[string]int{}
+	for _, node := range nodes {
+		seen := map[string]bool{}
+		for _, taint := range node.Spec.Taints {
+			t := taint.ToString()
+			if systemKey(taint.Key) || seen[t] {
+				continue
+			}
+			seen[t] = true
+			counts[t]++
+		}
+	}
+
+	var taints []string
+	for t, count := range counts {
+		if count == len(nodes) {
+			taints = append(taints, t)
+		}
+	}
+	sort.Strings(taints)
+	return taints
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+func newNode(name, zone, tier, runtime string, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				corev1.LabelHostname:           name,
+				corev1.LabelTopologyZone:       zone,
+				LabelZoneID:                    zone + "-id",
+				corev1.LabelInstanceTypeStable: "m5.xlarge",
+				"node.ethos.adobe.net/tier":    tier,
+				"team":                         name,
+			},
+		},
+		Spec: corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: runtime},
+		},
+	}
+}
+
+func newNodesClient(t *testing.T, nodes ...client.Object) client.Client {
+	s := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(s))
+	return fake.NewClientBuilder().WithScheme(s).WithObjects(nodes...).Build()
+}
+
+func TestNodes(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test discovering the availability zones and the tiers from the nodes.")
+
+	dedicated := corev1.Taint{Key: "dedicated", Value: "worker", Effect: corev1.TaintEffectNoSchedule}
+	unreachable := corev1.Taint{Key: corev1.TaintNodeUnreachable, Effect: corev1.TaintEffectNoExecute}
+
+	c := newNodesClient(t,
+		newNode("node1", "us-east-1a", "worker", "containerd://1.7.11", dedicated, unreachable),
+		newNode("node2", "us-east-1b", "worker", "containerd://1.7.11", dedicated),
+		newNode("node3", "us-east-1b", "system", "containerd://1.7.11"),
+		newNode("node4", "us-east-1b", "system", "cri-o://1.29.1"),
+	)
+
+	spec, err := Nodes(c, "node.ethos.adobe.net/tier").Discover(context.Background())
+	test.NoError(err)
+
+	test.Equal([]registryv1.AvailabilityZone{
+		{Name: "us-east-1a", ID: "us-east-1a-id"},
+		{Name: "us-east-1b", ID: "us-east-1b-id"},
+	}, spec.AvailabilityZones)
+
+	test.Equal([]registryv1.Tier{
+		{
+			Name:         "system",
+			InstanceType: "m5.xlarge",
+			NodeCount:    2,
+			Labels:       map[string]string{"node.ethos.adobe.net/tier": "system"},
+		},
+		{
+			Name:             "worker",
+			InstanceType:     "m5.xlarge",
+			ContainerRuntime: "containerd",
+			NodeCount:        2,
+			Labels:           map[string]string{"node.ethos.adobe.net/tier": "worker"},
+			Taints:           []string{"dedicated=worker:NoSchedule"},
+		},
+	}, spec.Tiers)
+
+	t.Logf("\tTest no tier label:\tWhen discovering without a tier label")
+	spec, err = Nodes(c, "").Discover(context.Background())
+	test.NoError(err)
+	test.Len(spec.AvailabilityZones, 2)
+	test.Empty(spec.Tiers)
+}
+
+func TestKubernetesVersion(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test discovering the version of the API server.")
+
+	d := &fakediscovery.FakeDiscovery{
+		Fake:               &clienttesting.Fake{},
+		FakedServerVersion: &version.Info{GitVersion: "v1.30.2-eks-1552ad0"},
+	}
+
+	spec, err := KubernetesVersion(d).Discover(context.Background())
+	test.NoError(err)
+	test.Equal("v1.30.2-eks-1552ad0", spec.KubernetesVersion)
+}
+
+func TestOidcIssuer(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test discovering the issuer of the service account tokens.")
+
+	tcs := []struct {
+		name           string
+		status         int
+		body           string
+		expectedIssuer string
+		expectedErr    bool
+	}{
+		{
+			name:           "issuer discovery document",
+			status:         http.StatusOK,
+			body:           `{"issuer":"https://oidc.eks.us-east-1.amazonaws.com/id/ABC","jwks_uri":"https://ip:443/openid/v1/jwks"}`,
+			expectedIssuer: "https://oidc.eks.us-east-1.amazonaws.com/id/ABC",
+		},
+		{
+			name:        "no issuer",
+			status:      http.StatusOK,
+			body:        `{}`,
+			expectedErr: true,
+		},
+		{
+			name:        "forbidden",
+			status:      http.StatusForbidden,
+			body:        `{}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen the API server responds with %d", tc.name, tc.status)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			test.Equal(oidcDiscoveryPath, r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(tc.status)
+			_, _ = w.Write([]byte(tc.body))
+		}))
+
+		d := discovery.NewDiscoveryClientForConfigOrDie(&rest.Config{Host: server.URL})
+		spec, err := OidcIssuer(d.RESTClient()).Discover(context.Background())
+
+		test.Equal(tc.expectedErr, err != nil)
+		if err == nil {
+			test.Equal(tc.expectedIssuer, spec.Extra.OidcIssuer)
+		}
+		server.Close()
+	}
+}
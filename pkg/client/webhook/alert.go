@@ -22,13 +22,16 @@ const (
 	AlertStatusResolved string = "resolved"
 )
 
+// LabelAlertName is the label holding the name of the alerts
+const LabelAlertName = "alertname"
+
 // Alert represents an Alertmanager alert
 type Alert struct {
 	Receiver          string            `json:"receiver"`
 	Status            string            `json:"status"`
 	Alerts            []AlertItem       `json:"alerts"`
-	GroupLabels       GroupLabels       `json:"groupLabels"`
-	CommonLabels      CommonLabels      `json:"commonLabels"`
+	GroupLabels       AlertLabels       `json:"groupLabels"`
+	CommonLabels      AlertLabels       `json:"commonLabels"`
 	CommonAnnotations CommonAnnotations `json:"commonAnnotations"`
 	ExternalURL       string            `json:"externalURL"`
 	Version           string            `json:"version"`
@@ -46,23 +49,13 @@ type AlertItem struct {
 	Fingerprint  string            `json:"fingerprint"`
 }
 
-// AlertLabels ...
-type AlertLabels struct {
-	Alertname string `json:"alertname"`
-	Service   string `json:"service"`
-	Severity  string `json:"severity"`
-}
-
-// GroupLabels ...
-type GroupLabels struct {
-	Alertname string `json:"alertname"`
-}
+// AlertLabels are the labels of the alerts, and the group and common labels
+// of the notifications
+type AlertLabels map[string]string
 
-// CommonLabels ...
-type CommonLabels struct {
-	Alertname string `json:"alertname"`
-	Service   string `json:"service"`
-	Severity  string `json:"severity"`
+// AlertName returns the name of the alert
+func (l AlertLabels) AlertName() string {
+	return l[LabelAlertName]
 }
 
 // CommonAnnotations ...
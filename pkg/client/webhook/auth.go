@@ -0,0 +1,181 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package webhook
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
+)
+
+// Reasons of the rejected requests
+const (
+	RejectedUnauthorized = "unauthorized"
+	RejectedTooLarge     = "too_large"
+	RejectedInvalid      = "invalid"
+	RejectedDuplicate    = "duplicate"
+	RejectedUnprocessed  = "unprocessed"
+)
+
+// DefaultMaxRequestBytes is the size limit of the notifications
+const DefaultMaxRequestBytes int64 = 1 << 20
+
+// authenticate checks the credentials of the request against the bearer token
+// or the basic auth credentials of the config
+func authenticate(auth *configv1.AlertmanagerWebhookAuth, r *http.Request) error {
+	if auth == nil {
+		return nil
+	}
+
+	if auth.BearerTokenFile != "" {
+		token, err := readCredential(auth.BearerTokenFile)
+		if err != nil {
+			return err
+		}
+		if t, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && equal(t, token) {
+			return nil
+		}
+	}
+
+	if auth.UsernameFile != "" || auth.PasswordFile != "" {
+		username, err := readCredential(auth.UsernameFile)
+		if err != nil {
+			return err
+		}
+		password, err := readCredential(auth.PasswordFile)
+		if err != nil {
+			return err
+		}
+		// both are compared to not leak which one is wrong
+		u, p, ok := r.BasicAuth()
+		usernameOk, passwordOk := equal(u, username), equal(p, password)
+		if ok && usernameOk && passwordOk {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid or missing credentials")
+}
+
+// readCredential reads a credential from its file, which must not be empty
+func readCredential(file string) (string, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read credentials: %w", err)
+	}
+	credential := strings.TrimSpace(string(b))
+	if credential == "" {
+		return "", fmt.Errorf("empty credentials in %s", file)
+	}
+	return credential, nil
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// tlsConfig returns the TLS config of the server, requiring the certificates
+// of the clients to be signed by the client CA, if any
+func tlsConfig(cfg *configv1.AlertmanagerWebhookTLS) (*tls.Config, error) {
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile == "" {
+		return c, nil
+	}
+
+	ca, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in client CA %s", cfg.ClientCAFile)
+	}
+	c.ClientCAs = pool
+	c.ClientAuth = tls.RequireAndVerifyClientCert
+	return c, nil
+}
+
+// dedupCache holds the notifications handled within the dedup window, by
+// their group key, status and alerts
+type dedupCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// dedupKey identifies a notification by its group key and status, and by the
+// fingerprints and statuses of its alerts, so that a group whose alerts
+// changed is not taken for a repeat
+func dedupKey(alert Alert) string {
+	items := make([]string, 0, len(alert.Alerts))
+	for _, item := range alert.Alerts {
+		items = append(items, item.Fingerprint+":"+item.Status)
+	}
+	sort.Strings(items)
+
+	hash := fnv.New64a()
+	for _, item := range items {
+		_, _ = hash.Write([]byte(item))
+		_, _ = hash.Write([]byte{0})
+	}
+	return alert.GroupKey + "/" + alert.Status + "/" + strconv.FormatUint(hash.Sum64(), 36)
+}
+
+// reserve marks the notification as handled and returns true, unless it was
+// already handled within the window. The check and the mark are atomic, so
+// that concurrent repeats of a notification are handled once.
+func (d *dedupCache) reserve(alert Alert, now time.Time, window time.Duration) bool {
+	if window <= 0 || alert.GroupKey == "" {
+		return true
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen == nil {
+		d.seen = make(map[string]time.Time)
+	}
+	// forget the notifications which are out of the window
+	for key, t := range d.seen {
+		if now.Sub(t) >= window {
+			delete(d.seen, key)
+		}
+	}
+	key := dedupKey(alert)
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// release forgets the reservation of a notification which failed to be
+// handled, so that it is handled when Alertmanager retries it
+func (d *dedupCache) release(alert Alert, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := dedupKey(alert)
+	if t, ok := d.seen[key]; ok && t.Equal(now) {
+		delete(d.seen, key)
+	}
+}
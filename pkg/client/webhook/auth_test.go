@@ -0,0 +1,242 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/client"
+)
+
+func TestWebhookHandlerAuth(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rejecting the unauthenticated, too large and repeated notifications.")
+
+	dir := t.TempDir()
+	for file, content := range map[string]string{"token": "secret-token\n", "username": "alertmanager", "password": "secret"} {
+		test.NoError(os.WriteFile(filepath.Join(dir, file), []byte(content), 0600))
+	}
+	bearer := &configv1.AlertmanagerWebhookAuth{BearerTokenFile: filepath.Join(dir, "token")}
+	basic := &configv1.AlertmanagerWebhookAuth{
+		UsernameFile: filepath.Join(dir, "username"),
+		PasswordFile: filepath.Join(dir, "password"),
+	}
+
+	notification := func(groupKey string) string {
+		b, err := json.Marshal(Alert{
+			Status:   AlertStatusFiring,
+			GroupKey: groupKey,
+			Alerts:   []AlertItem{{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance"}}},
+		})
+		test.NoError(err)
+		return string(b)
+	}
+
+	tcs := []struct {
+		name             string
+		auth             *configv1.AlertmanagerWebhookAuth
+		header           func(r *http.Request)
+		body             string
+		requests         int
+		expectedStatus   int
+		expectedRejected string
+	}{
+		{
+			name:           "no auth",
+			body:           notification("{}:{alertname=\"Maintenance\"}"),
+			requests:       1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "bearer token",
+			auth:           bearer,
+			header:         func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret-token") },
+			body:           notification("{}:{alertname=\"Maintenance\"}"),
+			requests:       1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "wrong bearer token",
+			auth:             bearer,
+			header:           func(r *http.Request) { r.Header.Set("Authorization", "Bearer other") },
+			body:             notification("{}:{alertname=\"Maintenance\"}"),
+			requests:         1,
+			expectedStatus:   http.StatusUnauthorized,
+			expectedRejected: RejectedUnauthorized,
+		},
+		{
+			name:           "basic auth",
+			auth:           basic,
+			header:         func(r *http.Request) { r.SetBasicAuth("alertmanager", "secret") },
+			body:           notification("{}:{alertname=\"Maintenance\"}"),
+			requests:       1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "missing basic auth",
+			auth:             basic,
+			body:             notification("{}:{alertname=\"Maintenance\"}"),
+			requests:         1,
+			expectedStatus:   http.StatusUnauthorized,
+			expectedRejected: RejectedUnauthorized,
+		},
+		{
+			name:             "too large",
+			body:             `{"receiver":"` + strings.Repeat("a", 2048) + `"}`,
+			requests:         1,
+			expectedStatus:   http.StatusRequestEntityTooLarge,
+			expectedRejected: RejectedTooLarge,
+		},
+		{
+			name:             "repeated notification",
+			body:             notification("{}:{alertname=\"Maintenance\"}"),
+			requests:         2,
+			expectedStatus:   http.StatusOK,
+			expectedRejected: RejectedDuplicate,
+		},
+		{
+			name:           "repeated notification without group key",
+			body:           notification(""),
+			requests:       2,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen sending %d notifications", tc.name, tc.requests)
+
+		s := runtime.NewScheme()
+		test.NoError(registryv1.AddToScheme(s))
+
+		metrics := monitoring.NewMetrics()
+		metrics.Init(true)
+
+		server := &Server{
+			Client:          fake.NewClientBuilder().WithScheme(s).Build(),
+			Namespace:       "cluster-registry",
+			Log:             logr.Discard(),
+			Metrics:         metrics,
+			AlertMap:        []configv1.AlertRule{{AlertName: "Maintenance", OnFiring: map[string]string{"maintenance": "true"}}},
+			Auth:            tc.auth,
+			MaxRequestBytes: 1024,
+			DedupWindow:     time.Minute,
+		}
+
+		var status int
+		for i := 0; i < tc.requests; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tc.body))
+			if tc.header != nil {
+				tc.header(req)
+			}
+			w := httptest.NewRecorder()
+			server.webhookHandler(w, req)
+			status = w.Result().StatusCode
+		}
+		test.Equal(tc.expectedStatus, status)
+
+		rejected := metrics.GetMetricByName("cluster_registry_cc_webhook_rejected_requests_total").(*prometheus.CounterVec)
+		for _, reason := range []string{RejectedUnauthorized, RejectedTooLarge, RejectedDuplicate} {
+			expected := 0.0
+			if reason == tc.expectedRejected {
+				expected = 1
+			}
+			test.Equal(expected, testutil.ToFloat64(rejected.WithLabelValues(reason)), reason)
+		}
+	}
+}
+
+func TestDedupCache(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test dropping the repeated notifications of a group whose alerts did not change.")
+
+	now := time.Now()
+	notification := Alert{
+		Status:   AlertStatusFiring,
+		GroupKey: "{}:{alertname=\"Maintenance\"}",
+		Alerts: []AlertItem{
+			{Status: AlertStatusFiring, Fingerprint: "a1"},
+			{Status: AlertStatusFiring, Fingerprint: "b2"},
+		},
+	}
+
+	reordered := notification
+	reordered.Alerts = []AlertItem{notification.Alerts[1], notification.Alerts[0]}
+
+	added := notification
+	added.Alerts = append([]AlertItem{{Status: AlertStatusFiring, Fingerprint: "c3"}}, notification.Alerts...)
+
+	resolved := notification
+	resolved.Alerts = []AlertItem{notification.Alerts[0], {Status: AlertStatusResolved, Fingerprint: "b2"}}
+
+	tcs := []struct {
+		name     string
+		alert    Alert
+		at       time.Time
+		expected bool
+	}{
+		{name: "first notification", alert: notification, at: now, expected: true},
+		{name: "repeated notification", alert: notification, at: now.Add(time.Second), expected: false},
+		{name: "reordered alerts", alert: reordered, at: now.Add(time.Second), expected: false},
+		{name: "alert added to the group", alert: added, at: now.Add(time.Second), expected: true},
+		{name: "alert of the group resolved", alert: resolved, at: now.Add(time.Second), expected: true},
+		{name: "repeated after the window", alert: notification, at: now.Add(time.Minute), expected: true},
+	}
+
+	d := &dedupCache{}
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen receiving the notification", tc.name)
+		test.Equal(tc.expected, d.reserve(tc.alert, tc.at, time.Minute), tc.name)
+	}
+
+	t.Logf("\tTest release:\tWhen the notification failed to be handled")
+	d = &dedupCache{}
+	test.True(d.reserve(notification, now, time.Minute))
+	d.release(notification, now)
+	test.True(d.reserve(notification, now.Add(time.Second), time.Minute))
+
+	t.Logf("\tTest concurrent repeats:\tWhen receiving the same notification concurrently")
+	d = &dedupCache{}
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if d.reserve(notification, now, time.Minute) {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	test.Equal(int32(1), reserved.Load())
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
+	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/client"
+)
+
+func TestProcess(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test tagging the clusters selected by the alerts matching the alert map.")
+
+	onFiring := map[string]string{"paused": "true"}
+	onResolved := map[string]string{"paused": "false"}
+
+	tcs := []struct {
+		name          string
+		rule          configv1.AlertRule
+		alerts        []AlertItem
+		expectedTags  map[string]string
+		expectedError bool
+	}{
+		{
+			name: "alert name",
+			rule: configv1.AlertRule{AlertName: "Maintenance"},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance"}},
+			},
+			expectedTags: map[string]string{"cluster1": "true", "cluster2": "true", "cluster3": "true"},
+		},
+		{
+			name: "matchers",
+			rule: configv1.AlertRule{
+				AlertName: "Maintenance",
+				Matchers:  []configv1.AlertMatcher{{Name: "severity", Value: "critical"}},
+			},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance", "severity": "warning"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "regex matchers",
+			rule: configv1.AlertRule{
+				Matchers: []configv1.AlertMatcher{{Name: "service", Value: "ingress|dns", Regex: true}},
+			},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Down", "service": "dns"}},
+			},
+			expectedTags: map[string]string{"cluster1": "true", "cluster2": "true", "cluster3": "true"},
+		},
+		{
+			name: "cluster label",
+			rule: configv1.AlertRule{AlertName: "Maintenance", ClusterLabel: "cluster"},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance", "cluster": "cluster1"}},
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance", "cluster": "spec-cluster2"}},
+			},
+			expectedTags: map[string]string{"cluster1": "true", "cluster2": "true"},
+		},
+		{
+			name: "cluster selector",
+			rule: configv1.AlertRule{
+				AlertName:       "Maintenance",
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}},
+			},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance"}},
+			},
+			expectedTags: map[string]string{"cluster2": "true", "cluster3": "true"},
+		},
+		{
+			name: "grouped alerts",
+			rule: configv1.AlertRule{AlertName: "Maintenance", ClusterLabel: "cluster"},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Maintenance", "cluster": "cluster1"}},
+				{Status: AlertStatusResolved, Labels: AlertLabels{LabelAlertName: "Maintenance", "cluster": "cluster3"}},
+			},
+			expectedTags: map[string]string{"cluster1": "true", "cluster3": "false"},
+		},
+		{
+			name: "unmapped alert",
+			rule: configv1.AlertRule{AlertName: "Maintenance"},
+			alerts: []AlertItem{
+				{Status: AlertStatusFiring, Labels: AlertLabels{LabelAlertName: "Down"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen receiving %d alerts", tc.name, len(tc.alerts))
+		ctx := context.Background()
+
+		s := runtime.NewScheme()
+		test.NoError(registryv1.AddToScheme(s))
+
+		cluster := func(name, region string) *registryv1.Cluster {
+			return &registryv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cluster-registry", Labels: map[string]string{"region": region}},
+				Spec:       registryv1.ClusterSpec{Name: "spec-" + name},
+			}
+		}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(cluster("cluster1", "us"), cluster("cluster2", "eu"), cluster("cluster3", "eu")).
+			Build()
+
+		metrics := monitoring.NewMetrics()
+		metrics.Init(true)
+
+		tc.rule.OnFiring = onFiring
+		tc.rule.OnResolved = onResolved
+		server := &Server{
+			Client:    c,
+			Namespace: "cluster-registry",
+			Log:       logr.Discard(),
+			Metrics:   metrics,
+			AlertMap:  []configv1.AlertRule{tc.rule},
+		}
+
+		err := server.process(Alert{Status: AlertStatusFiring, Alerts: tc.alerts})
+		test.Equal(tc.expectedError, err != nil)
+
+		tags := map[string]string{}
+		clusters := &registryv1.ClusterList{}
+		test.NoError(c.List(ctx, clusters, client.InNamespace("cluster-registry")))
+		for _, cluster := range clusters.Items {
+			if value, ok := cluster.Spec.Tags["paused"]; ok {
+				tags[cluster.Name] = value
+			}
+		}
+		if tc.expectedTags == nil {
+			test.Empty(tags)
+		} else {
+			test.Equal(tc.expectedTags, tags)
+		}
+	}
+}
+
+func TestProcessTagOverrides(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test reverting the tags set by the firing alerts after the max duration of their rule.")
+
+	ctx := context.Background()
+
+	s := runtime.NewScheme()
+	test.NoError(registryv1.AddToScheme(s))
+
+	cluster := &registryv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster1", Namespace: "cluster-registry"},
+		Spec:       registryv1.ClusterSpec{Tags: map[string]string{"onboarding": "on"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(cluster).Build()
+
+	metrics := monitoring.NewMetrics()
+	metrics.Init(true)
+
+	server := &Server{
+		Client:    c,
+		Namespace: "cluster-registry",
+		Log:       logr.Discard(),
+		Metrics:   metrics,
+		AlertMap: []configv1.AlertRule{{
+			AlertName:   "Maintenance",
+			OnFiring:    map[string]string{"onboarding": "off"},
+			OnResolved:  map[string]string{"onboarding": "on"},
+			MaxDuration: metav1.Duration{Duration: time.Hour},
+		}},
+	}
+	notify := func(status string) {
+		test.NoError(server.process(Alert{
+			Status: status,
+			Alerts: []AlertItem{{Status: status, Labels: AlertLabels{LabelAlertName: "Maintenance"}}},
+		}))
+	}
+	get := func() *registryv1.Cluster {
+		updated := new(registryv1.Cluster)
+		test.NoError(c.Get(ctx, client.ObjectKeyFromObject(cluster), updated))
+		return updated
+	}
+
+	t.Logf("\tTest firing:\tWhen the alert fires twice")
+	notify(AlertStatusFiring)
+	notify(AlertStatusFiring)
+	updated := get()
+	test.Equal("off", updated.Spec.Tags["onboarding"])
+	override := updated.Spec.TagOverrides["onboarding"]
+	test.Equal("off", override.Value)
+	test.Equal(ptr.To("on"), override.PreviousValue)
+	test.Equal(TagOverrideSetBy, override.SetBy)
+	expiry, err := override.Expiry()
+	test.NoError(err)
+	test.WithinDuration(time.Now().Add(time.Hour), expiry, time.Minute)
+
+	t.Logf("\tTest resolved:\tWhen the alert is resolved")
+	notify(AlertStatusResolved)
+	updated = get()
+	test.Equal("on", updated.Spec.Tags["onboarding"])
+	test.Empty(updated.Spec.TagOverrides)
+}
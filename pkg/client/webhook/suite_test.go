@@ -71,11 +71,14 @@ var _ = BeforeSuite(func() {
 	metrics := monitoring.NewMetrics()
 	metrics.Init(true)
 
+	queue, err := sqs.NewMemory(sqs.Config{})
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&controllers.ClusterReconciler{
 		Client: k8sManager.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Cluster"),
 		Scheme: k8sManager.GetScheme(),
-		Queue:  &sqs.Config{},
+		Queue:  queue,
 		CAData: CAData,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
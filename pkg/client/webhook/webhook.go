@@ -15,16 +15,19 @@ package webhook
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"regexp"
 	"time"
 
 	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/client"
 	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -36,11 +39,26 @@ type Server struct {
 	BindAddress string
 	Metrics     monitoring.MetricsI
 	AlertMap    []configv1.AlertRule
+
+	// Auth holds the credentials of the requests, if any
+	Auth *configv1.AlertmanagerWebhookAuth
+	// TLS serves the webhook over TLS, if set
+	TLS *configv1.AlertmanagerWebhookTLS
+	// MaxRequestBytes limits the size of the requests, DefaultMaxRequestBytes
+	// if not set
+	MaxRequestBytes int64
+	// DedupWindow drops the repeated notifications within the window
+	DedupWindow time.Duration
+
+	dedup dedupCache
 }
 
 const (
 	// DeadMansSwitchAlertName is the name of the DMS alert
 	DeadMansSwitchAlertName = "CRCDeadMansSwitch"
+
+	// TagOverrideSetBy records the webhook as the author of the tag changes
+	TagOverrideSetBy = "alertmanager-webhook"
 )
 
 // Start starts the webhook server
@@ -50,6 +68,16 @@ func (s *Server) Start() error {
 		Addr:              s.BindAddress,
 		ReadHeaderTimeout: 30 * time.Second,
 	}
+
+	if s.TLS != nil {
+		tlsConfig, err := tlsConfig(s.TLS)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsConfig
+		return server.ListenAndServeTLS(s.TLS.CertFile, s.TLS.KeyFile)
+	}
+
 	if err := server.ListenAndServe(); err != nil {
 		return err
 	}
@@ -67,8 +95,26 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}(r.Body)
 
-	body, err := io.ReadAll(r.Body)
+	if err := authenticate(s.Auth, r); err != nil {
+		s.Log.Error(err, "unauthorized request", "remoteAddr", r.RemoteAddr)
+		s.Metrics.RecordWebhookRejectedRequest(RejectedUnauthorized)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	maxRequestBytes := s.MaxRequestBytes
+	if maxRequestBytes <= 0 {
+		maxRequestBytes = DefaultMaxRequestBytes
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBytes))
 	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			s.Log.Error(err, "request body too large", "limit", maxRequestBytes)
+			s.Metrics.RecordWebhookRejectedRequest(RejectedTooLarge)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
 		s.Log.Error(err, "unable to read response body")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -77,14 +123,31 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 	err = json.Unmarshal(body, &alert)
 	if err != nil {
 		s.Log.Error(err, "unable to unmarshal response body")
+		s.Metrics.RecordWebhookRejectedRequest(RejectedInvalid)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
+	// Alertmanager repeats the notifications of the groups which did not
+	// change, they are acknowledged without being handled again. The
+	// DeadMansSwitch alert is expected to repeat, so it is never dropped.
+	now := time.Now()
+	dedup := alert.CommonLabels.AlertName() != DeadMansSwitchAlertName
+	if dedup && !s.dedup.reserve(alert, now, s.DedupWindow) {
+		s.Log.Info("dropped duplicate alert", "groupKey", alert.GroupKey, "status", alert.Status)
+		s.Metrics.RecordWebhookRejectedRequest(RejectedDuplicate)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	s.Log.Info("got alert", "alert", alert)
 	err = s.process(alert)
 	if err != nil {
+		if dedup {
+			s.dedup.release(alert, now)
+		}
 		s.Log.Error(err, "unable to handle alert", "alert", alert)
+		s.Metrics.RecordWebhookRejectedRequest(RejectedUnprocessed)
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -92,70 +155,189 @@ func (s *Server) webhookHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// tagUpdate holds the tags of an alert, and the rule which selects the
+// tagged clusters
+type tagUpdate struct {
+	rule   configv1.AlertRule
+	labels AlertLabels
+	tags   map[string]string
+
+	// ttl reverts the tags after it, if set
+	ttl    time.Duration
+	reason string
+}
+
 func (s *Server) process(alert Alert) error {
-	// DeadMansSwitchAlert should always fire
-	if alert.CommonLabels.Alertname == DeadMansSwitchAlertName && alert.Status == AlertStatusFiring {
-		s.Metrics.RecordDMSLastTimestamp()
-		s.Log.Info("received deadmansswitch", "alertname", DeadMansSwitchAlertName)
-		return nil
+	alerts := alert.Alerts
+	if len(alerts) == 0 {
+		// the group has no alert items, only its common labels
+		alerts = []AlertItem{{Status: alert.Status, Labels: alert.CommonLabels}}
 	}
 
-	for _, a := range s.AlertMap {
+	var updates []tagUpdate
+	mapped := false
+
+	// the alerts of a group are handled one by one, as their labels and
+	// status may differ
+	for _, item := range alerts {
+		status := item.Status
+		if status == "" {
+			status = alert.Status
+		}
 
-		// accept only preconfigured alerts
-		if a.AlertName != alert.CommonLabels.Alertname {
+		// DeadMansSwitchAlert should always fire
+		if item.Labels.AlertName() == DeadMansSwitchAlertName && status == AlertStatusFiring {
+			s.Metrics.RecordDMSLastTimestamp()
+			s.Log.Info("received deadmansswitch", "alertname", DeadMansSwitchAlertName)
+			mapped = true
 			continue
 		}
 
-		var tag map[string]string
+		for _, a := range s.AlertMap {
+			// accept only preconfigured alerts
+			matched, err := matchesAlert(a, item.Labels)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
+			}
+			mapped = true
+
+			update := tagUpdate{rule: a, labels: item.Labels}
+			if status == AlertStatusFiring {
+				s.Log.Info("OnFiring", "alert", item.Labels.AlertName(), "tag", a.OnFiring)
+				update.tags = a.OnFiring
+				// the tags are reverted if the resolved notification is lost
+				update.ttl = a.MaxDuration.Duration
+				update.reason = fmt.Sprintf("alert %s is firing", item.Labels.AlertName())
+			} else if status == AlertStatusResolved {
+				s.Log.Info("OnResolved", "alert", item.Labels.AlertName(), "tag", a.OnResolved)
+				update.tags = a.OnResolved
+			} else {
+				return fmt.Errorf("invalid alert status")
+			}
+			updates = append(updates, update)
+		}
+	}
+
+	if !mapped {
+		return fmt.Errorf("unmapped alert received via webhook")
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return retry(func() error {
+		return s.updateClusterTags(updates)
+	}, 3)
+}
 
-		if alert.Status == AlertStatusFiring {
-			s.Log.Info("OnFiring", "alert", alert.CommonLabels.Alertname, "tag", a.OnFiring)
-			tag = a.OnFiring
-		} else if alert.Status == AlertStatusResolved {
-			s.Log.Info("OnResolved", "alert", alert.CommonLabels.Alertname, "tag", a.OnResolved)
-			tag = a.OnResolved
-		} else {
-			return fmt.Errorf("invalid alert status")
+// matchesAlert returns whether the alert has the name of the rule and matches
+// all of its matchers
+func matchesAlert(rule configv1.AlertRule, alertLabels AlertLabels) (bool, error) {
+	if rule.AlertName == "" && len(rule.Matchers) == 0 {
+		return false, nil
+	}
+	if rule.AlertName != "" && rule.AlertName != alertLabels.AlertName() {
+		return false, nil
+	}
+
+	for _, m := range rule.Matchers {
+		value := alertLabels[m.Name]
+		if !m.Regex {
+			if value != m.Value {
+				return false, nil
+			}
+			continue
 		}
 
-		return retry(s.updateClusterTags, tag, 3)
+		// the regular expressions match the whole value, like in Alertmanager
+		re, err := regexp.Compile("^(?:" + m.Value + ")$")
+		if err != nil {
+			return false, fmt.Errorf("invalid regex of the %s matcher: %w", m.Name, err)
+		}
+		if !re.MatchString(value) {
+			return false, nil
+		}
 	}
-	return fmt.Errorf("unmapped alert received via webhook")
+	return true, nil
 }
 
-func (s *Server) updateClusterTags(tag map[string]string) error {
+// targetsCluster returns whether the cluster is named by the cluster label of
+// the alert and selected by the cluster selector of the rule
+func targetsCluster(rule configv1.AlertRule, alertLabels AlertLabels, cluster *registryv1.Cluster) (bool, error) {
+	if rule.ClusterLabel != "" {
+		name := alertLabels[rule.ClusterLabel]
+		if name == "" || (name != cluster.Name && name != cluster.Spec.Name) {
+			return false, nil
+		}
+	}
+
+	if rule.ClusterSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(rule.ClusterSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid cluster selector: %w", err)
+		}
+		if !selector.Matches(labels.Set(cluster.Labels)) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
 
+func (s *Server) updateClusterTags(updates []tagUpdate) error {
 	clusterList := &registryv1.ClusterList{}
 	err := s.Client.List(context.TODO(), clusterList, &client.ListOptions{Namespace: s.Namespace})
 	if err != nil {
 		return err
 	}
 
+	now := time.Now()
 	for i := range clusterList.Items {
-		var excludedTagsAnnotation string
-		var excludedTags []string
 		cluster := &clusterList.Items[i]
 
-		if cluster.Spec.Tags == nil {
-			cluster.Spec.Tags = make(map[string]string)
-		}
+		changed := false
+		for _, u := range updates {
+			targeted, err := targetsCluster(u.rule, u.labels, cluster)
+			if err != nil {
+				return err
+			}
+			if !targeted {
+				continue
+			}
 
-		excludedTagsAnnotation = cluster.Annotations["registry.ethos.adobe.com/excluded-tags"]
+			// skip processing tags which are in excluded-tags list
+			for key, value := range u.tags {
+				if cluster.TagExcluded(key) {
+					continue
+				}
 
-		if excludedTagsAnnotation != "" {
-			excludedTags = strings.Split(excludedTagsAnnotation, ",")
-		}
+				if u.ttl > 0 {
+					if cluster.Spec.TagOverrides == nil {
+						cluster.Spec.TagOverrides = make(map[string]registryv1.TagOverride)
+					}
+					cluster.Spec.TagOverrides[key] = cluster.Spec.NewTagOverride(key, value, now.Add(u.ttl), TagOverrideSetBy, u.reason)
+					changed = true
+				} else if _, ok := cluster.Spec.TagOverrides[key]; ok {
+					// the tag is set for good, it is not reverted anymore
+					delete(cluster.Spec.TagOverrides, key)
+					changed = true
+				}
 
-		// skip processing tags which are in excluded-tags list
-		for key, value := range tag {
-			if contains(key, excludedTags) {
-				continue
+				if cluster.Spec.Tags == nil {
+					cluster.Spec.Tags = make(map[string]string)
+				}
+				if tagValue, ok := cluster.Spec.Tags[key]; !ok || tagValue != value {
+					cluster.Spec.Tags[key] = value
+					changed = true
+				}
 			}
-			cluster.Spec.Tags[key] = value
 		}
 
-		if err := s.Client.Update(context.TODO(), &clusterList.Items[i], &client.UpdateOptions{}); err != nil {
+		if !changed {
+			continue
+		}
+		if err := s.Client.Update(context.TODO(), cluster, &client.UpdateOptions{}); err != nil {
 			return err
 		}
 	}
@@ -163,10 +345,10 @@ func (s *Server) updateClusterTags(tag map[string]string) error {
 }
 
 // Retry function for updateClusterTags
-func retry(f func(map[string]string) error, params map[string]string, attempts int) error {
+func retry(f func() error, attempts int) error {
 	var err error
 	for i := 0; i < attempts; i++ {
-		err = f(params)
+		err = f()
 		if err == nil {
 			return nil
 		}
@@ -174,12 +356,3 @@ func retry(f func(map[string]string) error, params map[string]string, attempts i
 	}
 	return fmt.Errorf("after %d attempts, last error: %s", attempts, err)
 }
-
-func contains(item string, slice []string) bool {
-	for _, s := range slice {
-		if s == item {
-			return true
-		}
-	}
-	return false
-}
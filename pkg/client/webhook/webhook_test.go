@@ -55,17 +55,17 @@ func newTestAlert(name string, status string) *Alert {
 			{
 				Status: status,
 				Labels: AlertLabels{
-					Alertname: name,
+					LabelAlertName: name,
 				},
 				StartsAt: time.Now(),
 				EndsAt:   time.Now().Add(time.Minute),
 			},
 		},
-		GroupLabels: GroupLabels{
-			Alertname: name,
+		GroupLabels: AlertLabels{
+			LabelAlertName: name,
 		},
-		CommonLabels: CommonLabels{
-			Alertname: name,
+		CommonLabels: AlertLabels{
+			LabelAlertName: name,
 		},
 	}
 }
@@ -21,6 +21,19 @@ import (
 	"github.com/labstack/gommon/log"
 )
 
+// Backends of the queue used between the clients and the API server
+const (
+	QueueBackendSQS    = "sqs"
+	QueueBackendRedis  = "redis"
+	QueueBackendMemory = "memory"
+)
+
+// Blob stores of the message bodies which are too large for the queue
+const (
+	BlobStoreS3         = "s3"
+	BlobStoreFilesystem = "filesystem"
+)
+
 type AppConfig struct {
 	ApiRateLimiterEnabled   bool
 	ApiHost                 string
@@ -32,12 +45,32 @@ type AppConfig struct {
 	LogLevel                log.Lvl
 	OidcClientId            string
 	OidcIssuerUrl           string
+	QueueBackend            string
+	QueueRedisHost          string
+	QueueRedisTLSEnabled    bool
+	QueueRedisStream        string
+	QueueRedisGroup         string
 	SqsEndpoint             string
 	SqsAwsRegion            string
 	SqsQueueName            string
 	SqsBatchSize            int64
 	SqsWaitSeconds          int64
 	SqsRunInterval          int
+	QueueMaxAttempts        int
+	QueueCompressThreshold  int
+	QueueOffloadThreshold   int
+	QueueMaxBodySize        int
+	QueueBlobStore          string
+	QueueBlobS3Bucket       string
+	QueueBlobS3Region       string
+	QueueBlobS3Endpoint     string
+	QueueBlobDir            string
+	QueueSigningIdentity    string
+	QueueSigningKeyFile     string
+	QueueTrustedKeysFile    string
+	QueueSignatureMaxAge    time.Duration
+	QueueAckName            string
+	QueueAckPrefix          string
 	K8sResourceId           string
 	ApiTenantId             string
 	ApiClientId             string
@@ -46,6 +79,10 @@ type AppConfig struct {
 	ApiCacheTTL             time.Duration
 	ApiCacheRedisHost       string
 	ApiCacheRedisTLSEnabled bool
+	ApiStaleAfter           time.Duration
+	TracingEnabled          bool
+	TracingEndpoint         string
+	TracingSampleRatio      float64
 }
 
 func LoadApiConfig() (*AppConfig, error) {
@@ -71,21 +108,6 @@ func LoadApiConfig() (*AppConfig, error) {
 
 	dbIndexName := getEnv("DB_INDEX_NAME", "")
 
-	sqsEndpoint := getEnv("SQS_ENDPOINT", "")
-	if sqsEndpoint == "" {
-		return nil, fmt.Errorf("environment variable SQS_ENDPOINT is not set")
-	}
-
-	sqsAwsRegion := getEnv("SQS_AWS_REGION", "")
-	if sqsAwsRegion == "" {
-		return nil, fmt.Errorf("environment variable SQS_AWS_REGION is not set")
-	}
-
-	sqsQueueName := getEnv("SQS_QUEUE_NAME", "")
-	if sqsQueueName == "" {
-		return nil, fmt.Errorf("environment variable SQS_QUEUE_NAME is not set")
-	}
-
 	sqsBatchSize := getEnv("SQS_BATCH_SIZE", "")
 	if sqsBatchSize == "" {
 		return nil, fmt.Errorf("environment variable SQS_BATCH_SIZE is not set")
@@ -113,6 +135,23 @@ func LoadApiConfig() (*AppConfig, error) {
 		return nil, fmt.Errorf("error parsing SQS_RUN_INTERVAL: %v", err)
 	}
 
+	queueMaxAttempts, err := strconv.Atoi(getEnv("QUEUE_MAX_ATTEMPTS", "5"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing QUEUE_MAX_ATTEMPTS: %v", err)
+	}
+	if queueMaxAttempts < 1 {
+		return nil, fmt.Errorf("QUEUE_MAX_ATTEMPTS should be at least 1")
+	}
+
+	// the cluster updates are only verified if trusted keys are configured
+	queueTrustedKeysFile := getEnv("QUEUE_TRUSTED_KEYS_FILE", "")
+
+	// the signed messages are only accepted for this long, zero accepts them forever
+	queueSignatureMaxAge, err := time.ParseDuration(getEnv("QUEUE_SIGNATURE_MAX_AGE", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing QUEUE_SIGNATURE_MAX_AGE: %v", err)
+	}
+
 	oidcClientId := getEnv("OIDC_CLIENT_ID", "")
 	if oidcClientId == "" {
 		return nil, fmt.Errorf("environment variable OIDC_CLIENT_ID is not set")
@@ -170,6 +209,12 @@ func LoadApiConfig() (*AppConfig, error) {
 		return nil, fmt.Errorf("error parsing API_CACHE_TTL: %v", err)
 	}
 
+	// a few heartbeat intervals of the clients
+	apiStaleAfter, err := time.ParseDuration(getEnv("API_STALE_AFTER", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing API_STALE_AFTER: %v", err)
+	}
+
 	apiCacheRedisHost := getEnv("API_CACHE_REDIS_HOST", "")
 	if apiCacheRedisHost == "" {
 		return nil, fmt.Errorf("environment variable API_CACHE_REDIS_HOST is not set")
@@ -181,18 +226,18 @@ func LoadApiConfig() (*AppConfig, error) {
 		return nil, fmt.Errorf("error parsing API_CACHE_REDIS_TLS_ENABLED: %v", err)
 	}
 
-	return &AppConfig{
+	appConfig := &AppConfig{
 		AwsRegion:               awsRegion,
 		DbEndpoint:              dbEndpoint,
 		DbAwsRegion:             dbAwsRegion,
 		DbTableName:             dbTableName,
 		DbIndexName:             dbIndexName,
-		SqsEndpoint:             sqsEndpoint,
-		SqsAwsRegion:            sqsAwsRegion,
-		SqsQueueName:            sqsQueueName,
 		SqsBatchSize:            sqsBatchSizeInt,
 		SqsWaitSeconds:          sqsWaitSecondsInt,
 		SqsRunInterval:          sqsRunIntervalInt,
+		QueueMaxAttempts:        queueMaxAttempts,
+		QueueTrustedKeysFile:    queueTrustedKeysFile,
+		QueueSignatureMaxAge:    queueSignatureMaxAge,
 		OidcClientId:            oidcClientId,
 		OidcIssuerUrl:           oidcIssuerUrl,
 		ApiRateLimiterEnabled:   apiRateLimiterEnabled,
@@ -206,30 +251,210 @@ func LoadApiConfig() (*AppConfig, error) {
 		ApiCacheTTL:             apiCacheTTL,
 		ApiCacheRedisHost:       apiCacheRedisHost,
 		ApiCacheRedisTLSEnabled: apiCacheRedisTLSEnabledBool,
-	}, nil
+		ApiStaleAfter:           apiStaleAfter,
+	}
+
+	if err := loadQueueConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	// the API server only acknowledges to the queues with this prefix, so that
+	// the senders cannot make it write to any queue
+	appConfig.QueueAckPrefix = getEnv("QUEUE_ACK_PREFIX", defaultAckPrefix(appConfig))
+
+	// the API server signs the dead letters it replays, which are trusted as
+	// signed by the cluster
+	if err := loadSigningConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	if err := loadTracingConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	return appConfig, nil
+}
+
+// defaultAckPrefix names the acknowledgement queues after the update queue,
+// such as cluster-registry-acks for cluster-registry
+func defaultAckPrefix(appConfig *AppConfig) string {
+	switch appConfig.QueueBackend {
+	case QueueBackendSQS:
+		return appConfig.SqsQueueName + "-"
+	case QueueBackendRedis:
+		return appConfig.QueueRedisStream + "-"
+	default:
+		return ""
+	}
 }
 
 func LoadClientConfig() (*AppConfig, error) {
-	sqsEndpoint := getEnv("SQS_ENDPOINT", "")
-	if sqsEndpoint == "" {
-		return nil, fmt.Errorf("environment variable SQS_ENDPOINT is not set")
+	appConfig := &AppConfig{}
+
+	if err := loadQueueConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	if err := loadSigningConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	// the queue where the API server acknowledges the cluster updates
+	appConfig.QueueAckName = getEnv("QUEUE_ACK_NAME", "")
+
+	if err := loadTracingConfig(appConfig); err != nil {
+		return nil, err
+	}
+
+	return appConfig, nil
+}
+
+// loadQueueConfig reads the settings of the queue backend shared by all components
+func loadQueueConfig(appConfig *AppConfig) error {
+	appConfig.QueueBackend = getEnv("QUEUE_BACKEND", QueueBackendSQS)
+
+	switch appConfig.QueueBackend {
+	case QueueBackendSQS:
+		appConfig.SqsEndpoint = getEnv("SQS_ENDPOINT", "")
+		if appConfig.SqsEndpoint == "" {
+			return fmt.Errorf("environment variable SQS_ENDPOINT is not set")
+		}
+
+		appConfig.SqsAwsRegion = getEnv("SQS_AWS_REGION", "")
+		if appConfig.SqsAwsRegion == "" {
+			return fmt.Errorf("environment variable SQS_AWS_REGION is not set")
+		}
+
+		appConfig.SqsQueueName = getEnv("SQS_QUEUE_NAME", "")
+		if appConfig.SqsQueueName == "" {
+			return fmt.Errorf("environment variable SQS_QUEUE_NAME is not set")
+		}
+
+	case QueueBackendRedis:
+		appConfig.QueueRedisHost = getEnv("QUEUE_REDIS_HOST", "")
+		if appConfig.QueueRedisHost == "" {
+			return fmt.Errorf("environment variable QUEUE_REDIS_HOST is not set")
+		}
+
+		tlsEnabled, err := strconv.ParseBool(getEnv("QUEUE_REDIS_TLS_ENABLED", "true"))
+		if err != nil {
+			return fmt.Errorf("error parsing QUEUE_REDIS_TLS_ENABLED: %v", err)
+		}
+		appConfig.QueueRedisTLSEnabled = tlsEnabled
+
+		appConfig.QueueRedisStream = getEnv("QUEUE_REDIS_STREAM", "cluster-registry")
+		appConfig.QueueRedisGroup = getEnv("QUEUE_REDIS_GROUP", "cluster-registry")
+
+	case QueueBackendMemory:
+
+	default:
+		return fmt.Errorf("unknown QUEUE_BACKEND %s, should be one of %s, %s or %s",
+			appConfig.QueueBackend, QueueBackendSQS, QueueBackendRedis, QueueBackendMemory)
+	}
+
+	return loadBlobConfig(appConfig)
+}
+
+// loadBlobConfig reads the settings of the compression and offloading of the
+// message bodies, which must match between the producers and the consumers
+func loadBlobConfig(appConfig *AppConfig) error {
+	compressThreshold, err := strconv.Atoi(getEnv("QUEUE_COMPRESS_THRESHOLD", "1024"))
+	if err != nil {
+		return fmt.Errorf("error parsing QUEUE_COMPRESS_THRESHOLD: %v", err)
+	}
+	if compressThreshold < 0 {
+		return fmt.Errorf("QUEUE_COMPRESS_THRESHOLD should not be negative")
+	}
+	appConfig.QueueCompressThreshold = compressThreshold
+
+	// SQS messages are limited to 256 KB, including the attributes
+	offloadThreshold, err := strconv.Atoi(getEnv("QUEUE_OFFLOAD_THRESHOLD", "196608"))
+	if err != nil {
+		return fmt.Errorf("error parsing QUEUE_OFFLOAD_THRESHOLD: %v", err)
+	}
+	if offloadThreshold < 0 {
+		return fmt.Errorf("QUEUE_OFFLOAD_THRESHOLD should not be negative")
+	}
+	appConfig.QueueOffloadThreshold = offloadThreshold
+
+	// the decompressed bodies are bounded, for a small compressed body not to
+	// exhaust the memory of the consumers
+	maxBodySize, err := strconv.Atoi(getEnv("QUEUE_MAX_BODY_SIZE", "4194304"))
+	if err != nil {
+		return fmt.Errorf("error parsing QUEUE_MAX_BODY_SIZE: %v", err)
+	}
+	if maxBodySize <= 0 {
+		return fmt.Errorf("QUEUE_MAX_BODY_SIZE should be positive")
 	}
+	appConfig.QueueMaxBodySize = maxBodySize
 
-	sqsAwsRegion := getEnv("SQS_AWS_REGION", "")
-	if sqsAwsRegion == "" {
-		return nil, fmt.Errorf("environment variable SQS_AWS_REGION is not set")
+	appConfig.QueueBlobStore = getEnv("QUEUE_BLOB_STORE", "")
+
+	switch appConfig.QueueBlobStore {
+	case "":
+
+	case BlobStoreS3:
+		appConfig.QueueBlobS3Bucket = getEnv("QUEUE_BLOB_S3_BUCKET", "")
+		if appConfig.QueueBlobS3Bucket == "" {
+			return fmt.Errorf("environment variable QUEUE_BLOB_S3_BUCKET is not set")
+		}
+
+		appConfig.QueueBlobS3Region = getEnv("QUEUE_BLOB_S3_REGION", "")
+		if appConfig.QueueBlobS3Region == "" {
+			return fmt.Errorf("environment variable QUEUE_BLOB_S3_REGION is not set")
+		}
+
+		appConfig.QueueBlobS3Endpoint = getEnv("QUEUE_BLOB_S3_ENDPOINT", "")
+
+	case BlobStoreFilesystem:
+		appConfig.QueueBlobDir = getEnv("QUEUE_BLOB_DIR", "")
+		if appConfig.QueueBlobDir == "" {
+			return fmt.Errorf("environment variable QUEUE_BLOB_DIR is not set")
+		}
+
+	default:
+		return fmt.Errorf("unknown QUEUE_BLOB_STORE %s, should be one of %s or %s",
+			appConfig.QueueBlobStore, BlobStoreS3, BlobStoreFilesystem)
+	}
+
+	return nil
+}
+
+// loadSigningConfig reads the key which signs the messages sent by a client, or
+// replayed by the API server
+func loadSigningConfig(appConfig *AppConfig) error {
+	appConfig.QueueSigningKeyFile = getEnv("QUEUE_SIGNING_KEY_FILE", "")
+	if appConfig.QueueSigningKeyFile == "" {
+		return nil
 	}
 
-	sqsQueueName := getEnv("SQS_QUEUE_NAME", "")
-	if sqsQueueName == "" {
-		return nil, fmt.Errorf("environment variable SQS_QUEUE_NAME is not set")
+	appConfig.QueueSigningIdentity = getEnv("QUEUE_SIGNING_IDENTITY", "")
+	if appConfig.QueueSigningIdentity == "" {
+		return fmt.Errorf("environment variable QUEUE_SIGNING_IDENTITY is not set")
+	}
+
+	return nil
+}
+
+// loadTracingConfig reads the OpenTelemetry settings shared by all components
+func loadTracingConfig(appConfig *AppConfig) error {
+	tracingEnabled, err := strconv.ParseBool(getEnv("TRACING_ENABLED", "false"))
+	if err != nil {
+		return fmt.Errorf("error parsing TRACING_ENABLED: %v", err)
+	}
+
+	tracingSampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1.0"), 64)
+	if err != nil {
+		return fmt.Errorf("error parsing TRACING_SAMPLE_RATIO: %v", err)
+	}
+	if tracingSampleRatio < 0 || tracingSampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO should be between 0 and 1")
 	}
 
-	return &AppConfig{
-		SqsEndpoint:  sqsEndpoint,
-		SqsAwsRegion: sqsAwsRegion,
-		SqsQueueName: sqsQueueName,
-	}, nil
+	appConfig.TracingEnabled = tracingEnabled
+	appConfig.TracingEndpoint = getEnv("TRACING_OTLP_ENDPOINT", "")
+	appConfig.TracingSampleRatio = tracingSampleRatio
+	return nil
 }
 
 func getEnv(varName string, defaultValue string) string {
@@ -87,6 +87,9 @@ func TestLoadApiConfig(t *testing.T) {
 				"SQS_BATCH_SIZE":              "10",
 				"SQS_WAIT_SECONDS":            "5",
 				"SQS_RUN_INTERVAL":            "30",
+				"QUEUE_MAX_ATTEMPTS":          "3",
+				"QUEUE_TRUSTED_KEYS_FILE":     "/etc/cluster-registry/trusted-keys.yaml",
+				"QUEUE_SIGNATURE_MAX_AGE":     "30m",
 				"API_HOST":                    "custom-host:8080",
 				"K8S_RESOURCE_ID":             "k8s-resource-id",
 				"API_TENANT_ID":               "api-tenant-id",
@@ -96,6 +99,9 @@ func TestLoadApiConfig(t *testing.T) {
 				"API_CACHE_TTL":               "1h",
 				"API_CACHE_REDIS_HOST":        "localhost:6379",
 				"API_CACHE_REDIS_TLS_ENABLED": "true",
+				"TRACING_ENABLED":             "true",
+				"TRACING_OTLP_ENDPOINT":       "http://localhost:4318",
+				"TRACING_SAMPLE_RATIO":        "0.5",
 			},
 			expectedAppConfig: &AppConfig{
 				ApiRateLimiterEnabled:   true,
@@ -108,20 +114,32 @@ func TestLoadApiConfig(t *testing.T) {
 				LogLevel:                log.DEBUG,
 				OidcClientId:            "oidc-client-id",
 				OidcIssuerUrl:           "http://fake-oidc-provider",
+				QueueBackend:            QueueBackendSQS,
 				SqsEndpoint:             "http://localhost:9324",
 				SqsAwsRegion:            "sqs-aws-region",
 				SqsQueueName:            "cluster-registry-local",
 				SqsBatchSize:            10,
 				SqsWaitSeconds:          5,
 				SqsRunInterval:          30,
+				QueueMaxAttempts:        3,
+				QueueTrustedKeysFile:    "/etc/cluster-registry/trusted-keys.yaml",
+				QueueSignatureMaxAge:    30 * time.Minute,
+				QueueAckPrefix:          "cluster-registry-local-",
+				QueueCompressThreshold:  1024,
+				QueueOffloadThreshold:   196608,
+				QueueMaxBodySize:        4194304,
 				K8sResourceId:           "k8s-resource-id",
 				ApiTenantId:             "api-tenant-id",
 				ApiClientId:             "api-client-id",
 				ApiClientSecret:         "api-client-secret",
 				ApiAuthorizedGroupId:    "api-authorized-group-id",
 				ApiCacheTTL:             time.Hour,
+				ApiStaleAfter:           15 * time.Minute,
 				ApiCacheRedisHost:       "localhost:6379",
 				ApiCacheRedisTLSEnabled: true,
+				TracingEnabled:          true,
+				TracingEndpoint:         "http://localhost:4318",
+				TracingSampleRatio:      0.5,
 			},
 			expectedError: nil,
 		},
@@ -204,12 +222,186 @@ func TestLoadClientConfig(t *testing.T) {
 				"SQS_QUEUE_NAME": "cluster-registry-local",
 			},
 			expectedAppConfig: &AppConfig{
-				SqsEndpoint:  "http://localhost:9324",
-				SqsAwsRegion: "sqs-aws-region",
-				SqsQueueName: "cluster-registry-local",
+				QueueBackend:           QueueBackendSQS,
+				SqsEndpoint:            "http://localhost:9324",
+				SqsAwsRegion:           "sqs-aws-region",
+				SqsQueueName:           "cluster-registry-local",
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				TracingSampleRatio:     1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid app config with acknowledgements",
+			envVars: map[string]string{
+				"SQS_ENDPOINT":   "http://localhost:9324",
+				"SQS_AWS_REGION": "sqs-aws-region",
+				"SQS_QUEUE_NAME": "cluster-registry-local",
+				"QUEUE_ACK_NAME": "cluster-registry-local-acks",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:           QueueBackendSQS,
+				SqsEndpoint:            "http://localhost:9324",
+				SqsAwsRegion:           "sqs-aws-region",
+				SqsQueueName:           "cluster-registry-local",
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				QueueAckName:           "cluster-registry-local-acks",
+				TracingSampleRatio:     1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid redis app config",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":           "redis",
+				"QUEUE_REDIS_HOST":        "localhost:6379",
+				"QUEUE_REDIS_TLS_ENABLED": "false",
+				"QUEUE_REDIS_STREAM":      "cluster-registry-local",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:           QueueBackendRedis,
+				QueueRedisHost:         "localhost:6379",
+				QueueRedisTLSEnabled:   false,
+				QueueRedisStream:       "cluster-registry-local",
+				QueueRedisGroup:        "cluster-registry",
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				TracingSampleRatio:     1,
 			},
 			expectedError: nil,
 		},
+		{
+			name: "valid memory app config",
+			envVars: map[string]string{
+				"QUEUE_BACKEND": "memory",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:           QueueBackendMemory,
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				TracingSampleRatio:     1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid filesystem blob store app config",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":            "memory",
+				"QUEUE_COMPRESS_THRESHOLD": "0",
+				"QUEUE_OFFLOAD_THRESHOLD":  "65536",
+				"QUEUE_BLOB_STORE":         "filesystem",
+				"QUEUE_BLOB_DIR":           "/tmp/cluster-registry",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:          QueueBackendMemory,
+				QueueOffloadThreshold: 65536,
+				QueueMaxBodySize:      4194304,
+				QueueBlobStore:        BlobStoreFilesystem,
+				QueueBlobDir:          "/tmp/cluster-registry",
+				TracingSampleRatio:    1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid s3 blob store app config",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":        "memory",
+				"QUEUE_BLOB_STORE":     "s3",
+				"QUEUE_BLOB_S3_BUCKET": "cluster-registry-payloads",
+				"QUEUE_BLOB_S3_REGION": "us-east-1",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:           QueueBackendMemory,
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				QueueBlobStore:         BlobStoreS3,
+				QueueBlobS3Bucket:      "cluster-registry-payloads",
+				QueueBlobS3Region:      "us-east-1",
+				TracingSampleRatio:     1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "valid signing app config",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":          "memory",
+				"QUEUE_SIGNING_KEY_FILE": "/etc/cluster-registry/signing.pem",
+				"QUEUE_SIGNING_IDENTITY": "cluster1-prod-useast1",
+			},
+			expectedAppConfig: &AppConfig{
+				QueueBackend:           QueueBackendMemory,
+				QueueCompressThreshold: 1024,
+				QueueOffloadThreshold:  196608,
+				QueueMaxBodySize:       4194304,
+				QueueSigningKeyFile:    "/etc/cluster-registry/signing.pem",
+				QueueSigningIdentity:   "cluster1-prod-useast1",
+				TracingSampleRatio:     1,
+			},
+			expectedError: nil,
+		},
+		{
+			name: "signing key without identity",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":          "memory",
+				"QUEUE_SIGNING_KEY_FILE": "/etc/cluster-registry/signing.pem",
+			},
+			expectedError: fmt.Errorf("environment variable QUEUE_SIGNING_IDENTITY is not set"),
+		},
+		{
+			name: "s3 blob store without bucket",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":    "memory",
+				"QUEUE_BLOB_STORE": "s3",
+			},
+			expectedError: fmt.Errorf("environment variable QUEUE_BLOB_S3_BUCKET is not set"),
+		},
+		{
+			name: "unknown blob store",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":    "memory",
+				"QUEUE_BLOB_STORE": "gcs",
+			},
+			expectedError: fmt.Errorf("unknown QUEUE_BLOB_STORE gcs"),
+		},
+		{
+			name: "invalid offload threshold",
+			envVars: map[string]string{
+				"QUEUE_BACKEND":           "memory",
+				"QUEUE_OFFLOAD_THRESHOLD": "-1",
+			},
+			expectedError: fmt.Errorf("QUEUE_OFFLOAD_THRESHOLD should not be negative"),
+		},
+		{
+			name: "redis app config without host",
+			envVars: map[string]string{
+				"QUEUE_BACKEND": "redis",
+			},
+			expectedError: fmt.Errorf("environment variable QUEUE_REDIS_HOST is not set"),
+		},
+		{
+			name: "unknown queue backend",
+			envVars: map[string]string{
+				"QUEUE_BACKEND": "kafka",
+			},
+			expectedError: fmt.Errorf("unknown QUEUE_BACKEND kafka"),
+		},
+		{
+			name: "invalid tracing sample ratio",
+			envVars: map[string]string{
+				"SQS_ENDPOINT":         "http://localhost:9324",
+				"SQS_AWS_REGION":       "sqs-aws-region",
+				"SQS_QUEUE_NAME":       "cluster-registry-local",
+				"TRACING_SAMPLE_RATIO": "2",
+			},
+			expectedError: fmt.Errorf("TRACING_SAMPLE_RATIO should be between 0 and 1"),
+		},
 		{
 			name: "invalid app config",
 			envVars: map[string]string{
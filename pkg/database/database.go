@@ -14,6 +14,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/gusaul/go-dynamock"
 	"time"
@@ -21,13 +22,18 @@ import (
 	registryv1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
 	"github.com/adobe/cluster-registry/pkg/config"
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/apiserver"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
 	"github.com/labstack/gommon/log"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -36,16 +42,17 @@ const (
 
 // Db provides an interface for interacting with dynamoDb
 type Db interface {
-	GetCluster(name string) (*registryv1.Cluster, error)
-	ListClusters(offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error)
-	ListClustersWithFilter(offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error)
-	PutCluster(cluster *registryv1.Cluster) error
-	DeleteCluster(name string) error
-	Status() error
+	GetCluster(ctx context.Context, name string) (*registryv1.Cluster, error)
+	ListClusters(ctx context.Context, offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error)
+	ListClustersWithFilter(ctx context.Context, offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error)
+	PutCluster(ctx context.Context, cluster *registryv1.Cluster) error
+	UpdateLastSeen(ctx context.Context, name string, lastSeen time.Time) (bool, error)
+	DeleteCluster(ctx context.Context, name string) error
+	Status(ctx context.Context) error
 	Mock() *dynamock.DynaMock
-	ListClustersWithService(serviceId string, offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error)
-	ListClustersWithServiceAndFilter(serviceId string, offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error)
-	GetClusterWithService(serviceId string, clusterName string) (*registryv1.Cluster, error)
+	ListClustersWithService(ctx context.Context, serviceId string, offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error)
+	ListClustersWithServiceAndFilter(ctx context.Context, serviceId string, offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error)
+	GetClusterWithService(ctx context.Context, serviceId string, clusterName string) (*registryv1.Cluster, error)
 }
 
 // db struct
@@ -70,6 +77,7 @@ type ClusterDb struct {
 	Environment       string              `json:"environment"`
 	Status            string              `json:"status"`
 	LastUpdatedUnix   int64               `json:"lastUpdatedUnix"`
+	LastSeenUnix      int64               `json:"lastSeenUnix,omitempty"`
 	Cluster           *registryv1.Cluster `json:"crd"`
 }
 
@@ -111,15 +119,18 @@ func (d *db) Mock() *dynamock.DynaMock {
 }
 
 // Status checks if the database is reachable with a 5 sec timeout
-func (d *db) Status() error {
+func (d *db) Status(ctx context.Context) (err error) {
+	ctx, span := d.startSpan(ctx, "Status")
+	defer func() { endSpan(span, err) }()
+
 	params := &dynamodb.DescribeTableInput{
 		TableName: &d.table.name,
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := d.dbAPI.DescribeTableWithContext(ctx, params)
+	_, err = d.dbAPI.DescribeTableWithContext(ctx, params)
 	if err != nil {
 		d.metrics.RecordErrorCnt(egressTarget)
 		msg := fmt.Sprintf("Connectivity check using DescribeTable failed. Error: '%v'", err.Error())
@@ -131,7 +142,10 @@ func (d *db) Status() error {
 }
 
 // GetCluster a single cluster
-func (d *db) GetCluster(name string) (*registryv1.Cluster, error) {
+func (d *db) GetCluster(ctx context.Context, name string) (_ *registryv1.Cluster, err error) {
+	ctx, span := d.startSpan(ctx, "GetCluster", attribute.String("cluster.name", name))
+	defer func() { endSpan(span, err) }()
+
 	params := &dynamodb.GetItemInput{
 		TableName: &d.table.name,
 		Key: map[string]*dynamodb.AttributeValue{
@@ -142,7 +156,7 @@ func (d *db) GetCluster(name string) (*registryv1.Cluster, error) {
 	}
 
 	start := time.Now()
-	resp, err := d.dbAPI.GetItem(params)
+	resp, err := d.dbAPI.GetItemWithContext(ctx, params)
 	elapsed := float64(time.Since(start)) / float64(time.Second)
 
 	d.metrics.RecordEgressRequestCnt(egressTarget)
@@ -171,14 +185,15 @@ func (d *db) GetCluster(name string) (*registryv1.Cluster, error) {
 }
 
 // ListClusters list all clusters
-func (d *db) ListClusters(offset int, limit int, region string, environment string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error) {
+func (d *db) ListClusters(ctx context.Context, offset int, limit int, region string, environment string, status string, lastUpdated string) (_ []registryv1.Cluster, _ int, _ bool, err error) {
+	ctx, span := d.startSpan(ctx, "ListClusters")
+	defer func() { endSpan(span, err) }()
 
 	var clusters []registryv1.Cluster = []registryv1.Cluster{}
 	var queryInput *dynamodb.QueryInput
 	var filter expression.ConditionBuilder
 	var keyCondition expression.KeyConditionBuilder
 	var expr expression.Expression
-	var err error
 
 	if status != "" {
 		filter = expression.Name("status").Equal(expression.Value(status))
@@ -224,7 +239,7 @@ func (d *db) ListClusters(offset int, limit int, region string, environment stri
 
 	for {
 		start := time.Now()
-		result, err := d.dbAPI.Query(queryInput)
+		result, err := d.dbAPI.QueryWithContext(ctx, queryInput)
 		elapsed := float64(time.Since(start)) / float64(time.Second)
 
 		d.metrics.RecordEgressRequestCnt(egressTarget)
@@ -268,11 +283,13 @@ func (d *db) ListClusters(offset int, limit int, region string, environment stri
 	return clusters[startIndex:endIndex], endIndex - startIndex, more, err
 }
 
-func (d *db) ListClustersWithFilter(offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error) {
+func (d *db) ListClustersWithFilter(ctx context.Context, offset int, limit int, filter *DynamoDBFilter) (_ []registryv1.Cluster, _ int, _ bool, err error) {
+	ctx, span := d.startSpan(ctx, "ListClustersWithFilter")
+	defer func() { endSpan(span, err) }()
+
 	var clusters []registryv1.Cluster = []registryv1.Cluster{}
 	var scanInput *dynamodb.ScanInput
 	var expr expression.Expression
-	var err error
 
 	f, err := filter.Build()
 	if err != nil {
@@ -296,7 +313,7 @@ func (d *db) ListClustersWithFilter(offset int, limit int, filter *DynamoDBFilte
 
 	for {
 		start := time.Now()
-		result, err := d.dbAPI.Scan(scanInput)
+		result, err := d.dbAPI.ScanWithContext(ctx, scanInput)
 		elapsed := float64(time.Since(start)) / float64(time.Second)
 
 		d.metrics.RecordEgressRequestCnt(egressTarget)
@@ -341,7 +358,9 @@ func (d *db) ListClustersWithFilter(offset int, limit int, filter *DynamoDBFilte
 }
 
 // PutCluster (create/update) a cluster in database
-func (d *db) PutCluster(cluster *registryv1.Cluster) error {
+func (d *db) PutCluster(ctx context.Context, cluster *registryv1.Cluster) (err error) {
+	ctx, span := d.startSpan(ctx, "PutCluster", attribute.String("cluster.name", cluster.Spec.Name))
+	defer func() { endSpan(span, err) }()
 
 	lastUpdated, err := time.Parse(time.RFC3339, cluster.Spec.LastUpdated)
 	if err != nil {
@@ -350,7 +369,18 @@ func (d *db) PutCluster(cluster *registryv1.Cluster) error {
 		return fmt.Errorf("%s", msg)
 	}
 
-	existingCluster, _ := d.GetCluster(cluster.Spec.Name)
+	var lastSeenUnix int64
+	if cluster.Spec.LastSeen != "" {
+		lastSeen, err := time.Parse(time.RFC3339, cluster.Spec.LastSeen)
+		if err != nil {
+			msg := fmt.Sprintf("Error converting lastSeen parameter to RFC3339 for cluster %s: '%v'.", cluster.Spec.Name, err)
+			log.Errorf(msg)
+			return fmt.Errorf("%s", msg)
+		}
+		lastSeenUnix = lastSeen.Unix()
+	}
+
+	existingCluster, _ := d.GetCluster(ctx, cluster.Spec.Name)
 	if existingCluster != nil {
 		fmt.Printf("Cluster '%s' found in the database. It will be updated.", cluster.Spec.Name)
 		cluster.Spec.RegisteredAt = existingCluster.Spec.RegisteredAt
@@ -363,6 +393,7 @@ func (d *db) PutCluster(cluster *registryv1.Cluster) error {
 		Environment:       cluster.Spec.Environment,
 		Status:            cluster.Spec.Status,
 		LastUpdatedUnix:   lastUpdated.Unix(),
+		LastSeenUnix:      lastSeenUnix,
 		Cluster:           cluster,
 	})
 
@@ -378,7 +409,7 @@ func (d *db) PutCluster(cluster *registryv1.Cluster) error {
 	}
 
 	start := time.Now()
-	_, err = d.dbAPI.PutItem(params)
+	_, err = d.dbAPI.PutItemWithContext(ctx, params)
 	elapsed := float64(time.Since(start)) / float64(time.Second)
 
 	d.metrics.RecordEgressRequestCnt(egressTarget)
@@ -395,8 +426,66 @@ func (d *db) PutCluster(cluster *registryv1.Cluster) error {
 	return nil
 }
 
+// UpdateLastSeen records when the client of a cluster was last heard from,
+// without rewriting the rest of the record. It returns false if the cluster is
+// not registered, or was seen more recently.
+func (d *db) UpdateLastSeen(ctx context.Context, name string, lastSeen time.Time) (_ bool, err error) {
+	ctx, span := d.startSpan(ctx, "UpdateLastSeen", attribute.String("cluster.name", name))
+	defer func() { endSpan(span, err) }()
+
+	update := expression.
+		Set(expression.Name("crd.spec.lastSeen"), expression.Value(lastSeen.UTC().Format(time.RFC3339Nano))).
+		Set(expression.Name("lastSeenUnix"), expression.Value(lastSeen.Unix()))
+	condition := expression.Name("name").AttributeExists().And(
+		expression.Or(
+			expression.Name("lastSeenUnix").AttributeNotExists(),
+			expression.Name("lastSeenUnix").LessThanEqual(expression.Value(lastSeen.Unix())),
+		),
+	)
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return false, fmt.Errorf("cannot build lastSeen update for cluster %s: %v", name, err)
+	}
+
+	params := &dynamodb.UpdateItemInput{
+		TableName: &d.table.name,
+		Key: map[string]*dynamodb.AttributeValue{
+			"name": {
+				S: aws.String(name),
+			},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	start := time.Now()
+	_, err = d.dbAPI.UpdateItemWithContext(ctx, params)
+	elapsed := float64(time.Since(start)) / float64(time.Second)
+
+	d.metrics.RecordEgressRequestCnt(egressTarget)
+	d.metrics.RecordEgressRequestDur(egressTarget, elapsed)
+
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		log.Debugf("Cluster '%s' is not registered or was seen more recently.", name)
+		return false, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Cannot update lastSeen of cluster '%s' in the database. Error: '%v'", name, err.Error())
+		log.Errorf(msg)
+		return false, fmt.Errorf("%s", msg)
+	}
+
+	return true, nil
+}
+
 // DeleteCluster delete a cluster from database
-func (d *db) DeleteCluster(name string) error {
+func (d *db) DeleteCluster(ctx context.Context, name string) (err error) {
+	ctx, span := d.startSpan(ctx, "DeleteCluster", attribute.String("cluster.name", name))
+	defer func() { endSpan(span, err) }()
+
 	params := &dynamodb.DeleteItemInput{
 		TableName: &d.table.name,
 		Key: map[string]*dynamodb.AttributeValue{
@@ -407,7 +496,7 @@ func (d *db) DeleteCluster(name string) error {
 	}
 
 	start := time.Now()
-	_, err := d.dbAPI.DeleteItem(params)
+	_, err = d.dbAPI.DeleteItemWithContext(ctx, params)
 	elapsed := float64(time.Since(start)) / float64(time.Second)
 
 	d.metrics.RecordEgressRequestCnt(egressTarget)
@@ -425,8 +514,11 @@ func (d *db) DeleteCluster(name string) error {
 }
 
 // ListClustersWithService gets service metadata for a given serviceId on all clusters
-func (d *db) ListClustersWithService(serviceId string, offset int, limit int, environment string, region string, status string, lastUpdated string) ([]registryv1.Cluster, int, bool, error) {
-	clusters, _, _, err := d.ListClusters(offset, limit, environment, region, status, lastUpdated)
+func (d *db) ListClustersWithService(ctx context.Context, serviceId string, offset int, limit int, environment string, region string, status string, lastUpdated string) (_ []registryv1.Cluster, _ int, _ bool, err error) {
+	ctx, span := d.startSpan(ctx, "ListClustersWithService", attribute.String("service.id", serviceId))
+	defer func() { endSpan(span, err) }()
+
+	clusters, _, _, err := d.ListClusters(ctx, offset, limit, environment, region, status, lastUpdated)
 
 	var clustersWithService []registryv1.Cluster
 
@@ -456,8 +548,11 @@ func (d *db) ListClustersWithService(serviceId string, offset int, limit int, en
 }
 
 // ListClustersWithServiceAndFilter gets service metadata for a given serviceId on all clusters with additional filtering options
-func (d *db) ListClustersWithServiceAndFilter(serviceId string, offset int, limit int, filter *DynamoDBFilter) ([]registryv1.Cluster, int, bool, error) {
-	clusters, _, _, err := d.ListClustersWithFilter(offset, limit, filter)
+func (d *db) ListClustersWithServiceAndFilter(ctx context.Context, serviceId string, offset int, limit int, filter *DynamoDBFilter) (_ []registryv1.Cluster, _ int, _ bool, err error) {
+	ctx, span := d.startSpan(ctx, "ListClustersWithServiceAndFilter", attribute.String("service.id", serviceId))
+	defer func() { endSpan(span, err) }()
+
+	clusters, _, _, err := d.ListClustersWithFilter(ctx, offset, limit, filter)
 
 	if err != nil {
 		msg := fmt.Sprintf("Failed to list clusters with filter: '%v'.", err)
@@ -493,8 +588,11 @@ func (d *db) ListClustersWithServiceAndFilter(serviceId string, offset int, limi
 }
 
 // GetClusterWithService gets service metadata for a given serviceId on a given cluster
-func (d *db) GetClusterWithService(serviceId string, clusterName string) (*registryv1.Cluster, error) {
-	cluster, err := d.GetCluster(clusterName)
+func (d *db) GetClusterWithService(ctx context.Context, serviceId string, clusterName string) (_ *registryv1.Cluster, err error) {
+	ctx, span := d.startSpan(ctx, "GetClusterWithService", attribute.String("service.id", serviceId), attribute.String("cluster.name", clusterName))
+	defer func() { endSpan(span, err) }()
+
+	cluster, err := d.GetCluster(ctx, clusterName)
 
 	if err != nil {
 		return nil, err
@@ -515,3 +613,22 @@ func (d *db) GetClusterWithService(serviceId string, clusterName string) (*regis
 
 	return nil, err
 }
+
+// startSpan starts a client span for a database operation
+func (d *db) startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		semconv.DBSystemDynamoDB,
+		semconv.DBOperationName(operation),
+		semconv.AWSDynamoDBTableNames(d.table.name),
+	)
+	return tracing.Tracer().Start(ctx, "database."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// endSpan records the error of the operation, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	tracing.RecordError(span, err)
+	span.End()
+}
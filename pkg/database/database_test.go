@@ -13,6 +13,7 @@ governing permissions and limitations under the License.
 package database
 
 import (
+	"context"
 	"fmt"
 	"github.com/adobe/cluster-registry/pkg/apiserver/models"
 	"k8s.io/utils/ptr"
@@ -62,7 +63,7 @@ var _ = Describe("Database Suite", func() {
 	Context("Database tests", func() {
 
 		It("Should handle DB status OK", func() {
-			err := db.Status()
+			err := db.Status(context.Background())
 			Expect(err).To(BeNil())
 		})
 
@@ -75,7 +76,7 @@ var _ = Describe("Database Suite", func() {
 			}
 			newM := monitoring.NewMetrics("cluster_registry_api_database_test_new", true)
 			newDb := NewDb(appConfig, newM)
-			err := newDb.Status()
+			err := newDb.Status(context.Background())
 			Expect(err.Error()).To(ContainSubstring("Cannot do operations on a non-existent table"))
 		})
 
@@ -192,7 +193,7 @@ var _ = Describe("Database Suite", func() {
 
 			for _, tc := range tcs {
 				By(fmt.Sprintf("TestCase %s:\t When getting cluster %s", tc.name, tc.clusterName))
-				c, err := db.GetCluster(tc.clusterName)
+				c, err := db.GetCluster(context.Background(), tc.clusterName)
 
 				Expect(err).To(BeNil())
 				if tc.expectedCluster == nil {
@@ -361,10 +362,10 @@ var _ = Describe("Database Suite", func() {
 			for _, tc := range tcs {
 				By(fmt.Sprintf("TestCase %s:\t When put cluster %s", tc.name, tc.clusterName))
 
-				err := db.PutCluster(tc.newCluster)
+				err := db.PutCluster(context.Background(), tc.newCluster)
 				Expect(err).To(BeNil())
 
-				c, err := db.GetCluster(tc.clusterName)
+				c, err := db.GetCluster(context.Background(), tc.clusterName)
 				Expect(err).To(BeNil())
 
 				Expect(c.Spec).To(Equal(tc.expectedCluster.Spec))
@@ -392,10 +393,10 @@ var _ = Describe("Database Suite", func() {
 			for _, tc := range tcs {
 				By(fmt.Sprintf("TestCase %s:\t When deleting cluster %s", tc.name, tc.clusterName))
 
-				err := db.DeleteCluster(tc.clusterName)
+				err := db.DeleteCluster(context.Background(), tc.clusterName)
 				Expect(err).To(BeNil())
 
-				c, err := db.GetCluster(tc.clusterName)
+				c, err := db.GetCluster(context.Background(), tc.clusterName)
 				Expect(err).To(BeNil())
 				Expect(c).To(BeNil())
 			}
@@ -884,6 +885,7 @@ var _ = Describe("Database Suite", func() {
 					tc.limit))
 
 				clusters, count, more, err := db.ListClusters(
+					context.Background(),
 					tc.offset,
 					tc.limit,
 					tc.queryParams["region"],
@@ -1702,6 +1704,7 @@ var _ = Describe("Database Suite", func() {
 				tc.limit))
 
 			clusters, count, more, err := db.ListClustersWithFilter(
+				context.Background(),
 				tc.offset,
 				tc.limit,
 				tc.filter,
@@ -24,6 +24,11 @@ const FieldPrefix = "crd.spec."
 
 type DynamoDBFilter struct {
 	conditions []models.FilterCondition
+
+	// stale selects the clusters not seen since staleBefore, or the ones seen
+	// since then, if set
+	stale       *bool
+	staleBefore time.Time
 }
 
 func NewDynamoDBFilter() *DynamoDBFilter {
@@ -67,9 +72,40 @@ func (f *DynamoDBFilter) Build() (expression.ConditionBuilder, error) {
 		}
 	}
 
+	if f.stale != nil {
+		lastSeen := expression.Name("lastSeenUnix")
+		before := expression.Value(f.staleBefore.Unix())
+		if *f.stale {
+			// the clusters registered before the heartbeats were never seen
+			filter = filter.And(expression.Or(lastSeen.AttributeNotExists(), lastSeen.LessThan(before)))
+		} else {
+			filter = filter.And(lastSeen.GreaterThanEqual(before))
+		}
+	}
+
 	return filter, nil
 }
 
+// SetStale filters the clusters whose client was not seen since the given
+// time if stale is true, or the ones which were if false
+func (f *DynamoDBFilter) SetStale(stale bool, before time.Time) *DynamoDBFilter {
+	f.stale = &stale
+	f.staleBefore = before
+	return f
+}
+
+// String returns the conditions of the filter, for logging
+func (f *DynamoDBFilter) String() string {
+	conditions := make([]string, 0, len(f.conditions)+1)
+	for _, c := range f.conditions {
+		conditions = append(conditions, c.Field+c.Operand+c.Value)
+	}
+	if f.stale != nil {
+		conditions = append(conditions, fmt.Sprintf("stale=%t", *f.stale))
+	}
+	return strings.Join(conditions, ",")
+}
+
 func (f *DynamoDBFilter) AddCondition(condition *models.FilterCondition) *DynamoDBFilter {
 	f.conditions = append(f.conditions, *condition)
 	return f
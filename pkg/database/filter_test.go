@@ -19,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewDynamoDBFilter(t *testing.T) {
@@ -69,6 +70,27 @@ func TestBuild(t *testing.T) {
 			expectedExpression: expression.Name("status").NotEqual(expression.Value("")).
 				And(expression.Name("crd.spec.foo.bar").Equal(expression.Value("test"))),
 		},
+		{
+			name: "stale clusters",
+			filter: NewDynamoDBFilter().
+				AddCondition(models.NewFilterCondition("environment", "=", "prod")).
+				SetStale(true, time.Unix(1700000000, 0)),
+			expectedError: nil,
+			expectedExpression: expression.Name("status").NotEqual(expression.Value("")).
+				And(expression.Name("crd.spec.environment").Equal(expression.Value("prod"))).
+				And(expression.Or(
+					expression.Name("lastSeenUnix").AttributeNotExists(),
+					expression.Name("lastSeenUnix").LessThan(expression.Value(int64(1700000000))),
+				)),
+		},
+		{
+			name: "fresh clusters",
+			filter: NewDynamoDBFilter().
+				SetStale(false, time.Unix(1700000000, 0)),
+			expectedError: nil,
+			expectedExpression: expression.Name("status").NotEqual(expression.Value("")).
+				And(expression.Name("lastSeenUnix").GreaterThanEqual(expression.Value(int64(1700000000)))),
+		},
 		{
 			name: "condition with invalid operand",
 			filter: NewDynamoDBFilter().
@@ -158,7 +158,7 @@ func importData(db Db) error {
 	log.Printf("Populating database with dummy data.")
 
 	for _, cluster := range clusters {
-		err = db.PutCluster(&cluster)
+		err = db.PutCluster(context.Background(), &cluster)
 		if err != nil {
 			return fmt.Errorf("Failed to add or update the cluster %s: '%v'", cluster.Spec.Name, err.Error())
 		}
@@ -58,6 +58,58 @@ var egressMetrics = []*Metric{
 	egressReqDur,
 }
 
+// Queue metrics
+var queueInFlight = &Metric{
+	ID:          "queueInFlight",
+	Name:        "queue_messages_in_flight",
+	Description: "The number of queue messages being processed, partitioned by queue.",
+	Type:        "gauge_vec",
+	Args:        []string{"queue"}}
+
+var queueFailedCnt = &Metric{
+	ID:          "queueFailedCnt",
+	Name:        "queue_messages_failed_total",
+	Description: "How many queue messages failed to be processed, partitioned by queue.",
+	Type:        "counter_vec",
+	Args:        []string{"queue"}}
+
+var queueRejectedCnt = &Metric{
+	ID:          "queueRejectedCnt",
+	Name:        "queue_messages_rejected_total",
+	Description: "How many queue messages were rejected as untrusted, partitioned by reason.",
+	Type:        "counter_vec",
+	Args:        []string{"reason"}}
+
+var eventCnt = &Metric{
+	ID:          "eventCnt",
+	Name:        "queue_events_total",
+	Description: "How many queue events handled, partitioned by type and result.",
+	Type:        "counter_vec",
+	Args:        []string{"type", "result"}}
+
+var eventDur = &Metric{
+	ID:          "eventDur",
+	Name:        "queue_event_duration_seconds",
+	Description: "The queue event handling latencies in seconds partitioned by type.",
+	Type:        "histogram_vec",
+	Args:        []string{"type"}}
+
+var clusterLastSeen = &Metric{
+	ID:          "clusterLastSeen",
+	Name:        "cluster_last_seen_timestamp_seconds",
+	Description: "The time when the client of a cluster was last heard from, partitioned by cluster.",
+	Type:        "gauge_vec",
+	Args:        []string{"cluster"}}
+
+var queueMetrics = []*Metric{
+	queueInFlight,
+	queueFailedCnt,
+	queueRejectedCnt,
+	eventCnt,
+	eventDur,
+	clusterLastSeen,
+}
+
 var errCnt = &Metric{
 	ID:          "ErrCnt",
 	Name:        "error_count",
@@ -84,6 +136,12 @@ type MetricsI interface {
 	RecordIngressRequestCnt(code, method, url string)
 	RecordIngressRequestDur(code, method, url string, elapsed float64)
 	RecordErrorCnt(target string)
+	RecordQueueInFlight(queue string, count int)
+	RecordQueueFailedCnt(queue string)
+	RecordQueueRejectedCnt(reason string)
+	RecordEventCnt(eventType, result string)
+	RecordEventDur(eventType string, elapsed float64)
+	RecordClusterLastSeen(cluster string, timestamp float64)
 	Use(e *echo.Echo)
 }
 
@@ -95,6 +153,13 @@ type Metrics struct {
 	egressReqDur  *prometheus.HistogramVec
 	errCnt        *prometheus.CounterVec
 
+	queueInFlight    *prometheus.GaugeVec
+	queueFailedCnt   *prometheus.CounterVec
+	queueRejectedCnt *prometheus.CounterVec
+	eventCnt         *prometheus.CounterVec
+	eventDur         *prometheus.HistogramVec
+	clusterLastSeen  *prometheus.GaugeVec
+
 	metricsList []*Metric
 	subsystem   string
 	isUnitTest  bool
@@ -109,6 +174,7 @@ func NewMetrics(subsystem string, isUnitTest bool) *Metrics {
 
 	metricsList = append(metricsList, ingressMetrics...)
 	metricsList = append(metricsList, egressMetrics...)
+	metricsList = append(metricsList, queueMetrics...)
 	metricsList = append(metricsList, errCnt)
 
 	m := &Metrics{
@@ -152,6 +218,15 @@ func (m *Metrics) registerMetrics(subsystem string) {
 				},
 				metricDef.Args,
 			)
+		case "gauge_vec":
+			metric = factory.NewGaugeVec(
+				prometheus.GaugeOpts{
+					Subsystem: subsystem,
+					Name:      metricDef.Name,
+					Help:      metricDef.Description,
+				},
+				metricDef.Args,
+			)
 		}
 
 		switch metricDef {
@@ -163,6 +238,18 @@ func (m *Metrics) registerMetrics(subsystem string) {
 			m.egressReqCnt = metric.(*prometheus.CounterVec)
 		case egressReqDur:
 			m.egressReqDur = metric.(*prometheus.HistogramVec)
+		case queueInFlight:
+			m.queueInFlight = metric.(*prometheus.GaugeVec)
+		case queueFailedCnt:
+			m.queueFailedCnt = metric.(*prometheus.CounterVec)
+		case queueRejectedCnt:
+			m.queueRejectedCnt = metric.(*prometheus.CounterVec)
+		case eventCnt:
+			m.eventCnt = metric.(*prometheus.CounterVec)
+		case eventDur:
+			m.eventDur = metric.(*prometheus.HistogramVec)
+		case clusterLastSeen:
+			m.clusterLastSeen = metric.(*prometheus.GaugeVec)
 		case errCnt:
 			m.errCnt = metric.(*prometheus.CounterVec)
 		}
@@ -175,6 +262,37 @@ func (m *Metrics) RecordErrorCnt(target string) {
 	m.errCnt.WithLabelValues(target).Inc()
 }
 
+// RecordQueueInFlight sets the number of messages being processed for a queue
+func (m *Metrics) RecordQueueInFlight(queue string, count int) {
+	m.queueInFlight.WithLabelValues(queue).Set(float64(count))
+}
+
+// RecordQueueFailedCnt increases the failed messages counter for a queue
+func (m *Metrics) RecordQueueFailedCnt(queue string) {
+	m.queueFailedCnt.WithLabelValues(queue).Inc()
+}
+
+// RecordQueueRejectedCnt increases the untrusted messages counter for a reason
+func (m *Metrics) RecordQueueRejectedCnt(reason string) {
+	m.queueRejectedCnt.WithLabelValues(reason).Inc()
+}
+
+// RecordEventCnt increases the handled events counter for a type and result
+func (m *Metrics) RecordEventCnt(eventType, result string) {
+	m.eventCnt.WithLabelValues(eventType, result).Inc()
+}
+
+// RecordEventDur registers the handling duration for an event type
+func (m *Metrics) RecordEventDur(eventType string, elapsed float64) {
+	m.eventDur.WithLabelValues(eventType).Observe(elapsed)
+}
+
+// RecordClusterLastSeen sets the time when the client of a cluster was last
+// heard from, as a Unix timestamp
+func (m *Metrics) RecordClusterLastSeen(cluster string, timestamp float64) {
+	m.clusterLastSeen.WithLabelValues(cluster).Set(timestamp)
+}
+
 // RecordEgressRequestCnt increases the Egress counter for a target
 func (m *Metrics) RecordEgressRequestCnt(target string) {
 	m.egressReqCnt.WithLabelValues(target).Inc()
@@ -24,11 +24,13 @@ import (
 
 const (
 	egressTarget              = "testing_egress"
-	expectedMetricsRegistered = 5
+	expectedMetricsRegistered = 11
 	ingressCode               = "200"
 	ingressMethod             = "GET"
 	ingressURL                = "/api/v1/clusters/:name"
 	minRand                   = 1
+	queueName                 = "testing_queue"
+	eventType                 = "testing_event"
 	maxRand                   = 2.5
 	subsystem                 = "testing"
 )
@@ -56,6 +58,71 @@ func TestRecordStatusErrorCnt(t *testing.T) {
 	test.Equal(float64(1), testutil.ToFloat64((*m.errCnt).WithLabelValues(egressTarget)))
 }
 
+func TestRecordQueueInFlight(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordQueueInFlight(queueName, 3)
+	m.RecordQueueInFlight(queueName, 2)
+
+	test.Equal(1, testutil.CollectAndCount(*m.queueInFlight))
+	test.Equal(float64(2), testutil.ToFloat64((*m.queueInFlight).WithLabelValues(queueName)))
+}
+
+func TestRecordQueueFailedCnt(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordQueueFailedCnt(queueName)
+
+	test.Equal(1, testutil.CollectAndCount(*m.queueFailedCnt))
+	test.Equal(float64(1), testutil.ToFloat64((*m.queueFailedCnt).WithLabelValues(queueName)))
+}
+
+func TestRecordQueueRejectedCnt(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordQueueRejectedCnt("unsigned")
+	m.RecordQueueRejectedCnt("signer_mismatch")
+
+	test.Equal(2, testutil.CollectAndCount(*m.queueRejectedCnt))
+	test.Equal(float64(1), testutil.ToFloat64((*m.queueRejectedCnt).WithLabelValues("unsigned")))
+}
+
+func TestRecordEventCnt(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordEventCnt(eventType, "success")
+	m.RecordEventCnt(eventType, "failure")
+	m.RecordEventCnt(eventType, "failure")
+
+	test.Equal(2, testutil.CollectAndCount(*m.eventCnt))
+	test.Equal(float64(1), testutil.ToFloat64((*m.eventCnt).WithLabelValues(eventType, "success")))
+	test.Equal(float64(2), testutil.ToFloat64((*m.eventCnt).WithLabelValues(eventType, "failure")))
+}
+
+func TestRecordEventDur(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordEventDur(eventType, generateFloatRand(minRand, maxRand))
+
+	test.Equal(1, testutil.CollectAndCount(*m.eventDur))
+}
+
+func TestRecordClusterLastSeen(t *testing.T) {
+	test := assert.New(t)
+	m := NewMetrics(subsystem, true)
+
+	m.RecordClusterLastSeen("cluster1", 1700000000)
+	m.RecordClusterLastSeen("cluster1", 1700000300)
+
+	test.Equal(1, testutil.CollectAndCount(*m.clusterLastSeen))
+	test.Equal(float64(1700000300), testutil.ToFloat64((*m.clusterLastSeen).WithLabelValues("cluster1")))
+}
+
 func TestRecordEgressRequestCnt(t *testing.T) {
 	test := assert.New(t)
 	m := NewMetrics(subsystem, true)
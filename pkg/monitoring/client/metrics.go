@@ -24,6 +24,7 @@ type MetricsI interface {
 	RecordEgressRequestCnt(target string)
 	RecordEgressRequestDur(target string, elapsed float64)
 	RecordDMSLastTimestamp()
+	RecordWebhookRejectedRequest(reason string)
 	GetMetricByName(name string) prometheus.Collector
 }
 
@@ -32,6 +33,7 @@ type Metrics struct {
 	egressReqCnt     *prometheus.CounterVec
 	egressReqDur     *prometheus.HistogramVec
 	dmsLastTimestamp prometheus.Gauge
+	webhookRejected  *prometheus.CounterVec
 	metrics          []prometheus.Collector
 }
 
@@ -75,6 +77,16 @@ func (m *Metrics) Init(isUnitTest bool) {
 	)
 	m.dmsLastTimestamp = dmsLastTimestamp.(prometheus.Gauge)
 	m.metrics = append(m.metrics, m.dmsLastTimestamp)
+
+	var webhookRejected prometheus.Collector = promauto.With(reg).NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_registry_cc_webhook_rejected_requests_total",
+			Help: "How many alertmanager webhook requests were rejected, partitioned by reason.",
+		},
+		[]string{"reason"},
+	)
+	m.webhookRejected = webhookRejected.(*prometheus.CounterVec)
+	m.metrics = append(m.metrics, m.webhookRejected)
 }
 
 // RecordEgressRequestCnt increases the Egress counter for a taget
@@ -92,6 +104,12 @@ func (m *Metrics) RecordDMSLastTimestamp() {
 	m.dmsLastTimestamp.SetToCurrentTime()
 }
 
+// RecordWebhookRejectedRequest increases the counter of the rejected
+// alertmanager webhook requests for a reason
+func (m *Metrics) RecordWebhookRejectedRequest(reason string) {
+	m.webhookRejected.WithLabelValues(reason).Inc()
+}
+
 // GetMetricByName returns a metric by it's fully qualified name (used for testing purposes)
 func (m *Metrics) GetMetricByName(name string) prometheus.Collector {
 	// don't judge me :(
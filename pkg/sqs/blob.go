@@ -0,0 +1,214 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+)
+
+// ErrBlobNotFound is returned for the keys which are not in the blob store
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps the payloads which are too large to be sent in a message,
+// which then only carries their key
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get a payload, or ErrBlobNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Delete a payload, which is a no-op if it does not exist
+	Delete(ctx context.Context, key string) error
+}
+
+// validateBlobKey checks that a key is a bare UUID, as generated by encode.
+// The keys are read from the message attributes, so any other key could point
+// to a payload which was not offloaded, or outside of the payloads.
+func validateBlobKey(key string) error {
+	id, err := uuid.Parse(key)
+	if err != nil || id.String() != key {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
+
+// NewBlobStore creates the blob store selected in the application
+// configuration, or returns nil if none is
+func NewBlobStore(appConfig *config.AppConfig) (BlobStore, error) {
+	switch appConfig.QueueBlobStore {
+	case "":
+		return nil, nil
+	case config.BlobStoreS3:
+		return NewS3BlobStore(appConfig.QueueBlobS3Region, appConfig.QueueBlobS3Endpoint, appConfig.QueueBlobS3Bucket)
+	case config.BlobStoreFilesystem:
+		return NewFilesystemBlobStore(appConfig.QueueBlobDir)
+	default:
+		return nil, fmt.Errorf("unknown blob store %s", appConfig.QueueBlobStore)
+	}
+}
+
+// filesystemBlobStore keeps the payloads as files of a directory, which must
+// be shared by the producers and the consumers, e.g. to run locally
+type filesystemBlobStore struct {
+	dir string
+}
+
+// NewFilesystemBlobStore creates a blob store in the given directory, which is
+// created if missing
+func NewFilesystemBlobStore(dir string) (BlobStore, error) {
+	if dir == "" {
+		return nil, errors.New("a directory is required for the filesystem blob store")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create blob directory: %w", err)
+	}
+	return &filesystemBlobStore{dir: dir}, nil
+}
+
+// path of the file of a key, which cannot point outside the directory
+func (s *filesystemBlobStore) path(key string) (string, error) {
+	if err := validateBlobKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *filesystemBlobStore) Put(_ context.Context, key string, data []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o640)
+}
+
+func (s *filesystemBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return data, err
+}
+
+func (s *filesystemBlobStore) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// s3BlobStore keeps the payloads as objects of an S3 bucket
+type s3BlobStore struct {
+	svc    *s3.S3
+	bucket string
+}
+
+// blobPrefix groups the payloads in the bucket
+const blobPrefix = "payloads/"
+
+// objectKey returns the key of the object of a payload, which cannot point
+// outside the payloads
+func objectKey(key string) (string, error) {
+	if err := validateBlobKey(key); err != nil {
+		return "", err
+	}
+	return path.Join(blobPrefix, key), nil
+}
+
+// NewS3BlobStore creates a blob store in the given S3 bucket
+func NewS3BlobStore(region, endpoint, bucket string) (BlobStore, error) {
+	if bucket == "" {
+		return nil, errors.New("a bucket is required for the S3 blob store")
+	}
+
+	awsConfig := aws.NewConfig().
+		WithRegion(region).
+		WithEndpoint(endpoint).
+		WithS3ForcePathStyle(endpoint != "")
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create session: %w", err)
+	}
+
+	return &s3BlobStore{
+		svc:    s3.New(sess),
+		bucket: bucket,
+	}, nil
+}
+
+func (s *s3BlobStore) Put(ctx context.Context, key string, data []byte) error {
+	objKey, err := objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objKey),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (s *s3BlobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	objKey, err := objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.svc.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objKey),
+	})
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func (s *s3BlobStore) Delete(ctx context.Context, key string) error {
+	objKey, err := objectKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.svc.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objKey),
+	})
+	return err
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrDeadLetterNotFound is returned for the IDs which are not in the store
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+	// ErrDeadLetterUnauthenticated is returned when replaying the messages
+	// which were rejected as not authenticated, since the replay is signed by
+	// the API server
+	ErrDeadLetterUnauthenticated = errors.New("dead letter was not authenticated")
+)
+
+// DeadLetter is a message which could not be processed, along with the reason.
+// Unauthenticated is set for the messages rejected by the authentication.
+type DeadLetter struct {
+	ID              string            `json:"id"`
+	MessageID       string            `json:"messageId"`
+	Type            string            `json:"type"`
+	Body            string            `json:"body"`
+	Attributes      map[string]string `json:"attributes"`
+	Error           string            `json:"error"`
+	Unauthenticated bool              `json:"unauthenticated,omitempty"`
+	ReceiveCount    int               `json:"receiveCount"`
+	SentAt          time.Time         `json:"sentAt"`
+	FailedAt        time.Time         `json:"failedAt"`
+}
+
+// DeadLetterStore keeps the dead-lettered messages, until they are replayed
+// or purged
+type DeadLetterStore interface {
+	DeadLetterer
+
+	// List the dead letters, oldest first
+	List(ctx context.Context) ([]*DeadLetter, error)
+
+	// Get a dead letter, or ErrDeadLetterNotFound
+	Get(ctx context.Context, id string) (*DeadLetter, error)
+
+	// Delete a dead letter, or return ErrDeadLetterNotFound
+	Delete(ctx context.Context, id string) error
+
+	// Purge all the dead letters, returning their count
+	Purge(ctx context.Context) (int, error)
+}
+
+func newDeadLetter(msg *Message, reason error) *DeadLetter {
+	dl := &DeadLetter{
+		ID:           uuid.New().String(),
+		MessageID:    msg.ID,
+		Type:         msg.Attributes[MessageAttributeType],
+		Body:         msg.Body,
+		Attributes:   maps.Clone(msg.Attributes),
+		ReceiveCount: msg.ReceiveCount,
+		SentAt:       msg.SentTimestamp,
+		FailedAt:     time.Now().UTC(),
+	}
+	if reason != nil {
+		dl.Error = reason.Error()
+		dl.Unauthenticated = errors.Is(reason, ErrUnauthenticated)
+	}
+	return dl
+}
+
+func sortDeadLetters(dls []*DeadLetter) []*DeadLetter {
+	slices.SortFunc(dls, func(a, b *DeadLetter) int {
+		return a.FailedAt.Compare(b.FailedAt)
+	})
+	return dls
+}
+
+// Replay enqueues the message of a dead letter again, and removes it from the
+// store. The message is signed again by the queue, as its original signature
+// may be older than the max age by now, so the messages rejected as not
+// authenticated cannot be replayed.
+func Replay(ctx context.Context, q Queue, store DeadLetterStore, id string) (*DeadLetter, error) {
+	dl, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if dl.Unauthenticated {
+		return nil, fmt.Errorf("%w: %s", ErrDeadLetterUnauthenticated, id)
+	}
+
+	err = q.Enqueue(ctx, []*Message{
+		{
+			Body:       dl.Body,
+			Attributes: maps.Clone(dl.Attributes),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot enqueue dead letter %s: %w", id, err)
+	}
+
+	if err := store.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	logger.Info("Replayed dead letter", "id", id, "messageId", dl.MessageID)
+	return dl, nil
+}
+
+// memoryDeadLetterStore keeps the dead letters in memory, for tests and
+// single process setups
+type memoryDeadLetterStore struct {
+	mutex       sync.Mutex
+	deadLetters map[string]*DeadLetter
+}
+
+// NewMemoryDeadLetterStore creates a dead letter store which is not shared
+// outside the process
+func NewMemoryDeadLetterStore() DeadLetterStore {
+	return &memoryDeadLetterStore{
+		deadLetters: map[string]*DeadLetter{},
+	}
+}
+
+func (s *memoryDeadLetterStore) DeadLetter(_ context.Context, msg *Message, reason error) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dl := newDeadLetter(msg, reason)
+	s.deadLetters[dl.ID] = dl
+	return nil
+}
+
+func (s *memoryDeadLetterStore) List(_ context.Context) ([]*DeadLetter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dls := make([]*DeadLetter, 0, len(s.deadLetters))
+	for _, dl := range s.deadLetters {
+		c := *dl
+		dls = append(dls, &c)
+	}
+	return sortDeadLetters(dls), nil
+}
+
+func (s *memoryDeadLetterStore) Get(_ context.Context, id string) (*DeadLetter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dl, ok := s.deadLetters[id]
+	if !ok {
+		return nil, ErrDeadLetterNotFound
+	}
+	c := *dl
+	return &c, nil
+}
+
+func (s *memoryDeadLetterStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.deadLetters[id]; !ok {
+		return ErrDeadLetterNotFound
+	}
+	delete(s.deadLetters, id)
+	return nil
+}
+
+func (s *memoryDeadLetterStore) Purge(_ context.Context) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := len(s.deadLetters)
+	s.deadLetters = map[string]*DeadLetter{}
+	return count, nil
+}
+
+// redisDeadLetterStore keeps the dead letters in a Redis hash, keyed by ID
+type redisDeadLetterStore struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// NewRedisDeadLetterStore creates a dead letter store in the given Redis hash
+func NewRedisDeadLetterStore(client redis.UniversalClient, key string) DeadLetterStore {
+	return &redisDeadLetterStore{
+		client: client,
+		key:    key,
+	}
+}
+
+func (s *redisDeadLetterStore) DeadLetter(ctx context.Context, msg *Message, reason error) error {
+	dl := newDeadLetter(msg, reason)
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.key, dl.ID, data).Err()
+}
+
+func (s *redisDeadLetterStore) List(ctx context.Context) ([]*DeadLetter, error) {
+	values, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dls := make([]*DeadLetter, 0, len(values))
+	for id, data := range values {
+		var dl DeadLetter
+		if err := json.Unmarshal([]byte(data), &dl); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal dead letter %s: %w", id, err)
+		}
+		dls = append(dls, &dl)
+	}
+	return sortDeadLetters(dls), nil
+}
+
+func (s *redisDeadLetterStore) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	data, err := s.client.HGet(ctx, s.key, id).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dl DeadLetter
+	if err := json.Unmarshal([]byte(data), &dl); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal dead letter %s: %w", id, err)
+	}
+	return &dl, nil
+}
+
+func (s *redisDeadLetterStore) Delete(ctx context.Context, id string) error {
+	deleted, err := s.client.HDel(ctx, s.key, id).Result()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func (s *redisDeadLetterStore) Purge(ctx context.Context) (int, error) {
+	var count *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.HLen(ctx, s.key)
+		pipe.Del(ctx, s.key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
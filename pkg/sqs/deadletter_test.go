@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterStores(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test storing, listing and purging dead letters.")
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	tcs := []struct {
+		name  string
+		store DeadLetterStore
+	}{
+		{
+			name:  "memory",
+			store: NewMemoryDeadLetterStore(),
+		},
+		{
+			name:  "redis",
+			store: NewRedisDeadLetterStore(client, "cluster-registry:dead-letters"),
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen dead-lettering messages", tc.name)
+		ctx := context.Background()
+
+		test.NoError(tc.store.DeadLetter(ctx, &Message{
+			ID:           "1",
+			Body:         "first",
+			Attributes:   map[string]string{MessageAttributeType: ClusterUpdateEvent},
+			ReceiveCount: 5,
+		}, errors.New("database unavailable")))
+		test.NoError(tc.store.DeadLetter(ctx, &Message{
+			ID:           "2",
+			Body:         "second",
+			Attributes:   map[string]string{MessageAttributeType: PartialClusterUpdateEvent},
+			ReceiveCount: 1,
+		}, ErrUnknownEvent))
+
+		dls, err := tc.store.List(ctx)
+		test.NoError(err)
+		test.Len(dls, 2)
+		test.Equal("1", dls[0].MessageID)
+		test.Equal(ClusterUpdateEvent, dls[0].Type)
+		test.Equal("first", dls[0].Body)
+		test.Equal("database unavailable", dls[0].Error)
+		test.Equal(5, dls[0].ReceiveCount)
+		test.Equal("2", dls[1].MessageID)
+
+		dl, err := tc.store.Get(ctx, dls[0].ID)
+		test.NoError(err)
+		test.Equal(dls[0].MessageID, dl.MessageID)
+		test.Equal(dls[0].Attributes, dl.Attributes)
+
+		_, err = tc.store.Get(ctx, "missing")
+		test.ErrorIs(err, ErrDeadLetterNotFound)
+
+		test.NoError(tc.store.Delete(ctx, dls[0].ID))
+		test.ErrorIs(tc.store.Delete(ctx, dls[0].ID), ErrDeadLetterNotFound)
+
+		count, err := tc.store.Purge(ctx)
+		test.NoError(err)
+		test.Equal(1, count)
+
+		dls, err = tc.store.List(ctx)
+		test.NoError(err)
+		test.Empty(dls)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test replaying a dead letter to the queue.")
+
+	ctx := context.Background()
+	q, err := NewMemory(Config{VisibilityTimeout: 60})
+	test.NoError(err)
+	mt := q.(*queue).transport.(*memoryTransport)
+	store := NewMemoryDeadLetterStore()
+
+	test.NoError(store.DeadLetter(ctx, &Message{
+		ID:         "1",
+		Body:       "{}",
+		Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent},
+	}, errors.New("database unavailable")))
+	dls, err := store.List(ctx)
+	test.NoError(err)
+	test.Len(dls, 1)
+
+	dl, err := Replay(ctx, q, store, dls[0].ID)
+	test.NoError(err)
+	test.Equal(dls[0].ID, dl.ID)
+
+	msgs, err := mt.receive(ctx, 10)
+	test.NoError(err)
+	test.Len(msgs, 1)
+	test.Equal("{}", msgs[0].Body)
+	test.Equal(ClusterUpdateEvent, msgs[0].Attributes[MessageAttributeType])
+
+	_, err = store.Get(ctx, dl.ID)
+	test.ErrorIs(err, ErrDeadLetterNotFound)
+
+	_, err = Replay(ctx, q, store, dl.ID)
+	test.ErrorIs(err, ErrDeadLetterNotFound)
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrUnknownEvent is returned for the messages without a registered handler
+var ErrUnknownEvent = errors.New("no handler registered for event")
+
+// permanentError marks the failures which would not succeed on retry
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks an error returned by a handler as not worth retrying, so
+// that the message is dead-lettered right away if a store is configured
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// AckPolicy decides when the dispatcher deletes a message from the queue
+type AckPolicy int
+
+const (
+	// AckOnSuccess deletes the message once handled, and leaves it in the queue
+	// to be received again if the handler fails
+	AckOnSuccess AckPolicy = iota
+
+	// AckAlways deletes the message even if the handler fails
+	AckAlways
+
+	// AckNever leaves deleting the message to the handler
+	AckNever
+)
+
+// UnknownEventPolicy decides what happens to the messages which cannot be
+// dispatched, because their type is missing or has no registered handler
+type UnknownEventPolicy int
+
+const (
+	// RequeueUnknownEvents makes the message visible again after the requeue
+	// delay, for another consumer to handle it
+	RequeueUnknownEvents UnknownEventPolicy = iota
+
+	// DeadLetterUnknownEvents moves the message to the dead letter store
+	DeadLetterUnknownEvents
+)
+
+// HandlerFunc handles an event, and is wrapped by the middleware
+type HandlerFunc func(ctx context.Context, event *Event) error
+
+// Middleware wraps the handling of the events, for concerns shared by the
+// handlers such as logging or metrics
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// DeadLetterer stores the messages which cannot be processed, along with the
+// reason
+type DeadLetterer interface {
+	DeadLetter(ctx context.Context, msg *Message, reason error) error
+}
+
+// DispatcherOptions configures the handling of the messages which cannot be
+// dispatched or failed to be handled
+type DispatcherOptions struct {
+	// UnknownEvents is the policy for the messages without handler
+	UnknownEvents UnknownEventPolicy
+
+	// RequeueDelay is the number of seconds before a requeued message is
+	// visible again
+	RequeueDelay int64
+
+	// DeadLetter stores the dead-lettered messages, and is required by
+	// DeadLetterUnknownEvents and MaxAttempts
+	DeadLetter DeadLetterer
+
+	// MaxAttempts is the number of times a message is received before being
+	// dead-lettered, when handled with AckOnSuccess. Zero retries forever.
+	MaxAttempts int
+
+	// RetryBackoff is the number of seconds before a failed message is
+	// received again, doubled on each attempt up to MaxRetryBackoff. Zero
+	// keeps the visibility timeout of the queue, which also caps the backoff
+	// with Redis.
+	RetryBackoff    int64
+	MaxRetryBackoff int64
+}
+
+type registration struct {
+	handler HandlerFunc
+	ack     AckPolicy
+}
+
+// Dispatcher routes the messages received from the queue to the EventHandler
+// registered for their type
+type Dispatcher struct {
+	queue   Queue
+	options DispatcherOptions
+
+	mutex      sync.RWMutex
+	handlers   map[string]registration
+	middleware []Middleware
+}
+
+// NewDispatcher creates a dispatcher for the messages of the queue
+func NewDispatcher(q Queue, options DispatcherOptions) (*Dispatcher, error) {
+	if options.UnknownEvents == DeadLetterUnknownEvents && options.DeadLetter == nil {
+		return nil, errors.New("a dead letter store is required to dead-letter unknown events")
+	}
+	if options.MaxAttempts < 0 {
+		return nil, errors.New("MaxAttempts should not be negative")
+	}
+	if options.MaxAttempts > 0 && options.DeadLetter == nil {
+		return nil, errors.New("a dead letter store is required to limit the attempts")
+	}
+	if options.RequeueDelay < 0 || options.RequeueDelay > maxVisibilityTimeout {
+		return nil, errors.New("RequeueDelay should be between 0-43200")
+	}
+	if options.RetryBackoff < 0 || options.RetryBackoff > maxVisibilityTimeout {
+		return nil, errors.New("RetryBackoff should be between 0-43200")
+	}
+	if options.MaxRetryBackoff < 0 || options.MaxRetryBackoff > maxVisibilityTimeout {
+		return nil, errors.New("MaxRetryBackoff should be between 0-43200")
+	}
+	if options.MaxRetryBackoff == 0 {
+		options.MaxRetryBackoff = maxVisibilityTimeout
+	}
+
+	return &Dispatcher{
+		queue:    q,
+		options:  options,
+		handlers: map[string]registration{},
+	}, nil
+}
+
+// Use adds middleware, which wraps the handlers registered before or after.
+// The first middleware added is the outermost one.
+func (d *Dispatcher) Use(middleware ...Middleware) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.middleware = append(d.middleware, middleware...)
+}
+
+// Register the handler for the events of its type, replacing any previous one
+func (d *Dispatcher) Register(h EventHandler, ack AckPolicy) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.handlers[h.Type()] = registration{
+		handler: h.Handle,
+		ack:     ack,
+	}
+}
+
+// Handle dispatches a message to the handler of its type. It has the Handler
+// signature, to be passed to Poll.
+func (d *Dispatcher) Handle(ctx context.Context, msg *Message) error {
+	event, err := NewEvent(msg)
+	if err != nil {
+		return d.undeliverable(ctx, msg, err)
+	}
+
+	d.mutex.RLock()
+	r, ok := d.handlers[event.Type]
+	handler := decoding(r.handler)
+	for i := len(d.middleware) - 1; i >= 0; i-- {
+		handler = d.middleware[i](handler)
+	}
+	d.mutex.RUnlock()
+
+	if !ok {
+		return d.undeliverable(ctx, msg, fmt.Errorf("%w of type %s", ErrUnknownEvent, event.Type))
+	}
+
+	err = handler(ctx, event)
+	if msg.DecodeError() != nil {
+		return d.discard(ctx, msg, err)
+	}
+	if err != nil && r.ack == AckOnSuccess {
+		return d.retry(ctx, msg, err)
+	}
+	if r.ack == AckAlways || (r.ack == AckOnSuccess && err == nil) {
+		if deleteErr := d.queue.Delete(ctx, msg); deleteErr != nil {
+			return errors.Join(err, fmt.Errorf("cannot delete message: %w", deleteErr))
+		}
+	}
+	return err
+}
+
+// decoding decodes the body of the message before handling it, which is after
+// the middleware, so that messages are authenticated before being decoded
+func decoding(next HandlerFunc) HandlerFunc {
+	return func(ctx context.Context, event *Event) error {
+		if err := event.Message.Decode(ctx); err != nil {
+			return err
+		}
+		return next(ctx, event)
+	}
+}
+
+// retry dead-letters a failed message once it reached the maximum attempts or
+// failed permanently, or postpones its next attempt, and returns the reason it failed
+func (d *Dispatcher) retry(ctx context.Context, msg *Message, reason error) error {
+	if d.options.MaxAttempts > 0 && msg.ReceiveCount >= d.options.MaxAttempts {
+		logger.Info("Dead-lettering message after max attempts", "messageId", msg.ID, "attempts", msg.ReceiveCount)
+		return d.deadLetter(ctx, msg, reason)
+	}
+	if d.options.DeadLetter != nil && IsPermanent(reason) {
+		logger.Info("Dead-lettering message after permanent failure", "messageId", msg.ID)
+		return d.deadLetter(ctx, msg, reason)
+	}
+
+	if d.options.RetryBackoff > 0 {
+		backoff := d.backoff(msg.ReceiveCount)
+		if err := d.queue.ExtendVisibility(ctx, msg, backoff); err != nil {
+			return errors.Join(reason, fmt.Errorf("cannot postpone message: %w", err))
+		}
+	}
+	return reason
+}
+
+// backoff returns the number of seconds before the next attempt
+func (d *Dispatcher) backoff(attempt int) int64 {
+	backoff := d.options.RetryBackoff
+	for i := 1; i < attempt && backoff < d.options.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.options.MaxRetryBackoff)
+}
+
+// deadLetter moves a message to the dead letter store, and returns the reason
+func (d *Dispatcher) deadLetter(ctx context.Context, msg *Message, reason error) error {
+	if err := d.options.DeadLetter.DeadLetter(ctx, msg, reason); err != nil {
+		return errors.Join(reason, fmt.Errorf("cannot dead-letter message: %w", err))
+	}
+	if err := d.queue.Delete(ctx, msg); err != nil {
+		return errors.Join(reason, fmt.Errorf("cannot delete message: %w", err))
+	}
+	return reason
+}
+
+// discard dead-letters a message which cannot be handled, or deletes it if no
+// store is configured, and returns the reason
+func (d *Dispatcher) discard(ctx context.Context, msg *Message, reason error) error {
+	if d.options.DeadLetter != nil {
+		logger.Info("Dead-lettering message which cannot be decoded", "messageId", msg.ID)
+		return d.deadLetter(ctx, msg, reason)
+	}
+	logger.Info("Deleting message which cannot be decoded", "messageId", msg.ID)
+	if err := d.queue.Delete(ctx, msg); err != nil {
+		return errors.Join(reason, fmt.Errorf("cannot delete message: %w", err))
+	}
+	return reason
+}
+
+// undeliverable applies the unknown events policy to a message, and returns
+// the reason it could not be dispatched
+func (d *Dispatcher) undeliverable(ctx context.Context, msg *Message, reason error) error {
+	switch d.options.UnknownEvents {
+	case DeadLetterUnknownEvents:
+		return d.deadLetter(ctx, msg, reason)
+	default:
+		if err := d.queue.ExtendVisibility(ctx, msg, d.options.RequeueDelay); err != nil {
+			return errors.Join(reason, fmt.Errorf("cannot requeue message: %w", err))
+		}
+	}
+	return reason
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventHandler struct {
+	eventType string
+	err       error
+	handled   int
+}
+
+func (h *fakeEventHandler) Type() string {
+	return h.eventType
+}
+
+func (h *fakeEventHandler) Handle(_ context.Context, _ *Event) error {
+	h.handled++
+	return h.err
+}
+
+type fakeDeadLetter struct {
+	msgs    []*Message
+	reasons []error
+}
+
+func (d *fakeDeadLetter) DeadLetter(_ context.Context, msg *Message, reason error) error {
+	d.msgs = append(d.msgs, msg)
+	d.reasons = append(d.reasons, reason)
+	return nil
+}
+
+type fakeEventMetrics struct {
+	counts    map[string]int
+	durations int
+}
+
+func (m *fakeEventMetrics) RecordEventCnt(eventType, result string) {
+	m.counts[eventType+"/"+result]++
+}
+
+func (m *fakeEventMetrics) RecordEventDur(_ string, _ float64) {
+	m.durations++
+}
+
+func TestDispatcher(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test dispatching messages to the handlers registered for their type.")
+
+	tcs := []struct {
+		name               string
+		eventType          string
+		handlerErr         error
+		ack                AckPolicy
+		unknownEvents      UnknownEventPolicy
+		expectedHandled    int
+		expectedErr        error
+		expectedRemaining  int
+		expectedVisible    int
+		expectedDeadLetter int
+	}{
+		{
+			name:              "handled and acknowledged",
+			eventType:         ClusterUpdateEvent,
+			ack:               AckOnSuccess,
+			expectedHandled:   1,
+			expectedRemaining: 0,
+		},
+		{
+			name:              "failed and left in the queue",
+			eventType:         ClusterUpdateEvent,
+			handlerErr:        errors.New("database unavailable"),
+			ack:               AckOnSuccess,
+			expectedHandled:   1,
+			expectedErr:       errors.New("database unavailable"),
+			expectedRemaining: 1,
+		},
+		{
+			name:              "failed and acknowledged anyway",
+			eventType:         ClusterUpdateEvent,
+			handlerErr:        errors.New("bad payload"),
+			ack:               AckAlways,
+			expectedHandled:   1,
+			expectedErr:       errors.New("bad payload"),
+			expectedRemaining: 0,
+		},
+		{
+			name:              "acknowledged by the handler",
+			eventType:         ClusterUpdateEvent,
+			ack:               AckNever,
+			expectedHandled:   1,
+			expectedRemaining: 1,
+		},
+		{
+			name:              "unknown type requeued",
+			eventType:         "cluster-delete",
+			unknownEvents:     RequeueUnknownEvents,
+			expectedErr:       ErrUnknownEvent,
+			expectedRemaining: 1,
+			expectedVisible:   1,
+		},
+		{
+			name:               "unknown type dead-lettered",
+			eventType:          "cluster-delete",
+			unknownEvents:      DeadLetterUnknownEvents,
+			expectedErr:        ErrUnknownEvent,
+			expectedRemaining:  0,
+			expectedDeadLetter: 1,
+		},
+		{
+			name:               "missing type dead-lettered",
+			eventType:          "",
+			unknownEvents:      DeadLetterUnknownEvents,
+			expectedErr:        errors.New("empty event type"),
+			expectedRemaining:  0,
+			expectedDeadLetter: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen dispatching event of type %q", tc.name, tc.eventType)
+
+		q, err := NewMemory(Config{VisibilityTimeout: 60})
+		test.NoError(err)
+		mt := q.(*queue).transport.(*memoryTransport)
+
+		deadLetter := &fakeDeadLetter{}
+		d, err := NewDispatcher(q, DispatcherOptions{
+			UnknownEvents: tc.unknownEvents,
+			DeadLetter:    deadLetter,
+		})
+		test.NoError(err)
+
+		handler := &fakeEventHandler{eventType: ClusterUpdateEvent, err: tc.handlerErr}
+		d.Register(handler, tc.ack)
+
+		test.NoError(q.Enqueue(context.Background(), []*Message{
+			{Attributes: map[string]string{MessageAttributeType: tc.eventType}, Body: "{}"},
+		}))
+		msgs, err := mt.receive(context.Background(), 1)
+		test.NoError(err)
+		test.Len(msgs, 1)
+
+		err = d.Handle(context.Background(), msgs[0])
+		switch {
+		case tc.expectedErr == nil:
+			test.NoError(err)
+		case errors.Is(tc.expectedErr, ErrUnknownEvent):
+			test.ErrorIs(err, ErrUnknownEvent)
+		default:
+			test.EqualError(err, tc.expectedErr.Error())
+		}
+
+		test.Equal(tc.expectedHandled, handler.handled)
+		test.Len(mt.messages, tc.expectedRemaining)
+		test.Len(mt.take(1), tc.expectedVisible)
+		test.Len(deadLetter.msgs, tc.expectedDeadLetter)
+		if tc.expectedDeadLetter > 0 {
+			test.Equal(msgs[0].ID, deadLetter.msgs[0].ID)
+			test.Error(deadLetter.reasons[0])
+		}
+	}
+}
+
+func TestNewDispatcher(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test validating the dispatcher options.")
+
+	q, err := NewMemory(Config{})
+	test.NoError(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{UnknownEvents: DeadLetterUnknownEvents})
+	test.Error(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{RequeueDelay: -1})
+	test.Error(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{MaxAttempts: 5})
+	test.Error(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{MaxAttempts: -1, DeadLetter: &fakeDeadLetter{}})
+	test.Error(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{RetryBackoff: maxVisibilityTimeout + 1})
+	test.Error(err)
+
+	_, err = NewDispatcher(q, DispatcherOptions{RequeueDelay: 30})
+	test.NoError(err)
+
+	d, err := NewDispatcher(q, DispatcherOptions{MaxAttempts: 5, DeadLetter: &fakeDeadLetter{}, RetryBackoff: 10})
+	test.NoError(err)
+	test.Equal(int64(maxVisibilityTimeout), d.options.MaxRetryBackoff)
+}
+
+func TestDispatcherRetry(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test postponing and dead-lettering the messages which failed.")
+
+	tcs := []struct {
+		name               string
+		receiveCount       int
+		permanent          bool
+		expectedDeadLetter int
+		expectedRemaining  int
+		expectedBackoff    int64
+	}{
+		{
+			name:              "first attempt",
+			receiveCount:      1,
+			expectedRemaining: 1,
+			expectedBackoff:   10,
+		},
+		{
+			name:              "third attempt",
+			receiveCount:      3,
+			expectedRemaining: 1,
+			expectedBackoff:   40,
+		},
+		{
+			name:              "backoff capped",
+			receiveCount:      4,
+			expectedRemaining: 1,
+			expectedBackoff:   60,
+		},
+		{
+			name:               "permanent failure",
+			receiveCount:       1,
+			permanent:          true,
+			expectedDeadLetter: 1,
+			expectedRemaining:  0,
+		},
+		{
+			name:               "max attempts reached",
+			receiveCount:       5,
+			expectedDeadLetter: 1,
+			expectedRemaining:  0,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen the handler failed on attempt %d", tc.name, tc.receiveCount)
+
+		q, err := NewMemory(Config{VisibilityTimeout: 600})
+		test.NoError(err)
+		mt := q.(*queue).transport.(*memoryTransport)
+
+		deadLetter := &fakeDeadLetter{}
+		d, err := NewDispatcher(q, DispatcherOptions{
+			DeadLetter:      deadLetter,
+			MaxAttempts:     5,
+			RetryBackoff:    10,
+			MaxRetryBackoff: 60,
+		})
+		test.NoError(err)
+		handlerErr := errors.New("database unavailable")
+		if tc.permanent {
+			handlerErr = Permanent(handlerErr)
+		}
+		d.Register(&fakeEventHandler{eventType: ClusterUpdateEvent, err: handlerErr}, AckOnSuccess)
+
+		test.NoError(q.Enqueue(context.Background(), []*Message{
+			{Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}, Body: "{}"},
+		}))
+		msgs, err := mt.receive(context.Background(), 1)
+		test.NoError(err)
+		test.Len(msgs, 1)
+		msgs[0].ReceiveCount = tc.receiveCount
+
+		err = d.Handle(context.Background(), msgs[0])
+		test.EqualError(err, "database unavailable")
+		test.Equal(tc.permanent, IsPermanent(err))
+
+		test.Len(deadLetter.msgs, tc.expectedDeadLetter)
+		test.Len(mt.messages, tc.expectedRemaining)
+		if tc.expectedRemaining > 0 {
+			backoff := time.Until(mt.messages[0].visibleAt)
+			test.InDelta(float64(tc.expectedBackoff), backoff.Seconds(), 1)
+		}
+	}
+}
+
+func TestDispatcherMiddleware(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test wrapping the handlers with middleware.")
+
+	q, err := NewMemory(Config{VisibilityTimeout: 60})
+	test.NoError(err)
+
+	d, err := NewDispatcher(q, DispatcherOptions{})
+	test.NoError(err)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(ctx context.Context, event *Event) error {
+				calls = append(calls, name+" before")
+				err := next(ctx, event)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	metrics := &fakeEventMetrics{counts: map[string]int{}}
+
+	d.Use(Tracing(), Logging(), Recording(metrics), trace("outer"))
+	d.Register(&fakeEventHandler{eventType: ClusterUpdateEvent}, AckNever)
+	d.Register(&fakeEventHandler{eventType: PartialClusterUpdateEvent, err: errors.New("failed")}, AckNever)
+	d.Use(trace("inner"))
+
+	for _, eventType := range []string{ClusterUpdateEvent, PartialClusterUpdateEvent} {
+		_ = d.Handle(context.Background(), &Message{
+			ID:         "1",
+			Attributes: map[string]string{MessageAttributeType: eventType},
+		})
+	}
+
+	test.Equal([]string{
+		"outer before", "inner before", "inner after", "outer after",
+		"outer before", "inner before", "inner after", "outer after",
+	}, calls)
+	test.Equal(map[string]int{
+		ClusterUpdateEvent + "/success":        1,
+		PartialClusterUpdateEvent + "/failure": 1,
+	}, metrics.counts)
+	test.Equal(2, metrics.durations)
+}
@@ -13,9 +13,8 @@ governing permissions and limitations under the License.
 package sqs
 
 import (
+	"context"
 	"errors"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
-	"slices"
 )
 
 const (
@@ -23,6 +22,10 @@ const (
 	MessageAttributeClusterName           = "ClusterName"
 	MessageAttributeSkipCacheInvalidation = "SkipCacheInvalidation"
 
+	// MessageAttributeRequestID correlates the message with the request or
+	// reconciliation which produced it, and is logged by the event handlers.
+	MessageAttributeRequestID = "RequestID"
+
 	// ClusterUpdateEvent refers to an update of the Cluster object that
 	// is sent by the client controller. This event is sent to the SQS queue and
 	// is consumed by the API server which reconciles the DB.
@@ -33,24 +36,37 @@ const (
 	// the SQS queue and is consumed by the sync client which creates/updates the
 	// Cluster object on the cluster.
 	PartialClusterUpdateEvent = "partial-cluster-update"
+
+	// ClusterHeartbeatEvent is sent periodically by the client controller for
+	// each Cluster object, even if unchanged, so that the API server can tell
+	// the clusters whose client stopped from the ones which did not change.
+	// The cluster is identified by the ClusterName attribute, and the body is
+	// an empty JSON object.
+	ClusterHeartbeatEvent = "cluster-heartbeat"
+
+	// ClusterAckEvent is sent by the API server to the queue named by the
+	// ReplyTo attribute of a cluster update, once the update is stored. It
+	// echoes the Hash attribute of the update, and carries the Revision of the
+	// cluster in the registry.
+	ClusterAckEvent = "cluster-ack"
 )
 
 type Event struct {
 	Type    string
-	Message *awssqs.Message
+	Message *Message
 }
 
-func NewEvent(msg *awssqs.Message) (*Event, error) {
+func NewEvent(msg *Message) (*Event, error) {
 	if msg == nil {
 		return nil, errors.New("empty message")
 	}
 	// check if type is set
-	if msg.MessageAttributes[MessageAttributeType] == nil {
+	eventType, ok := msg.Attributes[MessageAttributeType]
+	if !ok {
 		return nil, errors.New("missing event type")
 	}
-	eventType := *msg.MessageAttributes[MessageAttributeType].StringValue
-	if !slices.Contains([]string{ClusterUpdateEvent, PartialClusterUpdateEvent}, eventType) {
-		return nil, errors.New("invalid event type")
+	if eventType == "" {
+		return nil, errors.New("empty event type")
 	}
 
 	return &Event{
@@ -59,7 +75,16 @@ func NewEvent(msg *awssqs.Message) (*Event, error) {
 	}, nil
 }
 
+// RequestID returns the ID of the request which produced the event, if any
+func (e *Event) RequestID() string {
+	if e.Message == nil {
+		return ""
+	}
+	return e.Message.Attributes[MessageAttributeRequestID]
+}
+
+// EventHandler handles the events of a type, and is registered with a Dispatcher
 type EventHandler interface {
 	Type() string
-	Handle(event *Event) error
+	Handle(ctx context.Context, event *Event) error
 }
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memoryTransport keeps the messages in memory, with the same visibility
+// semantics as SQS. It is meant for tests and single process setups.
+type memoryTransport struct {
+	cfg Config
+
+	mutex    sync.Mutex
+	messages []*memoryMessage
+
+	// arrived is signalled when messages are enqueued, to wake up the receivers
+	arrived chan struct{}
+}
+
+type memoryMessage struct {
+	Message
+	visibleAt time.Time
+}
+
+// NewMemory creates a queue which is not shared outside the process
+func NewMemory(cfg Config) (Queue, error) {
+	if err := validatePolling(cfg); err != nil {
+		logger.Error(err, "invalid memory queue Config")
+		return nil, err
+	}
+
+	return newQueue(cfg, &memoryTransport{
+		cfg:     cfg,
+		arrived: make(chan struct{}, 1),
+	}), nil
+}
+
+func (t *memoryTransport) system() string {
+	return "memory"
+}
+
+func (t *memoryTransport) send(_ context.Context, msgs []*Message) error {
+	now := time.Now()
+
+	t.mutex.Lock()
+	for _, msg := range msgs {
+		t.messages = append(t.messages, &memoryMessage{
+			Message: Message{
+				ID:            uuid.New().String(),
+				Body:          msg.Body,
+				Attributes:    maps.Clone(msg.Attributes),
+				SentTimestamp: now,
+			},
+			visibleAt: now.Add(time.Duration(msg.DelaySeconds) * time.Second),
+		})
+	}
+	t.mutex.Unlock()
+
+	select {
+	case t.arrived <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (t *memoryTransport) receive(ctx context.Context, maxMsgs int64) ([]*Message, error) {
+	wait := time.NewTimer(time.Duration(t.cfg.WaitSeconds) * time.Second)
+	defer wait.Stop()
+
+	for {
+		if msgs := t.take(maxMsgs); len(msgs) > 0 {
+			return msgs, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wait.C:
+			return t.take(maxMsgs), nil
+		case <-t.arrived:
+		}
+	}
+}
+
+// take returns copies of the visible messages, and hides them for the
+// visibility timeout
+func (t *memoryTransport) take(maxMsgs int64) []*Message {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	var msgs []*Message
+	for _, m := range t.messages {
+		if int64(len(msgs)) >= maxMsgs {
+			break
+		}
+		if m.visibleAt.After(now) {
+			continue
+		}
+		m.visibleAt = now.Add(time.Duration(t.cfg.VisibilityTimeout) * time.Second)
+		m.ReceiveCount++
+		m.receipt = fmt.Sprintf("%s/%d", m.ID, m.ReceiveCount)
+
+		msg := m.Message
+		msg.Attributes = maps.Clone(m.Attributes)
+		msgs = append(msgs, &msg)
+	}
+	return msgs
+}
+
+// find returns the index of the message, as long as its receipt is still valid
+func (t *memoryTransport) find(msg *Message) (int, error) {
+	for i, m := range t.messages {
+		if m.ID != msg.ID {
+			continue
+		}
+		if m.receipt != msg.receipt {
+			return -1, fmt.Errorf("receipt of message %s is no longer valid", msg.ID)
+		}
+		return i, nil
+	}
+	return -1, fmt.Errorf("message %s not found", msg.ID)
+}
+
+func (t *memoryTransport) delete(_ context.Context, msg *Message) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	i, err := t.find(msg)
+	if err != nil {
+		return err
+	}
+	t.messages = append(t.messages[:i], t.messages[i+1:]...)
+	return nil
+}
+
+func (t *memoryTransport) extendVisibility(_ context.Context, msg *Message, seconds int64) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	i, err := t.find(msg)
+	if err != nil {
+		return err
+	}
+	t.messages[i].visibleAt = time.Now().Add(time.Duration(seconds) * time.Second)
+	return nil
+}
+
+func (t *memoryTransport) status() error {
+	return nil
+}
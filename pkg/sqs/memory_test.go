@@ -0,0 +1,101 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQueue(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test enqueuing, receiving and deleting messages in memory.")
+
+	q, err := NewMemory(Config{BatchSize: 10, VisibilityTimeout: 60})
+	test.NoError(err)
+	mt := q.(*queue).transport.(*memoryTransport)
+	ctx := context.Background()
+
+	err = q.Enqueue(ctx, []*Message{
+		{Body: "first", Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}},
+		{Body: "second", Attributes: map[string]string{MessageAttributeType: PartialClusterUpdateEvent}},
+		{Body: "delayed", DelaySeconds: 60},
+	})
+	test.NoError(err)
+
+	msgs, err := mt.receive(ctx, 10)
+	test.NoError(err)
+	test.Len(msgs, 2)
+	test.Equal("first", msgs[0].Body)
+	test.Equal(ClusterUpdateEvent, msgs[0].Attributes[MessageAttributeType])
+	test.Equal("second", msgs[1].Body)
+	test.Equal(PartialClusterUpdateEvent, msgs[1].Attributes[MessageAttributeType])
+	for _, msg := range msgs {
+		test.NotEmpty(msg.ID)
+		test.Equal(1, msg.ReceiveCount)
+		test.False(msg.SentTimestamp.IsZero())
+	}
+
+	t.Log("\tTest received messages are hidden until their visibility timeout expires")
+	hidden, err := mt.receive(ctx, 10)
+	test.NoError(err)
+	test.Empty(hidden)
+
+	test.NoError(q.Delete(ctx, msgs[0]))
+	test.Error(q.Delete(ctx, msgs[0]))
+
+	test.NoError(q.ExtendVisibility(ctx, msgs[1], 0))
+	received, err := mt.receive(ctx, 10)
+	test.NoError(err)
+	test.Len(received, 1)
+	test.Equal(msgs[1].ID, received[0].ID)
+	test.Equal(2, received[0].ReceiveCount)
+
+	t.Log("\tTest receipts of previous deliveries are no longer valid")
+	test.Error(q.Delete(ctx, msgs[1]))
+	test.Error(q.ExtendVisibility(ctx, msgs[1], 10))
+	test.NoError(q.Delete(ctx, received[0]))
+
+	test.Len(mt.messages, 1)
+	test.NoError(q.Status())
+}
+
+func TestMemoryQueueWait(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test long polling for messages in memory.")
+
+	q, err := NewMemory(Config{WaitSeconds: 5, VisibilityTimeout: 60})
+	test.NoError(err)
+	mt := q.(*queue).transport.(*memoryTransport)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = q.Enqueue(context.Background(), []*Message{{Body: "late"}})
+	}()
+
+	start := time.Now()
+	msgs, err := mt.receive(context.Background(), 1)
+	test.NoError(err)
+	test.Len(msgs, 1)
+	test.Less(time.Since(start), 5*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = mt.receive(ctx, 1)
+	test.ErrorIs(err, context.Canceled)
+}
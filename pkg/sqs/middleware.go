@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"time"
+
+	"github.com/adobe/cluster-registry/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EventMetrics records the handling of the events by the dispatcher
+type EventMetrics interface {
+	RecordEventCnt(eventType, result string)
+	RecordEventDur(eventType string, elapsed float64)
+}
+
+// Logging logs the outcome of the handling of each event
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+
+			l := logger.WithValues(
+				"type", event.Type,
+				"messageId", event.Message.ID,
+				"requestId", event.RequestID(),
+				"duration", time.Since(start).String(),
+			)
+			if err != nil {
+				l.Error(err, "Failed to handle event")
+			} else {
+				l.Info("Handled event")
+			}
+			return err
+		}
+	}
+}
+
+// Tracing handles each event within a span, named after the type of the event
+func Tracing() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) (err error) {
+			ctx, span := tracing.Tracer().Start(ctx, "event."+event.Type)
+			span.SetAttributes(attribute.String("event.type", event.Type))
+			defer func() {
+				tracing.RecordError(span, err)
+				span.End()
+			}()
+			return next(ctx, event)
+		}
+	}
+}
+
+// Recording counts the handled events and records their duration, partitioned
+// by type
+func Recording(m EventMetrics) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, event *Event) error {
+			start := time.Now()
+			err := next(ctx, event)
+			m.RecordEventDur(event.Type, time.Since(start).Seconds())
+
+			result := "success"
+			if err != nil {
+				result = "failure"
+			}
+			m.RecordEventCnt(event.Type, result)
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MessageAttributeContentEncoding flags the compressed bodies, which are
+	// gzipped and base64 encoded
+	MessageAttributeContentEncoding = "ContentEncoding"
+
+	// MessageAttributePayloadRef is the key of the body in the blob store,
+	// for the messages whose body was offloaded
+	MessageAttributePayloadRef = "PayloadRef"
+
+	contentEncodingGzip = "gzip"
+
+	// defaultMaxBodySize bounds the decompressed bodies, well above the size
+	// of the largest clusters
+	defaultMaxBodySize = 4 << 20
+)
+
+// encode compresses the body of a message above the compression threshold,
+// and offloads it to the blob store if still above the offload threshold
+func (q *queue) encode(ctx context.Context, msg *Message) error {
+	if q.cfg.CompressThreshold > 0 && len(msg.Body) > q.cfg.CompressThreshold {
+		body, err := compress(msg.Body)
+		if err != nil {
+			return fmt.Errorf("cannot compress message body: %w", err)
+		}
+		setAttribute(msg, MessageAttributeContentEncoding, contentEncodingGzip)
+		msg.Body = body
+	}
+
+	if q.cfg.OffloadThreshold > 0 && len(msg.Body) > q.cfg.OffloadThreshold {
+		key := uuid.New().String()
+		if err := q.cfg.Blobs.Put(ctx, key, []byte(msg.Body)); err != nil {
+			return fmt.Errorf("cannot offload message body: %w", err)
+		}
+		logger.Info("Offloaded message body", "key", key, "size", len(msg.Body))
+		setAttribute(msg, MessageAttributePayloadRef, key)
+		msg.Body = ""
+	}
+	return nil
+}
+
+// decode restores the body of a received message, so that the handlers do not
+// depend on how it was sent. The key of an offloaded body is kept to delete it
+// along with the message.
+func (q *queue) decode(ctx context.Context, msg *Message) error {
+	if key, ok := msg.Attributes[MessageAttributePayloadRef]; ok {
+		if q.cfg.Blobs == nil {
+			return fmt.Errorf("cannot resolve message body %s without a blob store", key)
+		}
+		data, err := q.cfg.Blobs.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("cannot resolve message body %s: %w", key, err)
+		}
+		delete(msg.Attributes, MessageAttributePayloadRef)
+		msg.payloadRef = key
+		msg.Body = string(data)
+	}
+
+	switch encoding := msg.Attributes[MessageAttributeContentEncoding]; encoding {
+	case "":
+	case contentEncodingGzip:
+		maxSize := q.cfg.MaxBodySize
+		if maxSize == 0 {
+			maxSize = defaultMaxBodySize
+		}
+		body, err := decompress(msg.Body, maxSize)
+		if err != nil {
+			return fmt.Errorf("cannot decompress message body: %w", err)
+		}
+		delete(msg.Attributes, MessageAttributeContentEncoding)
+		msg.Body = body
+	default:
+		return fmt.Errorf("unknown content encoding %s", encoding)
+	}
+	return nil
+}
+
+func setAttribute(msg *Message, key, value string) {
+	if msg.Attributes == nil {
+		msg.Attributes = map[string]string{}
+	}
+	msg.Attributes[key] = value
+}
+
+func compress(body string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decompress a body, failing if it is larger than maxSize bytes once decompressed
+func decompress(body string, maxSize int) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		return "", err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	decompressed, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return "", err
+	}
+	if len(decompressed) > maxSize {
+		return "", fmt.Errorf("decompressed body is larger than %d bytes", maxSize)
+	}
+	return string(decompressed), nil
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// randomBody returns a body of the given size, which does not compress well
+func randomBody(size int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	r := rand.New(rand.NewSource(1))
+	b := make([]byte, size)
+	for i := range b {
+		b[i] = letters[r.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func TestPayloadEncoding(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test compressing and offloading large message bodies.")
+
+	tcs := []struct {
+		name               string
+		body               string
+		expectedCompressed bool
+		expectedOffloaded  bool
+	}{
+		{
+			name: "small body",
+			body: `{"name":"cluster1"}`,
+		},
+		{
+			name:               "compressible body",
+			body:               strings.Repeat(`{"name":"cluster1"}`, 1000),
+			expectedCompressed: true,
+		},
+		{
+			name:               "large body",
+			body:               randomBody(8192),
+			expectedCompressed: true,
+			expectedOffloaded:  true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen enqueuing a body of %d bytes", tc.name, len(tc.body))
+		ctx := context.Background()
+
+		dir := t.TempDir()
+		blobs, err := NewFilesystemBlobStore(dir)
+		test.NoError(err)
+
+		q, err := NewMemory(Config{
+			VisibilityTimeout: 60,
+			RunOnce:           true,
+			CompressThreshold: 1024,
+			OffloadThreshold:  4096,
+			Blobs:             blobs,
+		})
+		test.NoError(err)
+		mt := q.(*queue).transport.(*memoryTransport)
+
+		test.NoError(q.Enqueue(ctx, []*Message{
+			{Body: tc.body, Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}},
+		}))
+
+		sent := mt.messages[0].Message
+		_, compressed := sent.Attributes[MessageAttributeContentEncoding]
+		_, offloaded := sent.Attributes[MessageAttributePayloadRef]
+		test.Equal(tc.expectedCompressed, compressed)
+		test.Equal(tc.expectedOffloaded, offloaded)
+		if tc.expectedOffloaded {
+			test.Empty(sent.Body)
+		}
+
+		var received *Message
+		test.NoError(q.Poll(ctx, func(ctx context.Context, msg *Message) error {
+			received = msg
+			if err := msg.Decode(ctx); err != nil {
+				return err
+			}
+			return q.Delete(ctx, msg)
+		}))
+
+		test.NotNil(received)
+		test.Equal(tc.body, received.Body)
+		test.Equal(ClusterUpdateEvent, received.Attributes[MessageAttributeType])
+		test.NotContains(received.Attributes, MessageAttributeContentEncoding)
+		test.NotContains(received.Attributes, MessageAttributePayloadRef)
+
+		// the offloaded body is deleted along with the message
+		files, err := os.ReadDir(dir)
+		test.NoError(err)
+		test.Empty(files)
+	}
+}
+
+func TestPayloadMissingBlob(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test dead-lettering a message whose offloaded body is missing.")
+
+	ctx := context.Background()
+	blobs, err := NewFilesystemBlobStore(t.TempDir())
+	test.NoError(err)
+
+	cfg := Config{VisibilityTimeout: 60, RunOnce: true, OffloadThreshold: 16, Blobs: blobs}
+	q, ft := newFakeQueue(cfg, 0)
+	metrics := &fakeMetrics{}
+	q.cfg.Metrics = metrics
+
+	test.NoError(q.Enqueue(ctx, []*Message{
+		{Body: randomBody(64), Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}},
+	}))
+	key := ft.messages[0].Attributes[MessageAttributePayloadRef]
+	test.NoError(blobs.Delete(ctx, key))
+
+	deadLetter := &fakeDeadLetter{}
+	d, err := NewDispatcher(q, DispatcherOptions{DeadLetter: deadLetter})
+	test.NoError(err)
+	h := &fakeEventHandler{eventType: ClusterUpdateEvent}
+	d.Register(h, AckOnSuccess)
+
+	test.NoError(q.Poll(ctx, d.Handle))
+
+	test.Equal(0, h.handled)
+	test.Len(deadLetter.msgs, 1)
+	test.Len(deadLetter.reasons, 1)
+	test.True(IsPermanent(deadLetter.reasons[0]))
+	test.ErrorContains(deadLetter.reasons[0], key)
+	test.Empty(ft.messages)
+	test.Equal(1, metrics.failed)
+}
+
+func TestPayloadMaxBodySize(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rejecting the bodies which are too large once decompressed.")
+
+	body, err := compress(strings.Repeat("0", 1<<20))
+	test.NoError(err)
+	test.Less(len(body), 4096)
+
+	_, err = decompress(body, 1<<20)
+	test.NoError(err)
+
+	_, err = decompress(body, 1<<20-1)
+	test.ErrorContains(err, "larger than")
+}
+
+func TestFilesystemBlobStore(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test storing payloads in a directory.")
+
+	ctx := context.Background()
+	blobs, err := NewFilesystemBlobStore(t.TempDir())
+	test.NoError(err)
+
+	key := uuid.New().String()
+	test.NoError(blobs.Put(ctx, key, []byte("payload")))
+	data, err := blobs.Get(ctx, key)
+	test.NoError(err)
+	test.Equal("payload", string(data))
+
+	test.NoError(blobs.Delete(ctx, key))
+	test.NoError(blobs.Delete(ctx, key))
+	_, err = blobs.Get(ctx, key)
+	test.ErrorIs(err, ErrBlobNotFound)
+
+	_, err = blobs.Get(ctx, "../"+key)
+	test.Error(err)
+	test.Error(blobs.Put(ctx, "", nil))
+	test.Error(blobs.Delete(ctx, "key"))
+
+	_, err = NewFilesystemBlobStore("")
+	test.Error(err)
+}
+
+func TestBlobKeys(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test accepting the blob keys generated when offloading only.")
+
+	key := uuid.New().String()
+	tcs := []struct {
+		name          string
+		key           string
+		expectedError bool
+	}{
+		{name: "generated key", key: key},
+		{name: "empty key", key: "", expectedError: true},
+		{name: "parent directory", key: "../secrets/x", expectedError: true},
+		{name: "nested key", key: key + "/" + key, expectedError: true},
+		{name: "braced UUID", key: "{" + key + "}", expectedError: true},
+		{name: "uppercase UUID", key: strings.ToUpper(key), expectedError: true},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen resolving the object of the key %q", tc.name, tc.key)
+		objKey, err := objectKey(tc.key)
+		if tc.expectedError {
+			test.Error(err, tc.name)
+			continue
+		}
+		test.NoError(err, tc.name)
+		test.Equal("payloads/"+tc.key, objKey)
+	}
+}
@@ -0,0 +1,506 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+	"github.com/adobe/cluster-registry/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Message of the queue, independent of the backend
+type Message struct {
+	// ID identifies the message in the backend, and is set when receiving
+	ID string
+
+	// Body of the message
+	Body string
+
+	// Attributes of the message, such as its type or the trace context
+	Attributes map[string]string
+
+	// DelaySeconds postpones the delivery of the message. Not supported by Redis.
+	DelaySeconds int64
+
+	// SentTimestamp is the time when the message was enqueued, and is set when receiving
+	SentTimestamp time.Time
+
+	// ReceiveCount is the number of times the message was received, including this one
+	ReceiveCount int
+
+	// receipt identifies the delivery of the message, for deleting it or
+	// extending its visibility
+	receipt string
+
+	// payloadRef is the key of the offloaded body, deleted along with the message
+	payloadRef string
+
+	// stopHeartbeat stops extending the visibility of the message, before the
+	// handler deletes it or sets its visibility
+	stopHeartbeat func()
+
+	// decode restores the body of a received message, once it is authenticated
+	decode    func(ctx context.Context) error
+	decoded   bool
+	decodeErr error
+}
+
+// Decode restores the body of a received message, which is received as it was
+// sent, possibly compressed or offloaded, so that its signature can be checked
+// before it is decoded. It is a no-op once the message is decoded, and returns
+// the permanent failure to decode it, such as a missing offloaded body.
+func (m *Message) Decode(ctx context.Context) error {
+	if m.decode == nil || m.decoded {
+		return m.decodeErr
+	}
+	m.decoded = true
+	m.decodeErr = Permanent(m.decode(ctx))
+	return m.decodeErr
+}
+
+// DecodeError returns the permanent failure to decode the body of a received
+// message, or nil if it was decoded or not yet
+func (m *Message) DecodeError() error {
+	return m.decodeErr
+}
+
+// stopKeepingInvisible stops the heartbeat of a message being handled, if any
+func (m *Message) stopKeepingInvisible() {
+	if m.stopHeartbeat != nil {
+		m.stopHeartbeat()
+	}
+}
+
+// Handler processes the messages received by Poll. It is responsible for
+// deleting the messages, which are otherwise received again once their
+// visibility timeout expires, and for decoding them with Decode before using
+// their body.
+type Handler func(ctx context.Context, msg *Message) error
+
+// Queue is used between the clients, which enqueue updates, and the API server,
+// which consumes them
+type Queue interface {
+	// Enqueue sends messages to the queue, along with the trace context of ctx
+	Enqueue(ctx context.Context, msgs []*Message) error
+
+	// Poll receives messages and processes them with the handler, until ctx is
+	// cancelled or, if configured to run once, after the first batch
+	Poll(ctx context.Context, handler Handler) error
+
+	// Delete acknowledges a message, which will not be received again
+	Delete(ctx context.Context, msg *Message) error
+
+	// ExtendVisibility hides a received message from the other consumers for
+	// the given number of seconds from now, up to the visibility timeout of
+	// the queue with Redis
+	ExtendVisibility(ctx context.Context, msg *Message, seconds int64) error
+
+	// Status checks the connection to the backend
+	Status() error
+}
+
+// transport is the part of a Queue which is specific to a backend. The polling
+// loop and the tracing are shared between the backends.
+type transport interface {
+	// system names the backend in traces
+	system() string
+	send(ctx context.Context, msgs []*Message) error
+	receive(ctx context.Context, maxMsgs int64) ([]*Message, error)
+	delete(ctx context.Context, msg *Message) error
+	extendVisibility(ctx context.Context, msg *Message, seconds int64) error
+	status() error
+}
+
+// NewQueue creates the queue backend selected in the application configuration.
+// The connection, payload and signing settings are taken from appConfig, the
+// polling ones from cfg.
+func NewQueue(appConfig *config.AppConfig, cfg Config) (Queue, error) {
+	blobs, err := NewBlobStore(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Blobs = blobs
+	if appConfig.QueueSigningKeyFile != "" {
+		signer, err := NewSignerFromFile(appConfig.QueueSigningIdentity, appConfig.QueueSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Signer = signer
+	}
+	cfg.CompressThreshold = appConfig.QueueCompressThreshold
+	cfg.MaxBodySize = appConfig.QueueMaxBodySize
+	if blobs != nil {
+		cfg.OffloadThreshold = appConfig.QueueOffloadThreshold
+	}
+
+	switch appConfig.QueueBackend {
+	case "", config.QueueBackendSQS:
+		cfg.AWSRegion = appConfig.SqsAwsRegion
+		cfg.Endpoint = appConfig.SqsEndpoint
+		cfg.QueueName = appConfig.SqsQueueName
+		return NewSQS(cfg)
+	case config.QueueBackendRedis:
+		cfg.QueueName = appConfig.QueueRedisStream
+		cfg.ConsumerGroup = appConfig.QueueRedisGroup
+		client := cfg.RedisClient
+		if client == nil {
+			client = redis.NewClient(redisOptions(appConfig.QueueRedisHost, appConfig.QueueRedisTLSEnabled))
+		}
+		return NewRedis(cfg, client)
+	case config.QueueBackendMemory:
+		return NewMemory(cfg)
+	default:
+		return nil, fmt.Errorf("unknown queue backend %s", appConfig.QueueBackend)
+	}
+}
+
+const (
+	// maxVisibilityTimeout is the longest a message can be hidden, in seconds
+	maxVisibilityTimeout = 12 * 60 * 60
+
+	// minReceiveBackoff is the delay before receiving again after an error
+	minReceiveBackoff = time.Second
+
+	// maxReceiveBackoff caps the delay, which doubles on consecutive errors
+	maxReceiveBackoff = time.Minute
+)
+
+type queue struct {
+	cfg       Config
+	transport transport
+
+	// delays before receiving again after errors
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	// heartbeat is the interval at which the visibility of the messages being
+	// processed is extended
+	heartbeat time.Duration
+
+	inFlight int
+	mutex    sync.Mutex
+}
+
+func newQueue(cfg Config, t transport) *queue {
+	return &queue{
+		cfg:        cfg,
+		transport:  t,
+		minBackoff: minReceiveBackoff,
+		maxBackoff: maxReceiveBackoff,
+		heartbeat:  time.Duration(cfg.VisibilityTimeout) * time.Second / 2,
+	}
+}
+
+// Enqueue messages, along with the trace context of ctx
+func (q *queue) Enqueue(ctx context.Context, msgs []*Message) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "sqs.Enqueue",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(q.transport.system()),
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingDestinationName(q.cfg.QueueName),
+			semconv.MessagingBatchMessageCount(len(msgs)),
+		),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	// the encoded messages are signed, so that their signature is checked
+	// before they are decompressed or their body is fetched
+	for _, msg := range msgs {
+		InjectTraceContext(ctx, msg)
+		if err := q.encode(ctx, msg); err != nil {
+			return err
+		}
+		if q.cfg.Signer != nil {
+			q.cfg.Signer.Sign(msg)
+		}
+	}
+
+	logger.Info("Enqueuing messages", "count", len(msgs))
+	return q.transport.send(ctx, msgs)
+}
+
+// Poll receives messages and processes them with a pool of cfg.MaxHandlers
+// workers. Messages are only received for the idle workers, so that their
+// visibility timeout does not expire while they wait. Once ctx is cancelled no
+// more messages are received, and Poll returns after the in-flight ones are
+// processed.
+func (q *queue) Poll(ctx context.Context, handler Handler) error {
+	workers := max(q.cfg.MaxHandlers, 1)
+
+	// idle holds a token per idle worker
+	idle := make(chan struct{}, workers)
+	msgs := make(chan *Message)
+
+	// the handlers are not cancelled along with ctx, for the in-flight
+	// messages to be drained
+	handlerCtx := context.WithoutCancel(ctx)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		idle <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range msgs {
+				q.handle(handlerCtx, handler, msg)
+				idle <- struct{}{}
+			}
+		}()
+	}
+	defer func() {
+		close(msgs)
+		logger.Info("Draining in-flight messages", "count", q.activeHandlers())
+		wg.Wait()
+		logger.Info("Stopped polling")
+	}()
+
+	backoff := q.minBackoff
+	for {
+		maxMsgs := acquire(ctx, idle, max(q.cfg.BatchSize, 1))
+		if maxMsgs == 0 {
+			return nil
+		}
+
+		received, err := q.transport.receive(ctx, maxMsgs)
+		release(idle, maxMsgs-int64(len(received)))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if q.cfg.RunOnce {
+				logger.Error(err, "Failed to receive messages")
+				return err
+			}
+			logger.Error(err, "Failed to receive messages, retrying", "backoff", backoff.String())
+			if !sleep(ctx, backoff) {
+				return nil
+			}
+			backoff = min(2*backoff, q.maxBackoff)
+			continue
+		}
+		backoff = q.minBackoff
+
+		if len(received) > 0 {
+			logger.Info("Fetched messages", "count", len(received))
+		}
+		for _, msg := range received {
+			q.addInFlight(1)
+			msgs <- msg
+		}
+
+		if q.cfg.RunOnce {
+			logger.Info("Exiting since configured to run once")
+			return nil
+		}
+
+		if len(received) == 0 {
+			logger.Info("Queue is empty, waiting before polling again", "interval", q.cfg.RunInterval)
+			if !sleep(ctx, time.Duration(q.cfg.RunInterval)*time.Second) {
+				return nil
+			}
+		}
+	}
+}
+
+// handle processes a message within a span, child of the one which enqueued
+// it, and keeps the message hidden from the other consumers meanwhile
+func (q *queue) handle(ctx context.Context, handler Handler, msg *Message) {
+	defer q.addInFlight(-1)
+
+	ctx, span := tracing.Tracer().Start(ExtractTraceContext(ctx, msg), "sqs.Process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String(q.transport.system()),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(q.cfg.QueueName),
+			semconv.MessagingMessageID(msg.ID),
+		),
+	)
+	defer span.End()
+
+	msg.stopHeartbeat = q.keepInvisible(ctx, msg)
+	msg.decode = func(ctx context.Context) error {
+		return q.decode(ctx, msg)
+	}
+	err := handler(ctx, msg)
+	msg.stopHeartbeat()
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		logger.Error(err, "Failed to handle message", "messageId", msg.ID)
+		if q.cfg.Metrics != nil {
+			q.cfg.Metrics.RecordQueueFailedCnt(q.cfg.QueueName)
+		}
+	}
+}
+
+// keepInvisible extends the visibility of the message every heartbeat, until
+// the returned function is first called
+func (q *queue) keepInvisible(ctx context.Context, msg *Message) func() {
+	if q.heartbeat <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(q.heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := q.transport.extendVisibility(ctx, msg, q.cfg.VisibilityTimeout)
+				if err != nil {
+					logger.Error(err, "Failed to extend message visibility", "messageId", msg.ID)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// Delete a message from the queue
+func (q *queue) Delete(ctx context.Context, msg *Message) error {
+	msg.stopKeepingInvisible()
+	logger.Info("Deleting message", "messageId", msg.ID)
+	if err := q.transport.delete(ctx, msg); err != nil {
+		return err
+	}
+
+	if msg.payloadRef != "" {
+		if err := q.cfg.Blobs.Delete(ctx, msg.payloadRef); err != nil {
+			logger.Error(err, "Failed to delete offloaded message body", "key", msg.payloadRef)
+		}
+	}
+	return nil
+}
+
+// ExtendVisibility of a received message. The heartbeat is stopped first, so
+// that it does not override the visibility set by the handler, such as the
+// backoff of a retry.
+func (q *queue) ExtendVisibility(ctx context.Context, msg *Message, seconds int64) error {
+	msg.stopKeepingInvisible()
+	logger.Info("Changing message visibility timeout", "messageId", msg.ID, "seconds", seconds)
+	return q.transport.extendVisibility(ctx, msg, seconds)
+}
+
+// Status of the connection to the backend
+func (q *queue) Status() error {
+	return q.transport.status()
+}
+
+func (q *queue) activeHandlers() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.inFlight
+}
+
+// addInFlight updates the number of messages being processed
+func (q *queue) addInFlight(delta int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.inFlight += delta
+	if q.cfg.Metrics != nil {
+		q.cfg.Metrics.RecordQueueInFlight(q.cfg.QueueName, q.inFlight)
+	}
+}
+
+// acquire takes up to n tokens from idle, waiting for the first one. It returns
+// the number of tokens taken, which is 0 if ctx was cancelled meanwhile.
+func acquire(ctx context.Context, idle chan struct{}, n int64) int64 {
+	select {
+	case <-ctx.Done():
+		return 0
+	case <-idle:
+	}
+
+	acquired := int64(1)
+	for acquired < n {
+		select {
+		case <-idle:
+			acquired++
+		default:
+			return acquired
+		}
+	}
+	return acquired
+}
+
+// release gives n tokens back to idle
+func release(idle chan struct{}, n int64) {
+	for i := int64(0); i < n; i++ {
+		idle <- struct{}{}
+	}
+}
+
+// sleep waits for the given duration, and returns false if ctx was cancelled meanwhile
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// validatePolling checks the settings shared by all the backends
+func validatePolling(cfg Config) error {
+	if cfg.BatchSize < 0 || cfg.BatchSize > 10 {
+		return errors.New("BatchSize should be between 1-10")
+	}
+
+	if cfg.WaitSeconds < 0 || cfg.WaitSeconds > 20 {
+		return errors.New("WaitSeconds should be between 1-20")
+	}
+
+	if cfg.VisibilityTimeout < 0 || cfg.VisibilityTimeout > maxVisibilityTimeout {
+		return errors.New("VisibilityTimeout should be between 1-43200")
+	}
+
+	if cfg.CompressThreshold < 0 || cfg.OffloadThreshold < 0 || cfg.MaxBodySize < 0 {
+		return errors.New("CompressThreshold, OffloadThreshold and MaxBodySize should not be negative")
+	}
+
+	if cfg.OffloadThreshold > 0 && cfg.Blobs == nil {
+		return errors.New("a blob store is required to offload message bodies")
+	}
+
+	return nil
+}
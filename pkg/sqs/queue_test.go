@@ -0,0 +1,321 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awssqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTransport wraps the memory transport, failing the first receives and
+// counting the visibility extensions
+type fakeTransport struct {
+	*memoryTransport
+
+	receiveFailures atomic.Int32
+	extensions      atomic.Int32
+}
+
+func (t *fakeTransport) receive(ctx context.Context, maxMsgs int64) ([]*Message, error) {
+	if t.receiveFailures.Add(-1) >= 0 {
+		return nil, errors.New("connection refused")
+	}
+	return t.memoryTransport.receive(ctx, maxMsgs)
+}
+
+func (t *fakeTransport) extendVisibility(ctx context.Context, msg *Message, seconds int64) error {
+	t.extensions.Add(1)
+	return t.memoryTransport.extendVisibility(ctx, msg, seconds)
+}
+
+type fakeMetrics struct {
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+	failed      int
+}
+
+func (m *fakeMetrics) RecordQueueInFlight(_ string, count int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight = count
+	m.maxInFlight = max(m.maxInFlight, count)
+}
+
+func (m *fakeMetrics) RecordQueueFailedCnt(_ string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.failed++
+}
+
+func newFakeQueue(cfg Config, receiveFailures int32) (*queue, *fakeTransport) {
+	t := &fakeTransport{
+		memoryTransport: &memoryTransport{cfg: cfg, arrived: make(chan struct{}, 1)},
+	}
+	t.receiveFailures.Store(receiveFailures)
+
+	q := newQueue(cfg, t)
+	q.minBackoff = 10 * time.Millisecond
+	q.maxBackoff = 40 * time.Millisecond
+	return q, t
+}
+
+func enqueue(t *testing.T, q Queue, count int) {
+	msgs := make([]*Message, 0, count)
+	for i := 0; i < count; i++ {
+		msgs = append(msgs, &Message{Body: fmt.Sprintf("message-%d", i)})
+	}
+	assert.NoError(t, q.Enqueue(context.Background(), msgs))
+}
+
+func TestPollWorkerPool(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test processing the messages with a bounded worker pool.")
+
+	tcs := []struct {
+		name            string
+		maxHandlers     int
+		messages        int
+		failEvery       int
+		expectedFailed  int
+		expectedDeleted int
+	}{
+		{
+			name:            "single worker",
+			maxHandlers:     0,
+			messages:        5,
+			expectedDeleted: 5,
+		},
+		{
+			name:            "more messages than workers",
+			maxHandlers:     3,
+			messages:        20,
+			expectedDeleted: 20,
+		},
+		{
+			name:            "failing handler",
+			maxHandlers:     4,
+			messages:        12,
+			failEvery:       3,
+			expectedFailed:  4,
+			expectedDeleted: 8,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen processing %d messages with %d handlers", tc.name, tc.messages, tc.maxHandlers)
+
+		metrics := &fakeMetrics{}
+		q, mt := newFakeQueue(Config{
+			BatchSize:         10,
+			VisibilityTimeout: 60,
+			MaxHandlers:       tc.maxHandlers,
+			Metrics:           metrics,
+		}, 0)
+		enqueue(t, q, tc.messages)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var processed, active, maxActive atomic.Int32
+		err := q.Poll(ctx, func(ctx context.Context, msg *Message) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+
+			count := int(processed.Add(1))
+			if count == tc.messages {
+				cancel()
+			}
+			if tc.failEvery > 0 && count%tc.failEvery == 0 {
+				return errors.New("handler failed")
+			}
+			return q.Delete(ctx, msg)
+		})
+		test.NoError(err)
+
+		test.Equal(int32(tc.messages), processed.Load())
+		test.LessOrEqual(int(maxActive.Load()), max(tc.maxHandlers, 1))
+		test.LessOrEqual(metrics.maxInFlight, max(tc.maxHandlers, 1))
+		test.Equal(0, metrics.inFlight)
+		test.Equal(tc.expectedFailed, metrics.failed)
+		test.Len(mt.messages, tc.messages-tc.expectedDeleted)
+	}
+}
+
+func TestPollDrain(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test draining the in-flight messages when polling is cancelled.")
+
+	q, mt := newFakeQueue(Config{BatchSize: 10, VisibilityTimeout: 60, MaxHandlers: 2}, 0)
+	enqueue(t, q, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	var handlerErrs atomic.Int32
+
+	done := make(chan error)
+	go func() {
+		done <- q.Poll(ctx, func(ctx context.Context, msg *Message) error {
+			started <- struct{}{}
+			<-release
+			if ctx.Err() != nil {
+				handlerErrs.Add(1)
+			}
+			return q.Delete(ctx, msg)
+		})
+	}()
+
+	<-started
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+		test.Fail("Poll returned before the in-flight messages were processed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	test.NoError(<-done)
+	test.Zero(handlerErrs.Load())
+	test.Empty(mt.messages)
+}
+
+func TestPollReceiveErrors(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test receiving messages again after errors.")
+
+	tcs := []struct {
+		name            string
+		runOnce         bool
+		receiveFailures int32
+		expectedError   bool
+	}{
+		{
+			name:            "retried with backoff",
+			receiveFailures: 3,
+		},
+		{
+			name:            "run once",
+			runOnce:         true,
+			receiveFailures: 1,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen the first %d receives fail", tc.name, tc.receiveFailures)
+
+		q, mt := newFakeQueue(Config{VisibilityTimeout: 60, RunOnce: tc.runOnce}, tc.receiveFailures)
+		enqueue(t, q, 1)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := q.Poll(ctx, func(ctx context.Context, msg *Message) error {
+			defer cancel()
+			return q.Delete(ctx, msg)
+		})
+		cancel()
+
+		if tc.expectedError {
+			test.Error(err)
+			test.Len(mt.messages, 1)
+		} else {
+			test.NoError(err)
+			test.Empty(mt.messages)
+		}
+		test.LessOrEqual(mt.receiveFailures.Load(), int32(0))
+	}
+}
+
+func TestPollHeartbeat(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test extending the visibility of the messages being processed.")
+
+	q, mt := newFakeQueue(Config{VisibilityTimeout: 1, RunOnce: true}, 0)
+	q.heartbeat = 10 * time.Millisecond
+	enqueue(t, q, 1)
+
+	err := q.Poll(context.Background(), func(ctx context.Context, msg *Message) error {
+		time.Sleep(100 * time.Millisecond)
+		return q.Delete(ctx, msg)
+	})
+	test.NoError(err)
+
+	test.GreaterOrEqual(mt.extensions.Load(), int32(2))
+	extensions := mt.extensions.Load()
+	time.Sleep(30 * time.Millisecond)
+	test.Equal(extensions, mt.extensions.Load())
+	test.Empty(mt.messages)
+}
+
+func TestPollHeartbeatStopped(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that the heartbeat does not override the visibility set by the handler.")
+
+	q, mt := newFakeQueue(Config{VisibilityTimeout: 1, RunOnce: true}, 0)
+	q.heartbeat = 10 * time.Millisecond
+	enqueue(t, q, 1)
+
+	var extensions int32
+	err := q.Poll(context.Background(), func(ctx context.Context, msg *Message) error {
+		time.Sleep(30 * time.Millisecond)
+		// postponed like a retry with a backoff
+		if err := q.ExtendVisibility(ctx, msg, 60); err != nil {
+			return err
+		}
+		extensions = mt.extensions.Load()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	test.NoError(err)
+
+	test.Equal(extensions, mt.extensions.Load())
+	test.Len(mt.messages, 1)
+	test.True(mt.messages[0].visibleAt.After(time.Now().Add(30 * time.Second)))
+}
+
+func TestBatchError(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test reporting the entries of a batch which SQS failed to enqueue.")
+
+	test.NoError(batchError(nil))
+
+	err := batchError([]*awssqs.BatchResultErrorEntry{
+		{Id: aws.String("1"), Code: aws.String("InvalidParameterValue"), Message: aws.String("too many attributes")},
+		{Id: aws.String("2"), Code: aws.String("InternalError"), SenderFault: aws.Bool(false)},
+	})
+	test.ErrorContains(err, "cannot enqueue 2 messages")
+	test.ErrorContains(err, "entry 1 failed with InvalidParameterValue: too many attributes")
+	test.ErrorContains(err, "entry 2 failed with InternalError")
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisFieldBody is the field of the stream entries holding the message body
+	redisFieldBody = "body"
+
+	// redisAttributePrefix prefixes the fields of the stream entries holding
+	// the message attributes
+	redisAttributePrefix = "attr:"
+
+	// defaultConsumerGroup is used if no consumer group is configured
+	defaultConsumerGroup = "cluster-registry"
+)
+
+// redisTransport sends and receives the messages through a Redis stream. The
+// consumers of a group share the messages, and the messages which were not
+// deleted within the visibility timeout are claimed by the next receive.
+type redisTransport struct {
+	cfg    Config
+	client redis.UniversalClient
+}
+
+// NewRedis creates a queue backed by the cfg.QueueName Redis stream, and the
+// cfg.ConsumerGroup consumer group, which are created if missing
+func NewRedis(cfg Config, client redis.UniversalClient) (Queue, error) {
+	if cfg.QueueName == "" {
+		return nil, errors.New("A valid Redis stream name is required")
+	}
+	if err := validatePolling(cfg); err != nil {
+		logger.Error(err, "invalid Redis Config")
+		return nil, err
+	}
+
+	if cfg.ConsumerGroup == "" {
+		cfg.ConsumerGroup = defaultConsumerGroup
+	}
+	if cfg.ConsumerName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get the consumer name: %w", err)
+		}
+		cfg.ConsumerName = hostname
+	}
+
+	err := client.XGroupCreateMkStream(context.Background(), cfg.QueueName, cfg.ConsumerGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		logger.Error(err, "Unable to create consumer group", "stream", cfg.QueueName, "group", cfg.ConsumerGroup)
+		return nil, fmt.Errorf("unable to create consumer group: %w", err)
+	}
+	logger.Info("Connected to Redis stream", "stream", cfg.QueueName, "group", cfg.ConsumerGroup, "consumer", cfg.ConsumerName)
+
+	return newQueue(cfg, &redisTransport{cfg: cfg, client: client}), nil
+}
+
+// redisOptions returns the options of the Redis client, enabling TLS for the
+// given host if requested
+func redisOptions(host string, tlsEnabled bool) *redis.Options {
+	options := &redis.Options{
+		Addr: host,
+	}
+
+	if tlsEnabled {
+		options.TLSConfig = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		}
+		redisHost := strings.Split(host, ":")[0]
+		if ipAddr := net.ParseIP(redisHost); ipAddr == nil {
+			options.TLSConfig.ServerName = redisHost
+		}
+	}
+
+	return options
+}
+
+func (t *redisTransport) system() string {
+	return "redis"
+}
+
+func (t *redisTransport) send(ctx context.Context, msgs []*Message) error {
+	_, err := t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, msg := range msgs {
+			values := make([]interface{}, 0, 2+2*len(msg.Attributes))
+			values = append(values, redisFieldBody, msg.Body)
+			for k, v := range msg.Attributes {
+				values = append(values, redisAttributePrefix+k, v)
+			}
+			pipe.XAdd(ctx, &redis.XAddArgs{
+				Stream: t.cfg.QueueName,
+				Values: values,
+			})
+		}
+		return nil
+	})
+	return err
+}
+
+func (t *redisTransport) receive(ctx context.Context, maxMsgs int64) ([]*Message, error) {
+	msgs, err := t.claim(ctx, maxMsgs)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(msgs)) >= maxMsgs {
+		return msgs, nil
+	}
+
+	// Block is omitted if negative, since 0 blocks forever
+	block := time.Duration(t.cfg.WaitSeconds) * time.Second
+	if block == 0 {
+		block = -1
+	}
+
+	streams, err := t.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    t.cfg.ConsumerGroup,
+		Consumer: t.cfg.ConsumerName,
+		Streams:  []string{t.cfg.QueueName, ">"},
+		Count:    maxMsgs - int64(len(msgs)),
+		Block:    block,
+	}).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	for _, stream := range streams {
+		for _, m := range stream.Messages {
+			msgs = append(msgs, newRedisMessage(m, 1))
+		}
+	}
+	return msgs, nil
+}
+
+// claim takes over the messages which were received by any consumer of the
+// group, but not deleted within the visibility timeout
+func (t *redisTransport) claim(ctx context.Context, maxMsgs int64) ([]*Message, error) {
+	claimed, _, err := t.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   t.cfg.QueueName,
+		Group:    t.cfg.ConsumerGroup,
+		Consumer: t.cfg.ConsumerName,
+		MinIdle:  time.Duration(t.cfg.VisibilityTimeout) * time.Second,
+		Start:    "0-0",
+		Count:    maxMsgs,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*Message, 0, len(claimed))
+	for _, m := range claimed {
+		pending, err := t.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+			Stream: t.cfg.QueueName,
+			Group:  t.cfg.ConsumerGroup,
+			Start:  m.ID,
+			End:    m.ID,
+			Count:  1,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		receiveCount := 1
+		if len(pending) > 0 {
+			receiveCount = int(pending[0].RetryCount)
+		}
+		msgs = append(msgs, newRedisMessage(m, receiveCount))
+	}
+	return msgs, nil
+}
+
+func newRedisMessage(m redis.XMessage, receiveCount int) *Message {
+	msg := &Message{
+		ID:           m.ID,
+		Attributes:   map[string]string{},
+		ReceiveCount: receiveCount,
+		receipt:      m.ID,
+	}
+	for k, v := range m.Values {
+		value := fmt.Sprint(v)
+		if k == redisFieldBody {
+			msg.Body = value
+		} else if name, ok := strings.CutPrefix(k, redisAttributePrefix); ok {
+			msg.Attributes[name] = value
+		}
+	}
+
+	// the ID of the stream entries starts with the time they were added
+	if sent, err := strconv.ParseInt(strings.Split(m.ID, "-")[0], 10, 64); err == nil {
+		msg.SentTimestamp = time.UnixMilli(sent)
+	}
+	return msg
+}
+
+func (t *redisTransport) delete(ctx context.Context, msg *Message) error {
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAck(ctx, t.cfg.QueueName, t.cfg.ConsumerGroup, msg.receipt)
+		pipe.XDel(ctx, t.cfg.QueueName, msg.receipt)
+		return nil
+	})
+	return err
+}
+
+// extendVisibility sets the idle time of the pending message, so that it is
+// claimed once the given number of seconds have elapsed. The visibility cannot
+// be extended beyond the configured visibility timeout. The retry count is kept,
+// since extending the visibility is not a delivery.
+// extendVisibility resets the idle time of the pending entry, so that it is
+// claimed again once idle for the visibility timeout. The entry cannot be
+// hidden for longer than the visibility timeout, which caps seconds, e.g. the
+// retry backoff of the dispatcher.
+func (t *redisTransport) extendVisibility(ctx context.Context, msg *Message, seconds int64) error {
+	idle := max(t.cfg.VisibilityTimeout-seconds, 0) * 1000
+	return t.client.Do(ctx, "XCLAIM", t.cfg.QueueName, t.cfg.ConsumerGroup, t.cfg.ConsumerName,
+		0, msg.receipt, "IDLE", idle, "RETRYCOUNT", msg.ReceiveCount, "JUSTID").Err()
+}
+
+func (t *redisTransport) status() error {
+	return t.client.Ping(context.Background()).Err()
+}
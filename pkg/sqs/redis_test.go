@@ -0,0 +1,172 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisQueue(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test enqueuing, receiving and deleting messages in a Redis stream.")
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	ctx := context.Background()
+
+	cfg := Config{
+		QueueName:         "cluster-registry",
+		ConsumerName:      "consumer-1",
+		BatchSize:         10,
+		VisibilityTimeout: 60,
+	}
+	q, err := NewRedis(cfg, client)
+	test.NoError(err)
+	rt := q.(*queue).transport.(*redisTransport)
+	test.Equal(defaultConsumerGroup, rt.cfg.ConsumerGroup)
+
+	// creating the consumer group again is a no-op
+	_, err = NewRedis(cfg, client)
+	test.NoError(err)
+
+	err = q.Enqueue(ctx, []*Message{
+		{Body: "first", Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}},
+		{Body: "second", Attributes: map[string]string{MessageAttributeType: PartialClusterUpdateEvent}},
+	})
+	test.NoError(err)
+
+	msgs, err := rt.receive(ctx, 10)
+	test.NoError(err)
+	test.Len(msgs, 2)
+	test.Equal("first", msgs[0].Body)
+	test.Equal(ClusterUpdateEvent, msgs[0].Attributes[MessageAttributeType])
+	test.Equal("second", msgs[1].Body)
+	test.Equal(PartialClusterUpdateEvent, msgs[1].Attributes[MessageAttributeType])
+	for _, msg := range msgs {
+		test.NotEmpty(msg.ID)
+		test.Equal(1, msg.ReceiveCount)
+		test.False(msg.SentTimestamp.IsZero())
+	}
+
+	t.Log("\tTest received messages are not delivered to the other consumers")
+	cfg.ConsumerName = "consumer-2"
+	other, err := NewRedis(cfg, client)
+	test.NoError(err)
+	ot := other.(*queue).transport.(*redisTransport)
+
+	pending, err := ot.receive(ctx, 10)
+	test.NoError(err)
+	test.Empty(pending)
+
+	test.NoError(q.Delete(ctx, msgs[0]))
+	test.Equal(int64(1), client.XLen(ctx, cfg.QueueName).Val())
+
+	t.Log("\tTest messages are claimed by the other consumers once their visibility timeout expires")
+	test.NoError(q.ExtendVisibility(ctx, msgs[1], 0))
+
+	claimed, err := ot.receive(ctx, 10)
+	test.NoError(err)
+	test.Len(claimed, 1)
+	test.Equal(msgs[1].ID, claimed[0].ID)
+	test.Equal("second", claimed[0].Body)
+	test.Equal(2, claimed[0].ReceiveCount)
+
+	test.NoError(other.Delete(ctx, claimed[0]))
+	test.Equal(int64(0), client.XLen(ctx, cfg.QueueName).Val())
+
+	test.NoError(q.Status())
+	mr.Close()
+	test.Error(q.Status())
+}
+
+func TestNewQueue(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test selecting the queue backend.")
+
+	mr := miniredis.RunT(t)
+
+	tcs := []struct {
+		name              string
+		appConfig         *config.AppConfig
+		expectedTransport transport
+		expectedError     bool
+	}{
+		{
+			name: "redis backend",
+			appConfig: &config.AppConfig{
+				QueueBackend:     config.QueueBackendRedis,
+				QueueRedisHost:   mr.Addr(),
+				QueueRedisStream: "cluster-registry",
+				QueueRedisGroup:  "cluster-registry",
+			},
+			expectedTransport: &redisTransport{},
+		},
+		{
+			name: "memory backend",
+			appConfig: &config.AppConfig{
+				QueueBackend: config.QueueBackendMemory,
+			},
+			expectedTransport: &memoryTransport{},
+		},
+		{
+			name: "unknown backend",
+			appConfig: &config.AppConfig{
+				QueueBackend: "kafka",
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen creating a queue for backend %s", tc.name, tc.appConfig.QueueBackend)
+
+		q, err := NewQueue(tc.appConfig, Config{WaitSeconds: 1, RunInterval: 1})
+		if tc.expectedError {
+			test.Error(err)
+			continue
+		}
+		test.NoError(err)
+		test.IsType(tc.expectedTransport, q.(*queue).transport)
+		test.NoError(q.Status())
+	}
+}
+
+func TestNewRedisMessage(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test parsing Redis stream entries.")
+
+	msg := newRedisMessage(redis.XMessage{
+		ID: "1700000000000-0",
+		Values: map[string]interface{}{
+			redisFieldBody: `{"foo":"bar"}`,
+			redisAttributePrefix + MessageAttributeType: ClusterUpdateEvent,
+			"unknown": "ignored",
+		},
+	}, 3)
+
+	test.Equal("1700000000000-0", msg.ID)
+	test.Equal(`{"foo":"bar"}`, msg.Body)
+	test.Equal(map[string]string{MessageAttributeType: ClusterUpdateEvent}, msg.Attributes)
+	test.Equal(3, msg.ReceiveCount)
+	test.Equal(time.UnixMilli(1700000000000), msg.SentTimestamp)
+}
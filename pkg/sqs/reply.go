@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MessageAttributeReplyTo is the name of the queue where the sender of a
+	// message expects the reply
+	MessageAttributeReplyTo = "ReplyTo"
+
+	// MessageAttributeHash is the hash of the cluster spec sent by the client,
+	// which is echoed by the acknowledgement
+	MessageAttributeHash = "Hash"
+
+	// MessageAttributeRevision is the revision of the cluster stored by the
+	// registry
+	MessageAttributeRevision = "Revision"
+)
+
+// NewNamedQueue creates a queue with the backend settings of appConfig, but
+// another name, such as the stream of the Redis backend
+func NewNamedQueue(appConfig *config.AppConfig, name string, cfg Config) (Queue, error) {
+	named := *appConfig
+	named.SqsQueueName = name
+	named.QueueRedisStream = name
+	return NewQueue(&named, cfg)
+}
+
+// maxReplyQueues bounds the queue clients kept by a replier, the oldest one is
+// dropped to make room for a new one
+const maxReplyQueues = 1000
+
+// ErrReplyNotAllowed is returned for the ReplyTo queues without the prefix of
+// the replier
+var ErrReplyNotAllowed = errors.New("reply queue is not allowed")
+
+// Replier sends the replies to the queues named by the ReplyTo attribute of the
+// received messages, creating a queue client per name
+type Replier struct {
+	newQueue  func(name string) (Queue, error)
+	prefix    string
+	maxQueues int
+
+	queues map[string]Queue
+	// names of the queues in the order they were created
+	names []string
+	mutex sync.Mutex
+}
+
+// NewReplier creates a replier for the queues with the backend settings of
+// appConfig, whose names start with its acknowledgement prefix. The Redis
+// queues share a client, so that the dropped ones leave no connection open.
+func NewReplier(appConfig *config.AppConfig, cfg Config) *Replier {
+	if appConfig.QueueBackend == config.QueueBackendRedis && cfg.RedisClient == nil {
+		cfg.RedisClient = redis.NewClient(redisOptions(appConfig.QueueRedisHost, appConfig.QueueRedisTLSEnabled))
+	}
+	return newReplier(appConfig.QueueAckPrefix, maxReplyQueues, func(name string) (Queue, error) {
+		return NewNamedQueue(appConfig, name, cfg)
+	})
+}
+
+func newReplier(prefix string, maxQueues int, newQueue func(name string) (Queue, error)) *Replier {
+	return &Replier{
+		newQueue:  newQueue,
+		prefix:    prefix,
+		maxQueues: maxQueues,
+		queues:    map[string]Queue{},
+	}
+}
+
+// Reply sends the reply to the queue named by the ReplyTo attribute of msg, and
+// does nothing if the sender expects no reply
+func (r *Replier) Reply(ctx context.Context, msg *Message, reply *Message) error {
+	to := msg.Attributes[MessageAttributeReplyTo]
+	if to == "" {
+		return nil
+	}
+	if !strings.HasPrefix(to, r.prefix) || to == r.prefix {
+		return fmt.Errorf("%w: %s", ErrReplyNotAllowed, to)
+	}
+
+	q, err := r.queue(to)
+	if err != nil {
+		return err
+	}
+	return q.Enqueue(ctx, []*Message{reply})
+}
+
+func (r *Replier) queue(name string) (Queue, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if q, ok := r.queues[name]; ok {
+		return q, nil
+	}
+	q, err := r.newQueue(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.names) >= r.maxQueues {
+		delete(r.queues, r.names[0])
+		r.names = r.names[1:]
+	}
+	r.queues[name] = q
+	r.names = append(r.names, name)
+	return q, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/adobe/cluster-registry/pkg/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplier(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test replying to the queue named by the messages.")
+
+	ctx := context.Background()
+	queues := map[string]Queue{}
+	created := 0
+	replier := newReplier("cluster-registry-", 2, func(name string) (Queue, error) {
+		if name == "cluster-registry-missing" {
+			return nil, errors.New("queue does not exist")
+		}
+		created++
+		q, err := NewMemory(Config{VisibilityTimeout: 60, RunOnce: true})
+		queues[name] = q
+		return q, err
+	})
+
+	reply := func() *Message {
+		return &Message{Body: "{}", Attributes: map[string]string{MessageAttributeType: ClusterAckEvent}}
+	}
+
+	// the messages without ReplyTo expect no reply
+	test.NoError(replier.Reply(ctx, &Message{Attributes: map[string]string{}}, reply()))
+	test.Equal(0, created)
+
+	msg := &Message{Attributes: map[string]string{MessageAttributeReplyTo: "cluster-registry-cluster1-acks"}}
+	test.NoError(replier.Reply(ctx, msg, reply()))
+	test.NoError(replier.Reply(ctx, msg, reply()))
+	test.Equal(1, created)
+
+	received := 0
+	for i := 0; i < 3; i++ {
+		test.NoError(queues["cluster-registry-cluster1-acks"].Poll(ctx, func(ctx context.Context, msg *Message) error {
+			test.Equal(ClusterAckEvent, msg.Attributes[MessageAttributeType])
+			received++
+			return queues["cluster-registry-cluster1-acks"].Delete(ctx, msg)
+		}))
+	}
+	test.Equal(2, received)
+
+	test.Error(replier.Reply(ctx, &Message{Attributes: map[string]string{MessageAttributeReplyTo: "cluster-registry-missing"}}, reply()))
+
+	// the queues without the prefix are never created
+	for _, to := range []string{"other-queue", "cluster-registry-"} {
+		err := replier.Reply(ctx, &Message{Attributes: map[string]string{MessageAttributeReplyTo: to}}, reply())
+		test.ErrorIs(err, ErrReplyNotAllowed, to)
+	}
+	test.Equal(1, created)
+
+	// the oldest queue clients are dropped past the limit
+	for _, to := range []string{"cluster-registry-cluster2-acks", "cluster-registry-cluster3-acks"} {
+		test.NoError(replier.Reply(ctx, &Message{Attributes: map[string]string{MessageAttributeReplyTo: to}}, reply()))
+	}
+	test.Equal(3, created)
+	test.Len(replier.queues, 2)
+	test.NotContains(replier.queues, "cluster-registry-cluster1-acks")
+}
+
+func TestRedisReplierSharesClient(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that the Redis reply queues share a client, which outlives the dropped queues.")
+
+	mr := miniredis.RunT(t)
+	appConfig := &config.AppConfig{
+		QueueBackend:     config.QueueBackendRedis,
+		QueueRedisHost:   mr.Addr(),
+		QueueRedisStream: "cluster-registry",
+		QueueAckPrefix:   "cluster-registry-",
+	}
+	replier := NewReplier(appConfig, Config{VisibilityTimeout: 60})
+	replier.maxQueues = 1
+
+	ctx := context.Background()
+	var clients []redis.UniversalClient
+	for _, to := range []string{"cluster-registry-cluster1-acks", "cluster-registry-cluster2-acks"} {
+		msg := &Message{Attributes: map[string]string{MessageAttributeReplyTo: to}}
+		test.NoError(replier.Reply(ctx, msg, &Message{Body: "{}", Attributes: map[string]string{MessageAttributeType: ClusterAckEvent}}))
+		clients = append(clients, replier.queues[to].(*queue).transport.(*redisTransport).client)
+	}
+	test.Len(replier.queues, 1)
+	test.Same(clients[0], clients[1])
+	test.NoError(clients[0].Ping(ctx).Err())
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// MessageAttributeSignature holds the identity which signed the message, the
+// RFC 3339 time it was signed at and the base64 encoded Ed25519 signature of
+// the body and of the signed attributes, separated by semicolons. They share a
+// single attribute as SQS accepts at most 10 attributes per message.
+const MessageAttributeSignature = "Signature"
+
+// signatureSeparator separates the fields of the signature attribute
+const signatureSeparator = ";"
+
+// signedAttributes are covered by the signature along with the encoded body,
+// which is signed as sent so that it is only decoded once authenticated. The
+// trace context is not, as it changes in transit.
+var signedAttributes = []string{
+	MessageAttributeType,
+	MessageAttributeClusterName,
+	MessageAttributeSkipCacheInvalidation,
+	MessageAttributeRequestID,
+	MessageAttributeReplyTo,
+	MessageAttributeHash,
+	MessageAttributeContentEncoding,
+	MessageAttributePayloadRef,
+}
+
+var (
+	// ErrUnsigned is returned for the messages without signature
+	ErrUnsigned = errors.New("message is not signed")
+
+	// ErrUnknownSigner is returned for the signers which are not trusted
+	ErrUnknownSigner = errors.New("message signer is not trusted")
+
+	// ErrInvalidSignature is returned for the signatures which do not match
+	// the message
+	ErrInvalidSignature = errors.New("message signature is invalid")
+
+	// ErrStaleSignature is returned for the messages signed too long ago, or
+	// too far in the future
+	ErrStaleSignature = errors.New("message signature is stale")
+
+	// ErrUnauthenticated wraps the errors of the messages rejected by the
+	// authentication, which are not replayed
+	ErrUnauthenticated = errors.New("message is not authenticated")
+)
+
+// signedPayload returns the digest of the signer, of the signing time, of the
+// body and of the signed attributes
+func signedPayload(msg *Message, signer, signedAt string) []byte {
+	fields := make([]string, 0, len(signedAttributes)+3)
+	fields = append(fields, signer, signedAt)
+	for _, attr := range signedAttributes {
+		fields = append(fields, msg.Attributes[attr])
+	}
+	bodyHash := sha256.Sum256([]byte(msg.Body))
+	fields = append(fields, base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	// encoding the fields as a JSON array keeps them unambiguous
+	payload, _ := json.Marshal(fields)
+	return payload
+}
+
+// parseSignature splits the signature attribute of a message into the signer,
+// the signing time and the encoded signature
+func parseSignature(msg *Message) (signer, signedAt, signature string, ok bool) {
+	fields := strings.Split(msg.Attributes[MessageAttributeSignature], signatureSeparator)
+	if len(fields) != 3 || fields[0] == "" || fields[2] == "" {
+		return "", "", "", false
+	}
+	return fields[0], fields[1], fields[2], true
+}
+
+// Signer signs the messages of a cluster with its private key
+type Signer struct {
+	identity string
+	key      ed25519.PrivateKey
+	now      func() time.Time
+}
+
+// NewSigner creates a signer for the given identity
+func NewSigner(identity string, key ed25519.PrivateKey) (*Signer, error) {
+	if identity == "" {
+		return nil, errors.New("a signer identity is required")
+	}
+	if strings.Contains(identity, signatureSeparator) {
+		return nil, fmt.Errorf("signer identity %q cannot contain %q", identity, signatureSeparator)
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid Ed25519 private key")
+	}
+	return &Signer{identity: identity, key: key, now: time.Now}, nil
+}
+
+// NewSignerFromFile creates a signer with the PKCS #8 PEM private key of the
+// given file
+func NewSignerFromFile(identity, path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read signing key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse signing key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an Ed25519 key")
+	}
+	return NewSigner(identity, edKey)
+}
+
+// Sign sets the signature attribute of a message
+func (s *Signer) Sign(msg *Message) {
+	signedAt := s.now().UTC().Format(time.RFC3339Nano)
+	signature := ed25519.Sign(s.key, signedPayload(msg, s.identity, signedAt))
+	setAttribute(msg, MessageAttributeSignature, strings.Join([]string{
+		s.identity, signedAt, base64.StdEncoding.EncodeToString(signature),
+	}, signatureSeparator))
+}
+
+// Verifier checks the signatures of the messages against the public keys of
+// the trusted identities
+type Verifier struct {
+	keys map[string]ed25519.PublicKey
+}
+
+// NewVerifier creates a verifier trusting the given identities
+func NewVerifier(keys map[string]ed25519.PublicKey) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// NewVerifierFromFile creates a verifier trusting the identities of the given
+// YAML file, which maps each identity to its PKIX PEM public key
+func NewVerifierFromFile(path string) (*Verifier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trusted keys: %w", err)
+	}
+
+	var encoded map[string]string
+	if err := yaml.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("cannot parse trusted keys: %w", err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(encoded))
+	for identity, pemKey := range encoded {
+		block, _ := pem.Decode([]byte(pemKey))
+		if block == nil {
+			return nil, fmt.Errorf("trusted key of %s is not PEM encoded", identity)
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse trusted key of %s: %w", identity, err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("trusted key of %s is not an Ed25519 key", identity)
+		}
+		keys[identity] = edKey
+	}
+	return NewVerifier(keys), nil
+}
+
+// Verify the signature of a message, and return its signer
+func (v *Verifier) Verify(msg *Message) (string, error) {
+	signer, signedAt, encoded, ok := parseSignature(msg)
+	if !ok {
+		return "", ErrUnsigned
+	}
+
+	key, ok := v.keys[signer]
+	if !ok {
+		return signer, fmt.Errorf("%w: %s", ErrUnknownSigner, signer)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || !ed25519.Verify(key, signedPayload(msg, signer, signedAt), signature) {
+		return signer, ErrInvalidSignature
+	}
+	return signer, nil
+}
+
+// CheckSignedAt checks that a message was signed within maxAge of now, in
+// either direction to allow for the clock skew between the clusters
+func CheckSignedAt(msg *Message, now time.Time, maxAge time.Duration) error {
+	_, encoded, _, _ := parseSignature(msg)
+	signedAt, err := time.Parse(time.RFC3339Nano, encoded)
+	if err != nil {
+		return fmt.Errorf("%w: no signing time", ErrStaleSignature)
+	}
+	if age := now.Sub(signedAt); age > maxAge || age < -maxAge {
+		return fmt.Errorf("%w: signed at %s", ErrStaleSignature, signedAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adobe/cluster-registry/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSignature(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test signing and verifying messages.")
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+
+	verifier := NewVerifier(map[string]ed25519.PublicKey{"cluster1": pub})
+
+	tcs := []struct {
+		name           string
+		identity       string
+		key            ed25519.PrivateKey
+		tamper         func(msg *Message)
+		expectedSigner string
+		expectedErr    error
+	}{
+		{
+			name:           "valid signature",
+			identity:       "cluster1",
+			key:            priv,
+			tamper:         func(_ *Message) {},
+			expectedSigner: "cluster1",
+		},
+		{
+			name:     "unsigned message",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				delete(msg.Attributes, MessageAttributeSignature)
+			},
+			expectedErr: ErrUnsigned,
+		},
+		{
+			name:           "unknown signer",
+			identity:       "cluster2",
+			key:            otherPriv,
+			tamper:         func(_ *Message) {},
+			expectedSigner: "cluster2",
+			expectedErr:    ErrUnknownSigner,
+		},
+		{
+			name:           "impersonated signer",
+			identity:       "cluster1",
+			key:            otherPriv,
+			tamper:         func(_ *Message) {},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "tampered body",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Body = `{"spec":{"name":"cluster2"}}`
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "tampered attribute",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Attributes[MessageAttributeClusterName] = "cluster2"
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "redirected reply",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Attributes[MessageAttributeReplyTo] = "cluster-registry-cluster2-acks"
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "tampered signing time",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				signer, _, signature, _ := parseSignature(msg)
+				msg.Attributes[MessageAttributeSignature] = signer + ";2099-01-01T00:00:00Z;" + signature
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "redirected payload",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Attributes[MessageAttributePayloadRef] = "0b7a7f5e-43a4-4c37-9b5f-1f0e4a8a6d0c"
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "added content encoding",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Attributes[MessageAttributeContentEncoding] = contentEncodingGzip
+			},
+			expectedSigner: "cluster1",
+			expectedErr:    ErrInvalidSignature,
+		},
+		{
+			name:     "unsigned attribute changed in transit",
+			identity: "cluster1",
+			key:      priv,
+			tamper: func(msg *Message) {
+				msg.Attributes["traceparent"] = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+			},
+			expectedSigner: "cluster1",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen verifying a message signed by %s", tc.name, tc.identity)
+
+		signer, err := NewSigner(tc.identity, tc.key)
+		test.NoError(err)
+
+		msg := &Message{
+			Body: `{"spec":{"name":"cluster1"}}`,
+			Attributes: map[string]string{
+				MessageAttributeType:        ClusterUpdateEvent,
+				MessageAttributeClusterName: "cluster1",
+				MessageAttributeReplyTo:     "cluster-registry-cluster1-acks",
+				MessageAttributeHash:        "hash1",
+			},
+		}
+		signer.Sign(msg)
+		tc.tamper(msg)
+
+		identity, err := verifier.Verify(msg)
+		test.Equal(tc.expectedSigner, identity)
+		if tc.expectedErr != nil {
+			test.ErrorIs(err, tc.expectedErr)
+		} else {
+			test.NoError(err)
+		}
+	}
+
+	_, err = NewSigner("", priv)
+	test.Error(err)
+	_, err = NewSigner("cluster1", priv[:10])
+	test.Error(err)
+	_, err = NewSigner("cluster1;2024-05-01T12:00:00Z", priv)
+	test.Error(err)
+}
+
+func TestCheckSignedAt(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test rejecting the messages signed too long ago, such as replayed ones.")
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	signer, err := NewSigner("cluster1", priv)
+	test.NoError(err)
+
+	signedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	signer.now = func() time.Time { return signedAt }
+	msg := &Message{Body: "{}", Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}}
+	signer.Sign(msg)
+	test.True(strings.HasPrefix(msg.Attributes[MessageAttributeSignature], "cluster1;2024-05-01T12:00:00Z;"))
+
+	tcs := []struct {
+		name        string
+		now         time.Time
+		expectedErr error
+	}{
+		{name: "fresh", now: signedAt.Add(time.Minute)},
+		{name: "slightly ahead clock", now: signedAt.Add(-time.Minute)},
+		{name: "replayed", now: signedAt.Add(2 * time.Hour), expectedErr: ErrStaleSignature},
+		{name: "far in the future", now: signedAt.Add(-2 * time.Hour), expectedErr: ErrStaleSignature},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen checking a message signed at %s", tc.name, signedAt)
+		err := CheckSignedAt(msg, tc.now, time.Hour)
+		if tc.expectedErr != nil {
+			test.ErrorIs(err, tc.expectedErr)
+		} else {
+			test.NoError(err)
+		}
+	}
+
+	delete(msg.Attributes, MessageAttributeSignature)
+	test.ErrorIs(CheckSignedAt(msg, signedAt, time.Hour), ErrStaleSignature)
+}
+
+func TestSignatureFromFiles(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test loading the signing key and the trusted keys from files.")
+
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+
+	privDer, err := x509.MarshalPKCS8PrivateKey(priv)
+	test.NoError(err)
+	keyFile := filepath.Join(dir, "signing.pem")
+	test.NoError(os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDer}), 0o600))
+
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	test.NoError(err)
+	pubPem := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer})
+	trustedFile := filepath.Join(dir, "trusted.yaml")
+	trusted := "cluster1: |\n  " + strings.ReplaceAll(strings.TrimSpace(string(pubPem)), "\n", "\n  ") + "\n"
+	test.NoError(os.WriteFile(trustedFile, []byte(trusted), 0o600))
+
+	signer, err := NewSignerFromFile("cluster1", keyFile)
+	test.NoError(err)
+	verifier, err := NewVerifierFromFile(trustedFile)
+	test.NoError(err)
+
+	// the signature covers the body before compression
+	q, err := NewMemory(Config{VisibilityTimeout: 60, RunOnce: true, CompressThreshold: 16, Signer: signer})
+	test.NoError(err)
+	test.NoError(q.Enqueue(context.Background(), []*Message{
+		{Body: strings.Repeat(`{"spec":{"name":"cluster1"}}`, 10), Attributes: map[string]string{MessageAttributeType: ClusterUpdateEvent}},
+	}))
+
+	var identity string
+	test.NoError(q.Poll(context.Background(), func(_ context.Context, msg *Message) error {
+		identity, err = verifier.Verify(msg)
+		return err
+	}))
+	test.NoError(err)
+	test.Equal("cluster1", identity)
+
+	_, err = NewSignerFromFile("cluster1", trustedFile)
+	test.Error(err)
+	_, err = NewVerifierFromFile(keyFile)
+	test.Error(err)
+}
+
+func TestSignedMessageAttributes(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that the signed cluster updates fit within the attributes accepted by SQS.")
+
+	_, err := tracing.Setup(context.Background(), tracing.Config{})
+	test.NoError(err)
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	test.NoError(err)
+	signer, err := NewSigner("cluster1", priv)
+	test.NoError(err)
+
+	blobs, err := NewFilesystemBlobStore(t.TempDir())
+	test.NoError(err)
+	q, err := NewMemory(Config{
+		VisibilityTimeout: 60,
+		RunOnce:           true,
+		CompressThreshold: 16,
+		OffloadThreshold:  16,
+		Blobs:             blobs,
+		Signer:            signer,
+	})
+	test.NoError(err)
+
+	// the worst case sets every attribute of a cluster update, compresses and
+	// offloads the body, and propagates the trace context
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+	}))
+	test.NoError(q.Enqueue(ctx, []*Message{{
+		Body: strings.Repeat(`{"spec":{"name":"cluster1"}}`, 100),
+		Attributes: map[string]string{
+			MessageAttributeType:                  ClusterUpdateEvent,
+			MessageAttributeClusterName:           "cluster1",
+			MessageAttributeSkipCacheInvalidation: "false",
+			MessageAttributeRequestID:             "request1",
+			MessageAttributeHash:                  "hash1",
+			MessageAttributeReplyTo:               "cluster-registry-cluster1-acks",
+		},
+	}}))
+
+	test.NoError(q.Poll(context.Background(), func(ctx context.Context, msg *Message) error {
+		test.Contains(msg.Attributes, MessageAttributePayloadRef)
+		test.Contains(msg.Attributes, MessageAttributeContentEncoding)
+		test.Contains(msg.Attributes, "traceparent")
+		test.LessOrEqual(len(msg.Attributes), maxMessageAttributes)
+		return msg.Decode(ctx)
+	}))
+}
@@ -16,29 +16,46 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/sqs"
+	awssqs "github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
-	"sync"
-	"time"
 )
 
 var logger logr.Logger
 
+// maxMessageAttributes is the number of attributes SQS accepts per message
+const maxMessageAttributes = 10
+
 func init() {
 	logger = ctrl.Log.WithName("sqs")
 	ctrl.SetLogger(zap.New())
 }
 
+// Config of the queue. The connection settings depend on the backend, while
+// the polling settings are shared by all of them.
 type Config struct {
 	AWSRegion string
-	QueueName string
 	Endpoint  string
 	QueueURL  string
 
+	// Name of the SQS queue or of the Redis stream
+	QueueName string
+
+	// Redis consumer group, shared by all the consumers of the stream
+	ConsumerGroup string
+
+	// Redis consumer name, unique per consumer (defaults to the hostname)
+	ConsumerName string
+
 	// Maximum number of time to attempt AWS service connection
 	MaxRetries int
 
@@ -54,32 +71,47 @@ type Config struct {
 	// Poll only once and exit
 	RunOnce bool
 
-	// Poll every X seconds defined by this value
+	// Seconds to wait before polling again when the queue is empty
 	RunInterval int
 
-	// Maximum number of handlers to spawn for batch processing
+	// Number of workers processing the messages concurrently
 	MaxHandlers int
 
-	// BusyTimeout in seconds
-	BusyTimeout int
+	// Metrics of the processed messages, optional
+	Metrics Metrics
+
+	// Bodies larger than CompressThreshold bytes are gzipped, and those still
+	// larger than OffloadThreshold bytes are put in Blobs. Zero disables them.
+	CompressThreshold int
+	OffloadThreshold  int
+	Blobs             BlobStore
 
-	svc          *sqs.SQS
-	handlerCount int
-	mutex        *sync.Mutex
-	handler      func(msg *sqs.Message)
+	// MaxBodySize bounds the size of the decompressed bodies, defaults to
+	// defaultMaxBodySize
+	MaxBodySize int
+
+	// Signer signs the enqueued messages, optional
+	Signer *Signer
+
+	// RedisClient is shared by the Redis queues created by NewQueue with this
+	// config, which otherwise create their own client
+	RedisClient redis.UniversalClient
 }
 
-type SQS interface {
-	Poll()
-	Delete(msg *sqs.Message) error
-	Enqueue(msgBatch []*sqs.SendMessageBatchRequestEntry) error
-	RegisterHandler(handler func(msg *sqs.Message))
-	ChangeVisibilityTimeout(msg *sqs.Message, seconds int64) bool
-	Status() error
+// Metrics records the processing of the messages received by Poll
+type Metrics interface {
+	RecordQueueInFlight(queue string, count int)
+	RecordQueueFailedCnt(queue string)
+}
+
+// sqsTransport sends and receives the messages through AWS SQS
+type sqsTransport struct {
+	cfg Config
+	svc *awssqs.SQS
 }
 
 // NewSQS - create new SQS instance
-func NewSQS(cfg Config) (*Config, error) {
+func NewSQS(cfg Config) (Queue, error) {
 	// Validate parameters
 	validateErr := validateConfig(cfg)
 	if validateErr != nil {
@@ -105,7 +137,7 @@ func NewSQS(cfg Config) (*Config, error) {
 	}
 
 	// Create a service connection
-	svc := sqs.New(newSession)
+	svc := awssqs.New(newSession)
 	if svc == nil {
 		logger.Info("Unable to connect to SQS")
 		return nil, errors.New("unable to create a service connection with AWS SQS")
@@ -113,7 +145,7 @@ func NewSQS(cfg Config) (*Config, error) {
 
 	if cfg.QueueURL == "" {
 		logger.Info("Fetching queue URL")
-		res, err := svc.GetQueueUrl(&sqs.GetQueueUrlInput{
+		res, err := svc.GetQueueUrl(&awssqs.GetQueueUrlInput{
 			QueueName: &cfg.QueueName,
 		})
 		if err != nil {
@@ -125,113 +157,37 @@ func NewSQS(cfg Config) (*Config, error) {
 	}
 	logger.Info("Connected to Queue")
 
-	cfg.svc = svc
-	cfg.mutex = &sync.Mutex{}
-	return &cfg, nil
+	return newQueue(cfg, &sqsTransport{cfg: cfg, svc: svc}), nil
 }
 
-// Poll for messages in the queue
-func (s *Config) Poll() {
-	if s.svc == nil {
-		logger.Error(nil, "No service connection")
-		return
-	}
-
-	wg := sync.WaitGroup{}
-	batch := 0
-
-	for {
-		batch++
-		childLogger := ctrl.Log.WithName(fmt.Sprintf("sqs-batch-%d", batch))
-		childLogger.Info("Start receiving messages")
-
-		maxMsgs := s.BatchSize
-
-		// Is running at capacity?
-		if s.MaxHandlers > 0 {
-			for s.handlerCount >= s.MaxHandlers {
-				childLogger.Info("Reached max handler count")
-				childLogger.Info("Going to wait state", "timeout", s.BusyTimeout)
-				<-time.After(time.Duration(s.BusyTimeout) * time.Second)
-			}
-			availableHandlers := int64(s.MaxHandlers - s.handlerCount)
-			if availableHandlers < maxMsgs {
-				maxMsgs = availableHandlers
-			}
-		}
-
-		childLogger.Info("Polling for messages", "maxMessages", maxMsgs)
-
-		result, err := s.svc.ReceiveMessage(&sqs.ReceiveMessageInput{
-			QueueUrl:                    &s.QueueURL,
-			MaxNumberOfMessages:         &maxMsgs,
-			WaitTimeSeconds:             &s.WaitSeconds,
-			VisibilityTimeout:           &s.VisibilityTimeout,
-			MessageAttributeNames:       aws.StringSlice([]string{"All"}),
-			MessageSystemAttributeNames: aws.StringSlice([]string{"All"}),
-			AttributeNames:              aws.StringSlice([]string{"All"}),
-		})
-
-		// Retrieve error?
-		if err != nil {
-			childLogger.Info("ReceiveMessageError:", err)
-			break
-		}
+func (t *sqsTransport) system() string {
+	return semconv.MessagingSystemAWSSqs.Value.AsString()
+}
 
-		// Message log
-		if len(result.Messages) == 0 {
-			childLogger.Info("Queue is empty")
-		} else {
-			childLogger.Info("Fetched messages", "count", len(result.Messages))
+func (t *sqsTransport) send(ctx context.Context, msgs []*Message) error {
+	entries := make([]*awssqs.SendMessageBatchRequestEntry, 0, len(msgs))
+	for _, msg := range msgs {
+		if len(msg.Attributes) > maxMessageAttributes {
+			return fmt.Errorf("message has %d attributes, SQS accepts at most %d", len(msg.Attributes), maxMessageAttributes)
 		}
-
-		// Process messages
-		for _, msg := range result.Messages {
-			if s.handler == nil {
-				childLogger.Info("No handler registered")
-			} else {
-				s.handlerCount++
-				wg.Add(1)
-
-				go func(m *sqs.Message) {
-					s.handler(m)
-
-					s.mutex.Lock()
-					s.handlerCount--
-					s.mutex.Unlock()
-
-					wg.Done()
-				}(msg)
+		attributes := make(map[string]*awssqs.MessageAttributeValue, len(msg.Attributes))
+		for k, v := range msg.Attributes {
+			attributes[k] = &awssqs.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(v),
 			}
-
-			childLogger.Info("Spawned handler", "messageId", *msg.MessageId)
 		}
-
-		if s.RunOnce {
-			childLogger.Info(`Exiting since configured to run once`)
-			break
-		} else {
-			childLogger.Info("Waiting before polling for next batch", "interval", s.RunInterval)
-			<-time.After(time.Duration(s.RunInterval) * time.Second)
-		}
-
-		childLogger.Info("Finished polling")
-	}
-
-	wg.Wait()
-}
-
-// Enqueue messages to SQS
-func (s *Config) Enqueue(ctx context.Context, msgBatch []*sqs.SendMessageBatchRequestEntry) error {
-	if s.svc == nil {
-		return errors.New("no service connection")
+		entries = append(entries, &awssqs.SendMessageBatchRequestEntry{
+			Id:                aws.String(uuid.New().String()),
+			DelaySeconds:      aws.Int64(msg.DelaySeconds),
+			MessageAttributes: attributes,
+			MessageBody:       aws.String(msg.Body),
+		})
 	}
 
-	logger.Info("Enqueuing messages", "count", len(msgBatch))
-
-	result, err := s.svc.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
-		QueueUrl: &s.QueueURL,
-		Entries:  msgBatch,
+	result, err := t.svc.SendMessageBatchWithContext(ctx, &awssqs.SendMessageBatchInput{
+		QueueUrl: &t.cfg.QueueURL,
+		Entries:  entries,
 	})
 
 	if err != nil {
@@ -239,66 +195,88 @@ func (s *Config) Enqueue(ctx context.Context, msgBatch []*sqs.SendMessageBatchRe
 		return err
 	}
 
-	for _, failed := range result.Failed {
-		logger.Info("Failed to enqueue message", "messageId", *failed.Id)
-	}
 	for _, success := range result.Successful {
 		logger.Info("Enqueued message", "messageId", *success.MessageId)
 	}
 
-	return err
+	return batchError(result.Failed)
 }
 
-// Delete a SQS message from the queue
-func (s *Config) Delete(msg *sqs.Message) error {
-	logger.Info("Deleting message", "messageId", *msg.MessageId)
-
-	_, err := s.svc.DeleteMessage(&sqs.DeleteMessageInput{
-		QueueUrl:      &s.QueueURL,
-		ReceiptHandle: msg.ReceiptHandle,
-	})
-
-	return err
-}
-
-// RegisterHandler : A method to register a custom Poll Handling method
-func (s *Config) RegisterHandler(handler func(msg *sqs.Message)) {
-	s.handler = handler
+// batchError aggregates the failed entries of a batch, so that the callers
+// retry the messages which were not enqueued
+func batchError(failed []*awssqs.BatchResultErrorEntry) error {
+	errs := make([]error, 0, len(failed))
+	for _, entry := range failed {
+		errs = append(errs, fmt.Errorf("entry %s failed with %s: %s",
+			aws.StringValue(entry.Id), aws.StringValue(entry.Code), aws.StringValue(entry.Message)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("cannot enqueue %d messages: %w", len(failed), err)
+	}
+	return nil
 }
 
-// ChangeVisibilityTimeout : Method to change visibility timeout of a message.
-func (s *Config) ChangeVisibilityTimeout(msg *sqs.Message, seconds int64) bool {
-	logger.Info("changing message visibility timeout", "messageId", *msg.MessageId)
-
-	if s.svc == nil {
-		logger.Error(nil, "SQS Connection failed")
-		return false
+func (t *sqsTransport) receive(ctx context.Context, maxMsgs int64) ([]*Message, error) {
+	result, err := t.svc.ReceiveMessageWithContext(ctx, &awssqs.ReceiveMessageInput{
+		QueueUrl:                    &t.cfg.QueueURL,
+		MaxNumberOfMessages:         &maxMsgs,
+		WaitTimeSeconds:             &t.cfg.WaitSeconds,
+		VisibilityTimeout:           &t.cfg.VisibilityTimeout,
+		MessageAttributeNames:       aws.StringSlice([]string{"All"}),
+		MessageSystemAttributeNames: aws.StringSlice([]string{"All"}),
+		AttributeNames:              aws.StringSlice([]string{"All"}),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	strURL := &s.QueueURL
-	receiptHandle := *msg.ReceiptHandle
+	msgs := make([]*Message, 0, len(result.Messages))
+	for _, m := range result.Messages {
+		msg := &Message{
+			ID:         aws.StringValue(m.MessageId),
+			Body:       aws.StringValue(m.Body),
+			Attributes: make(map[string]string, len(m.MessageAttributes)),
+			receipt:    aws.StringValue(m.ReceiptHandle),
+		}
+		for k, v := range m.MessageAttributes {
+			if v != nil && v.StringValue != nil {
+				msg.Attributes[k] = *v.StringValue
+			}
+		}
+		if sent, err := strconv.ParseInt(aws.StringValue(m.Attributes[awssqs.MessageSystemAttributeNameSentTimestamp]), 10, 64); err == nil {
+			msg.SentTimestamp = time.UnixMilli(sent)
+		}
+		if count, err := strconv.Atoi(aws.StringValue(m.Attributes[awssqs.MessageSystemAttributeNameApproximateReceiveCount])); err == nil {
+			msg.ReceiveCount = count
+		}
+		msgs = append(msgs, msg)
+	}
 
-	changeMessageVisibilityInput := sqs.ChangeMessageVisibilityInput{}
+	return msgs, nil
+}
 
-	changeMessageVisibilityInput.SetQueueUrl(*strURL)
-	changeMessageVisibilityInput.SetReceiptHandle(receiptHandle)
-	changeMessageVisibilityInput.SetVisibilityTimeout(seconds)
+func (t *sqsTransport) delete(ctx context.Context, msg *Message) error {
+	_, err := t.svc.DeleteMessageWithContext(ctx, &awssqs.DeleteMessageInput{
+		QueueUrl:      &t.cfg.QueueURL,
+		ReceiptHandle: aws.String(msg.receipt),
+	})
 
-	_, err := s.svc.ChangeMessageVisibility(&changeMessageVisibilityInput)
+	return err
+}
 
-	if err == nil {
-		logger.Info("successfully changed message visibility timeout", "messageId", *msg.MessageId)
-		return true
-	} else {
-		logger.Info("failed to change message visibility timeout", "messageId", *msg.MessageId)
-	}
+func (t *sqsTransport) extendVisibility(ctx context.Context, msg *Message, seconds int64) error {
+	_, err := t.svc.ChangeMessageVisibilityWithContext(ctx, &awssqs.ChangeMessageVisibilityInput{
+		QueueUrl:          &t.cfg.QueueURL,
+		ReceiptHandle:     aws.String(msg.receipt),
+		VisibilityTimeout: aws.Int64(seconds),
+	})
 
-	return false
+	return err
 }
 
-func (s *Config) Status() error {
-	_, err := s.svc.GetQueueUrl(&sqs.GetQueueUrlInput{
-		QueueName: &s.QueueName,
+func (t *sqsTransport) status() error {
+	_, err := t.svc.GetQueueUrl(&awssqs.GetQueueUrlInput{
+		QueueName: &t.cfg.QueueName,
 	})
 
 	// TODO: add metrics integration
@@ -323,17 +301,5 @@ func validateConfig(cfg Config) error {
 		return errors.New("A valid SQS Queue URL is required")
 	}
 
-	if cfg.BatchSize < 0 || cfg.BatchSize > 10 {
-		return errors.New("BatchSize should be between 1-10")
-	}
-
-	if cfg.WaitSeconds < 0 || cfg.WaitSeconds > 20 {
-		return errors.New("WaitSeconds should be between 1-20")
-	}
-
-	if cfg.VisibilityTimeout < 0 || cfg.VisibilityTimeout > 12*60*60 {
-		return errors.New("VisibilityTimeout should be between 1-43200")
-	}
-
-	return nil
+	return validatePolling(cfg)
 }
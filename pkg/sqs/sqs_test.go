@@ -17,8 +17,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/adobe/cluster-registry/pkg/config"
-	"github.com/aws/aws-sdk-go/aws"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -26,7 +24,7 @@ import (
 var _ = Describe("SQS suite", func() {
 
 	var (
-		q           *Config
+		q           Queue
 		appConfig   *config.AppConfig
 		messageBody = map[string]string{
 			"foo": "bar",
@@ -53,7 +51,6 @@ var _ = Describe("SQS suite", func() {
 			RunInterval:       5,
 			RunOnce:           true,
 			MaxHandlers:       10,
-			BusyTimeout:       30,
 		})
 		Expect(err).To(BeNil())
 	})
@@ -73,16 +70,12 @@ var _ = Describe("SQS suite", func() {
 			data, err := json.Marshal(messageBody)
 			Expect(err).To(BeNil())
 
-			err = q.Enqueue(context.Background(), []*awssqs.SendMessageBatchRequestEntry{
+			err = q.Enqueue(context.Background(), []*Message{
 				{
-					Id: aws.String("test-message"),
-					MessageAttributes: map[string]*awssqs.MessageAttributeValue{
-						MessageAttributeType: {
-							DataType:    aws.String("String"),
-							StringValue: aws.String(ClusterUpdateEvent),
-						},
+					Attributes: map[string]string{
+						MessageAttributeType: ClusterUpdateEvent,
 					},
-					MessageBody: aws.String(string(data)),
+					Body: string(data),
 				},
 			})
 			Expect(err).To(BeNil())
@@ -90,21 +83,15 @@ var _ = Describe("SQS suite", func() {
 
 		It("should successfully consume an enqueued message", func() {
 			var count = 0
-			q.RegisterHandler(func(msg *awssqs.Message) {
+			err := q.Poll(context.Background(), func(ctx context.Context, msg *Message) error {
 				defer GinkgoRecover()
 
-				if msg == nil {
-					return
-				}
-
-				Expect(*msg.Body).To(Equal(`{"foo":"bar"}`))
+				Expect(msg.Body).To(Equal(`{"foo":"bar"}`))
 				count++
 
-				err := q.Delete(msg)
-				Expect(err).To(BeNil())
+				return q.Delete(ctx, msg)
 			})
-
-			q.Poll()
+			Expect(err).To(BeNil())
 			Eventually(count).Should(Equal(1))
 		})
 	})
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// InjectTraceContext adds the trace context of ctx to the attributes of the message
+func InjectTraceContext(ctx context.Context, msg *Message) {
+	if msg.Attributes == nil {
+		msg.Attributes = map[string]string{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(msg.Attributes))
+}
+
+// ExtractTraceContext returns a copy of ctx carrying the trace context found
+// in the attributes of the message, if any
+func ExtractTraceContext(ctx context.Context, msg *Message) context.Context {
+	if msg == nil || msg.Attributes == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(msg.Attributes))
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package sqs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/adobe/cluster-registry/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextPropagation(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test propagating the trace context through message attributes.")
+
+	_, err := tracing.Setup(context.Background(), tracing.Config{})
+	test.NoError(err)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := tracing.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), "cluster-registry-test", 1)
+	otel.SetTracerProvider(tp)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	tcs := []struct {
+		name          string
+		withProducer  bool
+		expectedChild bool
+	}{
+		{
+			name:          "enqueued within a trace",
+			withProducer:  true,
+			expectedChild: true,
+		},
+		{
+			name:          "enqueued outside a trace",
+			withProducer:  false,
+			expectedChild: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		exporter.Reset()
+
+		q, err := NewMemory(Config{RunOnce: true})
+		test.NoError(err)
+
+		msg := &Message{
+			Attributes: map[string]string{
+				MessageAttributeType: ClusterUpdateEvent,
+			},
+		}
+
+		ctx := context.Background()
+		var producer trace.SpanContext
+		if tc.withProducer {
+			var span trace.Span
+			ctx, span = tracing.Tracer().Start(ctx, "producer")
+			test.NoError(q.Enqueue(ctx, []*Message{msg}))
+			span.End()
+			producer = span.SpanContext()
+		} else {
+			test.NoError(q.Enqueue(ctx, []*Message{msg}))
+		}
+
+		t.Logf("\tTest %s:\tWhen consuming message with attributes %v", tc.name, msg.Attributes)
+
+		var received *Message
+		err = q.Poll(context.Background(), func(ctx context.Context, m *Message) error {
+			received = m
+			return nil
+		})
+		test.NoError(err)
+		test.NotNil(received)
+
+		test.Equal(ClusterUpdateEvent, received.Attributes[MessageAttributeType])
+		test.NotEmpty(received.Attributes["traceparent"])
+
+		var enqueue, consumer tracetest.SpanStub
+		for _, s := range exporter.GetSpans() {
+			switch s.Name {
+			case "sqs.Enqueue":
+				enqueue = s
+			case "sqs.Process":
+				consumer = s
+			}
+		}
+		test.Equal(trace.SpanKindProducer, enqueue.SpanKind)
+		test.Equal(trace.SpanKindConsumer, consumer.SpanKind)
+		test.Equal(enqueue.SpanContext.TraceID(), consumer.SpanContext.TraceID())
+		test.Equal(enqueue.SpanContext.SpanID(), consumer.Parent.SpanID())
+
+		if tc.expectedChild {
+			test.Equal(producer.TraceID(), consumer.SpanContext.TraceID())
+			test.Equal(producer.SpanID(), enqueue.Parent.SpanID())
+		} else {
+			test.False(enqueue.Parent.IsValid())
+		}
+	}
+}
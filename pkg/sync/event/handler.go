@@ -13,6 +13,7 @@ governing permissions and limitations under the License.
 package event
 
 import (
+	"context"
 	"errors"
 	"github.com/adobe/cluster-registry/pkg/sqs"
 )
@@ -29,7 +30,7 @@ func (h *PartialClusterUpdateHandler) Type() string {
 	return sqs.PartialClusterUpdateEvent
 }
 
-func (h *PartialClusterUpdateHandler) Handle(event *sqs.Event) error {
+func (h *PartialClusterUpdateHandler) Handle(ctx context.Context, event *sqs.Event) error {
 	if event == nil {
 		return errors.New("event is nil")
 	}
@@ -19,10 +19,10 @@ import (
 	monitoring "github.com/adobe/cluster-registry/pkg/monitoring/manager"
 	"github.com/adobe/cluster-registry/pkg/sqs"
 	"github.com/adobe/cluster-registry/pkg/sync/parser"
-	"github.com/aws/aws-sdk-go/aws"
-	awssqs "github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/adobe/cluster-registry/pkg/tracing"
 	"github.com/go-logr/logr"
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/yaml.v3"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -53,7 +53,7 @@ type SyncController struct {
 	Log            logr.Logger
 	Scheme         *runtime.Scheme
 	WatchedGVKs    []schema.GroupVersionKind
-	Queue          *sqs.Config
+	Queue          sqs.Queue
 	ResourceParser *parser.ResourceParser
 	Metrics        monitoring.MetricsI
 }
@@ -61,6 +61,14 @@ type SyncController struct {
 func (c *SyncController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var start = time.Now()
 
+	ctx, span := tracing.Tracer().Start(ctx, "SyncController.Reconcile",
+		trace.WithAttributes(
+			attribute.String("clustersync.name", req.Name),
+			attribute.String("clustersync.namespace", req.Namespace),
+		),
+	)
+	defer span.End()
+
 	log := c.Log.WithValues("name", req.Name, "namespace", req.Namespace)
 	log.Info("start")
 	defer func() {
@@ -108,6 +116,7 @@ func (c *SyncController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		instance.Status.LastSyncError = ptr.To(errList[0].Error())
 		instance.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
 		log.Error(errList[0], "failed to sync resources")
+		tracing.RecordError(span, errList[0])
 		c.Metrics.RecordErrorCnt(req.Name)
 
 		if err := c.updateStatus(ctx, instance); err != nil {
@@ -127,8 +136,9 @@ func (c *SyncController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	if c.shouldEnqueueData(instance) {
 		instance.Status.SyncedDataHash = ptr.To(hash(instance.Status.SyncedData))
 		instance.Status.LastSyncTime = &metav1.Time{Time: time.Now()}
-		if err := c.enqueueData(instance); err != nil {
+		if err := c.enqueueData(ctx, instance); err != nil {
 			log.Error(err, "failed to enqueue message")
+			tracing.RecordError(span, err)
 			c.Metrics.RecordErrorCnt(req.Name)
 			if err := c.updateStatus(ctx, instance); err != nil {
 				return requeueAfter(c, req, 10*time.Second, err)
@@ -277,8 +287,8 @@ func (c *SyncController) enqueueRequestsFromMapFunc(gvk schema.GroupVersionKind)
 	}
 }
 
-func (c *SyncController) enqueueData(instance *registryv1alpha1.ClusterSync) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (c *SyncController) enqueueData(ctx context.Context, instance *registryv1alpha1.ClusterSync) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	obj, err := json.Marshal(instance.Status.SyncedData)
@@ -286,23 +296,14 @@ func (c *SyncController) enqueueData(instance *registryv1alpha1.ClusterSync) err
 		return err
 	}
 
-	id, err := uuid.NewUUID()
-	if err != nil {
-		return err
-	}
-
 	start := time.Now()
-	err = c.Queue.Enqueue(ctx, []*awssqs.SendMessageBatchRequestEntry{
+	err = c.Queue.Enqueue(ctx, []*sqs.Message{
 		{
-			Id:           aws.String(id.String()),
-			DelaySeconds: aws.Int64(10),
-			MessageAttributes: map[string]*awssqs.MessageAttributeValue{
-				"Type": {
-					DataType:    aws.String("String"),
-					StringValue: aws.String(sqs.PartialClusterUpdateEvent),
-				},
+			DelaySeconds: 10,
+			Attributes: map[string]string{
+				sqs.MessageAttributeType: sqs.PartialClusterUpdateEvent,
 			},
-			MessageBody: aws.String(string(obj)),
+			Body: string(obj),
 		},
 	})
 	elapsed := float64(time.Since(start)) / float64(time.Second)
@@ -0,0 +1,203 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+func TestAzureHandlers(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test parsing the recorded CAPZ and ASO objects.")
+
+	tcs := []struct {
+		name          string
+		fixture       string
+		handler       ObjectHandler
+		mutate        func(objects []unstructured.Unstructured)
+		expectedSpec  *v1.ClusterSpec
+		expectedError bool
+	}{
+		{
+			name:    "control plane",
+			fixture: "azuremanagedcontrolplane",
+			handler: &AzureManagedControlPlaneHandler{},
+			expectedSpec: &v1.ClusterSpec{
+				CloudProviderRegion: "eastus2",
+				AccountID:           "00000000-0000-0000-0000-000000000000",
+				Extra: v1.Extra{
+					OidcIssuer: "eastus2.oic.prod-aks.azure.com/11111111-1111-1111-1111-111111111111/22222222-2222-2222-2222-222222222222/",
+				},
+			},
+		},
+		{
+			name:    "control plane without OIDC issuer",
+			fixture: "azuremanagedcontrolplane",
+			handler: &AzureManagedControlPlaneHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[0].Object, "status", "oidcIssuerProfile")
+			},
+			expectedSpec: &v1.ClusterSpec{
+				CloudProviderRegion: "eastus2",
+				AccountID:           "00000000-0000-0000-0000-000000000000",
+			},
+		},
+		{
+			name:    "control plane without subscription",
+			fixture: "azuremanagedcontrolplane",
+			handler: &AzureManagedControlPlaneHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[0].Object, "spec", "subscriptionID")
+			},
+			expectedError: true,
+		},
+		{
+			name:    "machine pools",
+			fixture: "azuremanagedmachinepool",
+			handler: &AzureManagedMachinePoolHandler{},
+			expectedSpec: &v1.ClusterSpec{
+				Tiers: []v1.Tier{
+					{
+						Name:         "system",
+						InstanceType: "Standard_D4s_v3",
+						MinCapacity:  3,
+						MaxCapacity:  5,
+						Labels:       map[string]string{"tier": "system"},
+						Taints:       []string{"CriticalAddonsOnly=true:NoSchedule"},
+					},
+					{
+						Name:         "worker0",
+						InstanceType: "Standard_D8s_v3",
+						Labels:       map[string]string{"tier": "worker"},
+					},
+				},
+				AvailabilityZones: []v1.AvailabilityZone{
+					{Name: "1"},
+					{Name: "2"},
+					{Name: "3"},
+				},
+			},
+		},
+		{
+			name:    "machine pool without SKU",
+			fixture: "azuremanagedmachinepool",
+			handler: &AzureManagedMachinePoolHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[1].Object, "spec", "sku")
+			},
+			expectedError: true,
+		},
+		{
+			name:    "virtual network",
+			fixture: "azurevirtualnetwork",
+			handler: &AzureVirtualNetworkHandler{},
+			expectedSpec: &v1.ClusterSpec{
+				VirtualNetworks: []v1.VirtualNetwork{{
+					ID:    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ethos000devaz1/providers/Microsoft.Network/virtualNetworks/ethos000devaz1-vnet",
+					Cidrs: []string{"10.124.0.0/16", "100.64.0.0/16"},
+				}},
+			},
+		},
+		{
+			name:    "virtual network not provisioned",
+			fixture: "azurevirtualnetwork",
+			handler: &AzureVirtualNetworkHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[0].Object, "status", "id")
+			},
+			expectedSpec: &v1.ClusterSpec{
+				VirtualNetworks: []v1.VirtualNetwork{{
+					Cidrs: []string{"10.124.0.0/16", "100.64.0.0/16"},
+				}},
+			},
+		},
+		{
+			name:    "virtual network without address space",
+			fixture: "azurevirtualnetwork",
+			handler: &AzureVirtualNetworkHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[0].Object, "spec", "addressSpace")
+			},
+			expectedError: true,
+		},
+		{
+			name:    "subnets",
+			fixture: "azurevirtualnetworkssubnet",
+			handler: &AzureVirtualNetworksSubnetHandler{},
+			expectedSpec: &v1.ClusterSpec{
+				VirtualNetworks: []v1.VirtualNetwork{
+					{
+						ID:    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ethos000devaz1/providers/Microsoft.Network/virtualNetworks/ethos000devaz1-vnet/subnets/nodes",
+						Cidrs: []string{"10.124.0.0/20"},
+					},
+					{
+						ID:    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ethos000devaz1/providers/Microsoft.Network/virtualNetworks/ethos000devaz1-vnet/subnets/pods",
+						Cidrs: []string{"100.64.0.0/18", "100.64.64.0/18"},
+					},
+				},
+			},
+		},
+		{
+			name:    "subnet not provisioned",
+			fixture: "azurevirtualnetworkssubnet",
+			handler: &AzureVirtualNetworksSubnetHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[1].Object, "status", "id")
+			},
+			expectedSpec: &v1.ClusterSpec{
+				VirtualNetworks: []v1.VirtualNetwork{
+					{
+						ID:    "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ethos000devaz1/providers/Microsoft.Network/virtualNetworks/ethos000devaz1-vnet/subnets/nodes",
+						Cidrs: []string{"10.124.0.0/20"},
+					},
+					{
+						Cidrs: []string{"100.64.0.0/18", "100.64.64.0/18"},
+					},
+				},
+			},
+		},
+		{
+			name:    "subnet without address prefix",
+			fixture: "azurevirtualnetworkssubnet",
+			handler: &AzureVirtualNetworksSubnetHandler{},
+			mutate: func(objects []unstructured.Unstructured) {
+				unstructured.RemoveNestedField(objects[0].Object, "spec", "addressPrefix")
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen parsing the %s fixture", tc.name, tc.fixture)
+
+		objects := loadObjects(t, tc.fixture)
+		if tc.mutate != nil {
+			tc.mutate(objects)
+		}
+
+		spec, err := tc.handler.Handle(context.Background(), objects)
+		if tc.expectedError {
+			test.Error(err, tc.name)
+			continue
+		}
+		test.NoError(err, tc.name)
+		test.Equal(tc.expectedSpec, spec, tc.name)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"strings"
+)
+
+// AzureManagedControlPlaneHandler extracts the following Cluster Registry metadata from AzureManagedControlPlane (CAPZ) objects:
+// - cloudProviderRegion (e.g. "eastus2")
+// - accountId, the subscription (e.g. "00000000-0000-0000-0000-000000000000")
+// - extra.oidcIssuer, if the OIDC issuer is enabled (e.g. "eastus2.oic.prod-aks.azure.com/00000000-0000-0000-0000-000000000000/11111111-1111-1111-1111-111111111111/")
+type AzureManagedControlPlaneHandler struct{}
+
+func (h *AzureManagedControlPlaneHandler) Handle(ctx context.Context, objects []unstructured.Unstructured) (*v1.ClusterSpec, error) {
+	clusterSpec := new(v1.ClusterSpec)
+
+	for _, obj := range objects {
+		location, err := getNestedString(obj, "spec", "location")
+		if err != nil {
+			return nil, err
+		}
+		clusterSpec.CloudProviderRegion = location
+
+		subscriptionID, err := getNestedString(obj, "spec", "subscriptionID")
+		if err != nil {
+			return nil, err
+		}
+		clusterSpec.AccountID = subscriptionID
+
+		if !hasNestedField(obj, "status", "oidcIssuerProfile", "issuerURL") {
+			// the OIDC issuer is optional on AKS
+			continue
+		}
+		issuerURL, err := getNestedString(obj, "status", "oidcIssuerProfile", "issuerURL")
+		if err != nil {
+			return nil, err
+		}
+		// drop the scheme, like the issuers extracted from the EKS OIDC provider ARNs
+		clusterSpec.Extra.OidcIssuer = strings.TrimPrefix(issuerURL, "https://")
+	}
+
+	return clusterSpec, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"golang.org/x/exp/slices"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AzureManagedMachinePoolHandler extracts the following Cluster Registry metadata from AzureManagedMachinePool (CAPZ) objects:
+// tiers:
+// - name, the name of the agent pool (e.g. "worker0")
+// - instanceType, the VM size (e.g. "Standard_D4s_v3")
+// - minCapacity, if autoscaling is enabled (e.g. 1)
+// - maxCapacity, if autoscaling is enabled (e.g. 10)
+// - labels (e.g. {"tier": "worker"})
+// - taints (e.g. ["node-role.kubernetes.io/worker:NoSchedule"])
+// availabilityZones:
+// - name (e.g. "1")
+//
+// Azure subnets span all the zones of their region, so the zones are the ones the agent pools are spread across.
+type AzureManagedMachinePoolHandler struct{}
+
+func (h *AzureManagedMachinePoolHandler) Handle(ctx context.Context, objects []unstructured.Unstructured) (*v1.ClusterSpec, error) {
+	clusterSpec := new(v1.ClusterSpec)
+
+	for _, obj := range objects {
+		// the agent pool is named after the object unless its name is set
+		name := obj.GetName()
+		if hasNestedField(obj, "spec", "name") {
+			var err error
+			name, err = getNestedString(obj, "spec", "name")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		sku, err := getNestedString(obj, "spec", "sku")
+		if err != nil {
+			return nil, err
+		}
+
+		tier := v1.Tier{
+			Name:         name,
+			InstanceType: sku,
+		}
+
+		if hasNestedField(obj, "spec", "scaling") {
+			minSize, err := getNestedInt64(obj, "spec", "scaling", "minSize")
+			if err != nil {
+				return nil, err
+			}
+			maxSize, err := getNestedInt64(obj, "spec", "scaling", "maxSize")
+			if err != nil {
+				return nil, err
+			}
+			tier.MinCapacity = int(minSize)
+			tier.MaxCapacity = int(maxSize)
+		}
+
+		if hasNestedField(obj, "spec", "nodeLabels") {
+			tier.Labels, err = getNestedStringMap(obj, "spec", "nodeLabels")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if hasNestedField(obj, "spec", "taints") {
+			tier.Taints, err = getTaintsAsStringSlice(obj, "spec", "taints")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if k := slices.IndexFunc(clusterSpec.Tiers, func(t v1.Tier) bool {
+			return t.Name == name
+		}); k > -1 {
+			clusterSpec.Tiers[k] = tier
+		} else {
+			clusterSpec.Tiers = append(clusterSpec.Tiers, tier)
+		}
+
+		if !hasNestedField(obj, "spec", "availabilityZones") {
+			continue
+		}
+		zones, err := getNestedStringSlice(obj, "spec", "availabilityZones")
+		if err != nil {
+			return nil, err
+		}
+		for _, zone := range zones {
+			if !slices.ContainsFunc(clusterSpec.AvailabilityZones, func(az v1.AvailabilityZone) bool {
+				return az.Name == zone
+			}) {
+				clusterSpec.AvailabilityZones = append(clusterSpec.AvailabilityZones, v1.AvailabilityZone{Name: zone})
+			}
+		}
+	}
+
+	return clusterSpec, nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AzureVirtualNetworkHandler extracts the following Cluster Registry metadata from VirtualNetwork (ASO) objects:
+// virtualNetworks:
+// - id, the ARM resource ID (e.g. "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/ethos000devaz1/providers/Microsoft.Network/virtualNetworks/ethos000devaz1-vnet")
+// - cidrBlocks (e.g. ["10.124.0.0/16"])
+type AzureVirtualNetworkHandler struct{}
+
+func (h *AzureVirtualNetworkHandler) Handle(ctx context.Context, objects []unstructured.Unstructured) (*v1.ClusterSpec, error) {
+	clusterSpec := new(v1.ClusterSpec)
+
+	for _, obj := range objects {
+		// the ID is only known once the virtual network is provisioned
+		var id string
+		if hasNestedField(obj, "status", "id") {
+			var err error
+			id, err = getNestedString(obj, "status", "id")
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		cidrs, err := getNestedStringSlice(obj, "spec", "addressSpace", "addressPrefixes")
+		if err != nil {
+			return nil, err
+		}
+
+		clusterSpec.VirtualNetworks = append(clusterSpec.VirtualNetworks, v1.VirtualNetwork{
+			ID:    id,
+			Cidrs: cidrs,
+		})
+	}
+
+	return clusterSpec, nil
+}
@@ -0,0 +1,418 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+	registryv1alpha1 "github.com/adobe/cluster-registry/pkg/api/registry/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/jsonpath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// RuleHandler extracts the Cluster Registry metadata described by a ParserRule from any object, so that a new source
+// type can be synced by configuration only. Each object matching the filters of the rule is mapped to the fields of
+// the cluster spec, and to at most one element of each list of the cluster spec.
+type RuleHandler struct {
+	filters  []ruleFilter
+	lists    []ruleList
+	mappings []ruleMapping
+}
+
+type ruleFilter struct {
+	expression string
+	source     *jsonpath.JSONPath
+	regex      *regexp.Regexp
+}
+
+type ruleList struct {
+	path string
+	key  string
+}
+
+type ruleMapping struct {
+	expression string
+	source     *jsonpath.JSONPath
+	list       string
+	fields     []string
+	valueType  configv1.ParserValueType
+	regex      *regexp.Regexp
+	optional   bool
+}
+
+// NewRuleHandler compiles the expressions of the rule and returns its handler
+func NewRuleHandler(rule configv1.ParserRule) (*RuleHandler, error) {
+	h := &RuleHandler{}
+
+	for i, f := range rule.Filters {
+		source, err := registryv1alpha1.ParseExpression(f.Source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source of filter %d: %w", i, err)
+		}
+		regex, err := regexp.Compile(f.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex of filter %d: %w", i, err)
+		}
+		h.filters = append(h.filters, ruleFilter{expression: f.Source, source: source, regex: regex})
+	}
+
+	for i, l := range rule.Lists {
+		if l.Path == "" || strings.Contains(l.Path, "[]") {
+			return nil, fmt.Errorf("invalid path of list %d: %q", i, l.Path)
+		}
+		h.lists = append(h.lists, ruleList{path: l.Path, key: l.Key})
+	}
+
+	if len(rule.Mappings) == 0 {
+		return nil, fmt.Errorf("no mappings")
+	}
+	for i, m := range rule.Mappings {
+		mapping := ruleMapping{expression: m.Source, valueType: m.Type, optional: m.Optional}
+
+		source, err := registryv1alpha1.ParseExpression(m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source of mapping %d: %w", i, err)
+		}
+		mapping.source = source
+
+		mapping.list, mapping.fields, err = parseDestination(m.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination of mapping %d: %w", i, err)
+		}
+		if mapping.list != "" && h.list(mapping.list) == nil {
+			h.lists = append(h.lists, ruleList{path: mapping.list})
+		}
+
+		switch m.Type {
+		case "":
+			mapping.valueType = configv1.ParserValueString
+		case configv1.ParserValueString, configv1.ParserValueInteger, configv1.ParserValueStringList,
+			configv1.ParserValueStringMap, configv1.ParserValueTaints:
+		default:
+			return nil, fmt.Errorf("invalid type of mapping %d: %q", i, m.Type)
+		}
+
+		if m.Regex != "" {
+			if mapping.valueType != configv1.ParserValueString {
+				return nil, fmt.Errorf("invalid regex of mapping %d: only applies to strings", i)
+			}
+			mapping.regex, err = regexp.Compile(m.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex of mapping %d: %w", i, err)
+			}
+		}
+
+		h.mappings = append(h.mappings, mapping)
+	}
+
+	return h, nil
+}
+
+func (h *RuleHandler) Handle(ctx context.Context, objects []unstructured.Unstructured) (*v1.ClusterSpec, error) {
+	spec := map[string]interface{}{}
+
+	for _, obj := range objects {
+		match, err := h.matches(obj)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			continue
+		}
+
+		elements := map[string]map[string]interface{}{}
+		for _, m := range h.mappings {
+			value, found, err := m.value(obj)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				if m.optional {
+					continue
+				}
+				getErr := &ParseError{Fields: []string{m.expression}, Object: obj}
+				return nil, getErr.Wrap(fmt.Errorf("field not found"))
+			}
+
+			target := spec
+			if m.list != "" {
+				if _, ok := elements[m.list]; !ok {
+					elements[m.list] = map[string]interface{}{}
+				}
+				target = elements[m.list]
+			}
+			if err := unstructured.SetNestedField(target, value, m.fields...); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, l := range h.lists {
+			if element, ok := elements[l.path]; ok {
+				if err := mergeElement(spec, l, element); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	clusterSpec := new(v1.ClusterSpec)
+	if err := json.Unmarshal(b, clusterSpec); err != nil {
+		return nil, err
+	}
+
+	return clusterSpec, nil
+}
+
+func (h *RuleHandler) list(path string) *ruleList {
+	for i := range h.lists {
+		if h.lists[i].path == path {
+			return &h.lists[i]
+		}
+	}
+	return nil
+}
+
+// matches returns true if the object matches all the filters of the rule
+func (h *RuleHandler) matches(obj unstructured.Unstructured) (bool, error) {
+	for _, f := range h.filters {
+		results, err := findValues(obj, f.source)
+		if err != nil {
+			return false, (&ParseError{Fields: []string{f.expression}, Object: obj}).Wrap(err)
+		}
+		if len(results) != 1 {
+			return false, nil
+		}
+		value, ok := results[0].(string)
+		if !ok || !f.regex.MatchString(value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// value returns the value of the mapping for the object, converted to the unstructured representation of its type
+func (m *ruleMapping) value(obj unstructured.Unstructured) (interface{}, bool, error) {
+	getErr := &ParseError{Fields: []string{m.expression}, Object: obj}
+
+	results, err := findValues(obj, m.source)
+	if err != nil {
+		return nil, false, getErr.Wrap(err)
+	}
+	if len(results) == 0 {
+		return nil, false, nil
+	}
+
+	var value interface{}
+	switch m.valueType {
+	case configv1.ParserValueString:
+		value, err = stringValue(results)
+		if err == nil && m.regex != nil {
+			value = extractSubmatch(m.regex, value.(string))
+		}
+	case configv1.ParserValueInteger:
+		value, err = integerValue(results)
+	case configv1.ParserValueStringList:
+		value, err = stringListValue(results)
+	case configv1.ParserValueStringMap:
+		value, err = stringMapValue(results)
+	case configv1.ParserValueTaints:
+		value, err = taintsValue(results)
+	}
+	if err != nil {
+		return nil, false, getErr.Wrap(err)
+	}
+
+	return value, true, nil
+}
+
+// parseDestination splits the destination of a mapping into the path of its list, if any, and the path of its field
+func parseDestination(destination string) (string, []string, error) {
+	var list string
+	path := destination
+	if before, after, found := strings.Cut(destination, "[]"); found {
+		if before == "" || !strings.HasPrefix(after, ".") || strings.Contains(after, "[]") {
+			return "", nil, fmt.Errorf("%q is not of the form list[].field", destination)
+		}
+		list, path = before, strings.TrimPrefix(after, ".")
+	}
+
+	fields := strings.Split(path, ".")
+	for _, f := range fields {
+		if f == "" {
+			return "", nil, fmt.Errorf("%q has an empty field", destination)
+		}
+	}
+	return list, fields, nil
+}
+
+// mergeElement appends the element to its list in the cluster spec, or sets its fields on the element with the same
+// key if the list is keyed
+func mergeElement(spec map[string]interface{}, l ruleList, element map[string]interface{}) error {
+	fields := strings.Split(l.path, ".")
+	items, _, err := unstructured.NestedSlice(spec, fields...)
+	if err != nil {
+		return err
+	}
+
+	if key, ok := element[l.key]; ok && l.key != "" {
+		for i, item := range items {
+			existing, ok := item.(map[string]interface{})
+			if !ok || !reflect.DeepEqual(existing[l.key], key) {
+				continue
+			}
+			for k, v := range element {
+				existing[k] = v
+			}
+			items[i] = existing
+			return unstructured.SetNestedSlice(spec, items, fields...)
+		}
+	}
+
+	return unstructured.SetNestedSlice(spec, append(items, element), fields...)
+}
+
+func findValues(obj unstructured.Unstructured, source *jsonpath.JSONPath) ([]interface{}, error) {
+	results, err := source.FindResults(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	for _, result := range results {
+		for _, v := range result {
+			values = append(values, v.Interface())
+		}
+	}
+	return values, nil
+}
+
+func extractSubmatch(regex *regexp.Regexp, value string) string {
+	submatches := regex.FindStringSubmatch(value)
+	if len(submatches) < 2 {
+		return ""
+	}
+	return submatches[1]
+}
+
+func singleValue(results []interface{}) (interface{}, error) {
+	if len(results) != 1 {
+		return nil, fmt.Errorf("expected a single value, got %d", len(results))
+	}
+	return results[0], nil
+}
+
+func stringValue(results []interface{}) (interface{}, error) {
+	value, err := singleValue(results)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%v is of the type %T, expected string", value, value)
+	}
+	return s, nil
+}
+
+func integerValue(results []interface{}) (interface{}, error) {
+	value, err := singleValue(results)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v), nil
+		}
+	}
+	return nil, fmt.Errorf("%v is of the type %T, expected integer", value, value)
+}
+
+func stringListValue(results []interface{}) (interface{}, error) {
+	// a list selected as a whole, e.g. {.spec.cidrBlocks}, is a single result
+	if len(results) == 1 {
+		if items, ok := results[0].([]interface{}); ok {
+			results = items
+		}
+	}
+
+	list := make([]interface{}, 0, len(results))
+	for _, item := range results {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is of the type %T, expected string", item, item)
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+func stringMapValue(results []interface{}) (interface{}, error) {
+	value, err := singleValue(results)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v is of the type %T, expected map", value, value)
+	}
+
+	stringMap := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v is of the type %T, expected string", v, v)
+		}
+		stringMap[k] = s
+	}
+	return stringMap, nil
+}
+
+func taintsValue(results []interface{}) (interface{}, error) {
+	value, err := singleValue(results)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v is of the type %T, expected list", value, value)
+	}
+
+	var taints []interface{}
+	for _, item := range items {
+		taint := corev1.Taint{}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(b, &taint); err != nil {
+			return nil, err
+		}
+		taints = append(taints, taint.ToString())
+	}
+	return taints, nil
+}
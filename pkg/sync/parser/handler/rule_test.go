@@ -0,0 +1,230 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	configv1 "github.com/adobe/cluster-registry/pkg/api/config/v1"
+	v1 "github.com/adobe/cluster-registry/pkg/api/registry/v1"
+)
+
+// loadObjects reads the recorded objects of a fixture
+func loadObjects(t *testing.T, fixture string) []unstructured.Unstructured {
+	b, err := os.ReadFile(filepath.Join("testdata", fixture+".yaml"))
+	require.NoError(t, err)
+
+	// decode the objects like the API server responses, with integers as int64
+	var items []json.RawMessage
+	require.NoError(t, yaml.Unmarshal(b, &items))
+
+	objects := make([]unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		obj := unstructured.Unstructured{}
+		require.NoError(t, obj.UnmarshalJSON(item))
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// loadGolden reads the cluster spec expected from the objects of a fixture
+func loadGolden(t *testing.T, fixture string) *v1.ClusterSpec {
+	b, err := os.ReadFile(filepath.Join("testdata", fixture+".golden.json"))
+	require.NoError(t, err)
+
+	spec := new(v1.ClusterSpec)
+	require.NoError(t, json.Unmarshal(b, spec))
+	return spec
+}
+
+func loadRules(t *testing.T) map[string]configv1.ParserRule {
+	b, err := os.ReadFile(filepath.Join("testdata", "rules.yaml"))
+	require.NoError(t, err)
+
+	var rules []configv1.ParserRule
+	require.NoError(t, yaml.Unmarshal(b, &rules))
+
+	byKind := make(map[string]configv1.ParserRule, len(rules))
+	for _, rule := range rules {
+		byKind[rule.Kind] = rule
+	}
+	return byKind
+}
+
+func TestRuleHandlerGolden(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that the built-in handlers expressed as parser rules produce the same cluster spec.")
+
+	rules := loadRules(t)
+
+	tcs := []struct {
+		fixture string
+		kind    string
+		builtin ObjectHandler
+	}{
+		{fixture: "cluster", kind: "Cluster", builtin: &ClusterHandler{}},
+		{fixture: "vpc", kind: "VPC", builtin: &VPCHandler{}},
+		{fixture: "subnet", kind: "Subnet", builtin: &SubnetHandler{}},
+		{fixture: "awsmanagedcontrolplane", kind: "AWSManagedControlPlane", builtin: &AWSManagedControlPlaneHandler{}},
+		{fixture: "awsmanagedmachinepool", kind: "AWSManagedMachinePool", builtin: &AWSManagedMachinePoolHandler{}},
+		{fixture: "eksconfig", kind: "EKSConfig", builtin: &EKSConfigHandler{}},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen parsing the recorded objects", tc.fixture)
+
+		objects := loadObjects(t, tc.fixture)
+		expected := loadGolden(t, tc.fixture)
+
+		rule, ok := rules[tc.kind]
+		require.True(t, ok, "no rule for %s", tc.kind)
+		h, err := NewRuleHandler(rule)
+		require.NoError(t, err)
+
+		builtinSpec, err := tc.builtin.Handle(context.Background(), objects)
+		test.NoError(err)
+		test.Equal(expected, builtinSpec, "built-in handler of %s", tc.kind)
+
+		ruleSpec, err := h.Handle(context.Background(), objects)
+		test.NoError(err)
+		test.Equal(expected, ruleSpec, "rule handler of %s", tc.kind)
+	}
+}
+
+func TestRuleHandlerMissingField(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test that a missing value is an error unless its mapping is optional.")
+
+	objects := []unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Example",
+		"metadata":   map[string]interface{}{"name": "example", "namespace": "default"},
+		"spec":       map[string]interface{}{"region": "us-east-1"},
+	}}}
+
+	h, err := NewRuleHandler(configv1.ParserRule{
+		Mappings: []configv1.ParserMapping{
+			{Source: "{.spec.region}", Destination: "cloudProviderRegion"},
+			{Source: "{.spec.environment}", Destination: "environment"},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = h.Handle(context.Background(), objects)
+	var parseErr *ParseError
+	test.ErrorAs(err, &parseErr)
+	test.Equal([]string{"{.spec.environment}"}, parseErr.Fields)
+
+	h, err = NewRuleHandler(configv1.ParserRule{
+		Mappings: []configv1.ParserMapping{
+			{Source: "{.spec.region}", Destination: "cloudProviderRegion"},
+			{Source: "{.spec.environment}", Destination: "environment", Optional: true},
+		},
+	})
+	require.NoError(t, err)
+
+	spec, err := h.Handle(context.Background(), objects)
+	test.NoError(err)
+	test.Equal(&v1.ClusterSpec{CloudProviderRegion: "us-east-1"}, spec)
+}
+
+func TestNewRuleHandler(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test validating the parser rules.")
+
+	tcs := []struct {
+		name          string
+		rule          configv1.ParserRule
+		expectedError bool
+	}{
+		{
+			name: "valid rule",
+			rule: configv1.ParserRule{
+				Filters: []configv1.ParserFilter{{Source: "metadata.name", Regex: "^private-"}},
+				Lists:   []configv1.ParserList{{Path: "tiers", Key: "name"}},
+				Mappings: []configv1.ParserMapping{
+					{Source: "metadata.name", Destination: "tiers[].name"},
+					{Source: "spec.size", Destination: "tiers[].maxCapacity", Type: configv1.ParserValueInteger},
+				},
+			},
+		},
+		{
+			name:          "no mappings",
+			rule:          configv1.ParserRule{},
+			expectedError: true,
+		},
+		{
+			name: "invalid source",
+			rule: configv1.ParserRule{
+				Mappings: []configv1.ParserMapping{{Source: "{.spec[", Destination: "region"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid destination",
+			rule: configv1.ParserRule{
+				Mappings: []configv1.ParserMapping{{Source: "spec.name", Destination: "tiers[]"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "nested list destination",
+			rule: configv1.ParserRule{
+				Mappings: []configv1.ParserMapping{{Source: "spec.name", Destination: "tiers[].taints[].key"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid type",
+			rule: configv1.ParserRule{
+				Mappings: []configv1.ParserMapping{{Source: "spec.name", Destination: "region", Type: "boolean"}},
+			},
+			expectedError: true,
+		},
+		{
+			name: "regex of a non string",
+			rule: configv1.ParserRule{
+				Mappings: []configv1.ParserMapping{
+					{Source: "spec.size", Destination: "tiers[].maxCapacity", Type: configv1.ParserValueInteger, Regex: "(.*)"},
+				},
+			},
+			expectedError: true,
+		},
+		{
+			name: "invalid filter regex",
+			rule: configv1.ParserRule{
+				Filters:  []configv1.ParserFilter{{Source: "metadata.name", Regex: "("}},
+				Mappings: []configv1.ParserMapping{{Source: "spec.name", Destination: "region"}},
+			},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Logf("\tTest %s:\tWhen creating the handler of the rule", tc.name)
+		_, err := NewRuleHandler(tc.rule)
+		test.Equal(tc.expectedError, err != nil, "%s: %v", tc.name, err)
+	}
+}
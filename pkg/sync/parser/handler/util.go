@@ -91,3 +91,9 @@ func getNestedSlice(obj unstructured.Unstructured, fields ...string) ([]interfac
 	}
 	return value, nil
 }
+
+// hasNestedField returns true if the field is set, for the fields that are optional
+func hasNestedField(obj unstructured.Unstructured, fields ...string) bool {
+	value, found, err := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	return err == nil && found && value != nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Adobe. All rights reserved.
+This file is licensed to you under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License. You may obtain a copy
+of the License at http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software distributed under
+the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR REPRESENTATIONS
+OF ANY KIND, either express or implied. See the License for the specific language
+governing permissions and limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test setting up tracing when disabled.")
+
+	shutdown, err := Setup(context.Background(), Config{Enabled: false})
+	test.NoError(err)
+	test.NoError(shutdown(context.Background()))
+
+	fields := otel.GetTextMapPropagator().Fields()
+	test.Contains(fields, "traceparent")
+	test.Contains(fields, "baggage")
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test sampling of the spans.")
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01},
+		SpanID:     trace.SpanID{0x01},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+
+	tcs := []struct {
+		name            string
+		sampleRatio     float64
+		ctx             context.Context
+		expectedSampled bool
+	}{
+		{
+			name:            "root span always sampled",
+			sampleRatio:     1,
+			ctx:             context.Background(),
+			expectedSampled: true,
+		},
+		{
+			name:            "root span never sampled",
+			sampleRatio:     0,
+			ctx:             context.Background(),
+			expectedSampled: false,
+		},
+		{
+			name:            "child of a sampled remote span",
+			sampleRatio:     0,
+			ctx:             trace.ContextWithRemoteSpanContext(context.Background(), parent),
+			expectedSampled: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), "cluster-registry-test", tc.sampleRatio)
+
+		t.Logf("\tTest %s:\tWhen sampling with ratio %v", tc.name, tc.sampleRatio)
+
+		_, span := tp.Tracer(instrumentationName).Start(tc.ctx, "test")
+		span.End()
+
+		test.Equal(tc.expectedSampled, span.SpanContext().IsSampled())
+		if tc.expectedSampled {
+			test.Len(exporter.GetSpans(), 1)
+		} else {
+			test.Empty(exporter.GetSpans())
+		}
+		test.NoError(tp.Shutdown(context.Background()))
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	test := assert.New(t)
+
+	t.Log("Test recording errors on spans.")
+
+	tcs := []struct {
+		name           string
+		err            error
+		expectedStatus codes.Code
+		expectedEvents int
+	}{
+		{
+			name:           "no error",
+			err:            nil,
+			expectedStatus: codes.Unset,
+			expectedEvents: 0,
+		},
+		{
+			name:           "error",
+			err:            fmt.Errorf("connection refused"),
+			expectedStatus: codes.Error,
+			expectedEvents: 1,
+		},
+	}
+
+	for _, tc := range tcs {
+		exporter := tracetest.NewInMemoryExporter()
+		tp := NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), "cluster-registry-test", 1)
+
+		t.Logf("\tTest %s:\tWhen recording error %v", tc.name, tc.err)
+
+		_, span := tp.Tracer(instrumentationName).Start(context.Background(), "test")
+		RecordError(span, tc.err)
+		span.End()
+
+		spans := exporter.GetSpans()
+		test.Len(spans, 1)
+		test.Equal(tc.expectedStatus, spans[0].Status.Code)
+		test.Len(spans[0].Events, tc.expectedEvents)
+		test.NoError(tp.Shutdown(context.Background()))
+	}
+}
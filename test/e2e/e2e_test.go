@@ -202,7 +202,7 @@ func (s *e2eTestSuite) Test_EndToEnd_CreateCluster() {
 			s.T().Logf("Successfully delete cluster %s from k8s api.", inputCluster.Spec.Name)
 
 			d := database.NewDb(appConfig, m)
-			err := d.DeleteCluster(inputCluster.Spec.Name)
+			err := d.DeleteCluster(context.TODO(), inputCluster.Spec.Name)
 			if err != nil {
 				s.T().Fatalf("Error wihle trying to delete the cluster from database: %v", err.Error())
 			}